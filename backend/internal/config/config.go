@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -28,6 +29,49 @@ type Config struct {
 
 	// CORS
 	AllowedOrigins []string
+
+	// Credential health
+	PasswordMaxAgeDays int // Passwords not changed for longer are flagged as stale
+
+	// Trash
+	TrashRetentionDays int // Deleted users and credentials are purged after this many days
+
+	// Password reveal
+	RevealRateLimit     int // Reveal requests allowed per user per minute
+	ReauthWindowMinutes int // Tokens younger than this can reveal without the account password
+
+	// Share links
+	PublicURL      string // Base URL of this API as seen by recipients of share links
+	ShareRateLimit int    // Public share link requests allowed per IP per minute
+
+	// Idempotency keys
+	IdempotencyKeyTTLHours int // Responses are replayed for retries with the same Idempotency-Key this long
+
+	// Email (optional, emails are written to the log when SMTPHost is unset)
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	// Plan quotas, 0 means unlimited
+	PlanFreeMaxCredentials       int
+	PlanFreeMaxShareLinks        int
+	PlanFreeMaxExportsPerDay     int
+	PlanProMaxCredentials        int
+	PlanProMaxShareLinks         int
+	PlanProMaxExportsPerDay      int
+	PlanBusinessMaxCredentials   int
+	PlanBusinessMaxShareLinks    int
+	PlanBusinessMaxExportsPerDay int
+
+	// Apple Wallet passes (optional, pass download is disabled when unset)
+	PassTypeIdentifier   string
+	PassTeamIdentifier   string
+	PassOrganizationName string
+	PassCertPath         string // PEM encoded Pass Type ID certificate
+	PassKeyPath          string // PEM encoded private key for the certificate
+	PassWWDRCertPath     string // PEM encoded Apple WWDR intermediate certificate
 }
 
 // Load reads configuration from environment variables
@@ -53,6 +97,49 @@ func Load() *Config {
 
 		// CORS
 		AllowedOrigins: parseAllowedOrigins(getEnv("ALLOWED_ORIGINS", "http://localhost:4200")),
+
+		// Credential health
+		PasswordMaxAgeDays: getEnvInt("PASSWORD_MAX_AGE_DAYS", 180),
+
+		// Trash
+		TrashRetentionDays: getEnvInt("TRASH_RETENTION_DAYS", 30),
+
+		// Password reveal
+		RevealRateLimit:     getEnvInt("REVEAL_RATE_LIMIT", 5),
+		ReauthWindowMinutes: getEnvInt("REAUTH_WINDOW_MINUTES", 5),
+
+		// Share links
+		PublicURL:      strings.TrimRight(getEnv("PUBLIC_URL", "http://localhost:8080"), "/"),
+		ShareRateLimit: getEnvInt("SHARE_RATE_LIMIT", 30),
+
+		// Idempotency keys
+		IdempotencyKeyTTLHours: getEnvInt("IDEMPOTENCY_KEY_TTL_HOURS", 24),
+
+		// Email
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "WiFi QR <no-reply@localhost>"),
+
+		// Plan quotas
+		PlanFreeMaxCredentials:       getEnvInt("PLAN_FREE_MAX_CREDENTIALS", 25),
+		PlanFreeMaxShareLinks:        getEnvInt("PLAN_FREE_MAX_SHARE_LINKS", 5),
+		PlanFreeMaxExportsPerDay:     getEnvInt("PLAN_FREE_MAX_EXPORTS_PER_DAY", 10),
+		PlanProMaxCredentials:        getEnvInt("PLAN_PRO_MAX_CREDENTIALS", 500),
+		PlanProMaxShareLinks:         getEnvInt("PLAN_PRO_MAX_SHARE_LINKS", 100),
+		PlanProMaxExportsPerDay:      getEnvInt("PLAN_PRO_MAX_EXPORTS_PER_DAY", 100),
+		PlanBusinessMaxCredentials:   getEnvInt("PLAN_BUSINESS_MAX_CREDENTIALS", 0),
+		PlanBusinessMaxShareLinks:    getEnvInt("PLAN_BUSINESS_MAX_SHARE_LINKS", 0),
+		PlanBusinessMaxExportsPerDay: getEnvInt("PLAN_BUSINESS_MAX_EXPORTS_PER_DAY", 0),
+
+		// Apple Wallet
+		PassTypeIdentifier:   getEnv("PASS_TYPE_IDENTIFIER", ""),
+		PassTeamIdentifier:   getEnv("PASS_TEAM_IDENTIFIER", ""),
+		PassOrganizationName: getEnv("PASS_ORGANIZATION_NAME", "WiFi QR"),
+		PassCertPath:         getEnv("PASS_CERT_PATH", ""),
+		PassKeyPath:          getEnv("PASS_KEY_PATH", ""),
+		PassWWDRCertPath:     getEnv("PASS_WWDR_CERT_PATH", ""),
 	}
 
 	// Validate required configuration
@@ -78,6 +165,39 @@ func (c *Config) validate() {
 	if len(c.EncryptionKey) != 32 {
 		log.Fatal("ENCRYPTION_KEY must be exactly 32 characters (256 bits) for AES-256")
 	}
+
+	if c.TrashRetentionDays < 1 {
+		log.Fatal("TRASH_RETENTION_DAYS must be at least 1")
+	}
+
+	if c.RevealRateLimit < 1 {
+		log.Fatal("REVEAL_RATE_LIMIT must be at least 1")
+	}
+
+	if c.ShareRateLimit < 1 {
+		log.Fatal("SHARE_RATE_LIMIT must be at least 1")
+	}
+
+	if c.IdempotencyKeyTTLHours < 1 {
+		log.Fatal("IDEMPOTENCY_KEY_TTL_HOURS must be at least 1")
+	}
+
+	quotas := map[string]int{
+		"PLAN_FREE_MAX_CREDENTIALS":         c.PlanFreeMaxCredentials,
+		"PLAN_FREE_MAX_SHARE_LINKS":         c.PlanFreeMaxShareLinks,
+		"PLAN_FREE_MAX_EXPORTS_PER_DAY":     c.PlanFreeMaxExportsPerDay,
+		"PLAN_PRO_MAX_CREDENTIALS":          c.PlanProMaxCredentials,
+		"PLAN_PRO_MAX_SHARE_LINKS":          c.PlanProMaxShareLinks,
+		"PLAN_PRO_MAX_EXPORTS_PER_DAY":      c.PlanProMaxExportsPerDay,
+		"PLAN_BUSINESS_MAX_CREDENTIALS":     c.PlanBusinessMaxCredentials,
+		"PLAN_BUSINESS_MAX_SHARE_LINKS":     c.PlanBusinessMaxShareLinks,
+		"PLAN_BUSINESS_MAX_EXPORTS_PER_DAY": c.PlanBusinessMaxExportsPerDay,
+	}
+	for key, value := range quotas {
+		if value < 0 {
+			log.Fatalf("%s must not be negative (0 means unlimited)", key)
+		}
+	}
 }
 
 // getEnv retrieves an environment variable or returns a default value
@@ -89,6 +209,20 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvInt retrieves an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be an integer: %v", key, err)
+	}
+	return parsed
+}
+
 // parseAllowedOrigins parses comma-separated origins
 func parseAllowedOrigins(origins string) []string {
 	if origins == "" {
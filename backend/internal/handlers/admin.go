@@ -1,24 +1,35 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/models"
 	"gin-quickstart/internal/repositories"
+	"gin-quickstart/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // AdminHandler handles admin-only endpoints
 type AdminHandler struct {
-	userRepo *repositories.UserRepository
-	wifiRepo *repositories.WifiRepository
+	userRepo    *repositories.UserRepository
+	wifiRepo    *repositories.WifiRepository
+	auditRepo   *repositories.AuditLogRepository
+	wifiService *services.WifiService
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(userRepo *repositories.UserRepository, wifiRepo *repositories.WifiRepository) *AdminHandler {
+func NewAdminHandler(userRepo *repositories.UserRepository, wifiRepo *repositories.WifiRepository, auditRepo *repositories.AuditLogRepository, wifiService *services.WifiService) *AdminHandler {
 	return &AdminHandler{
-		userRepo: userRepo,
-		wifiRepo: wifiRepo,
+		userRepo:    userRepo,
+		wifiRepo:    wifiRepo,
+		auditRepo:   auditRepo,
+		wifiService: wifiService,
 	}
 }
 
@@ -50,18 +61,163 @@ func (h *AdminHandler) GetAllUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, publicUsers)
 }
 
-// GetAllCredentials handles retrieving all WiFi credentials (admin only)
+// DeleteUser handles moving a user and their WiFi credentials to the trash (admin only)
+// @Summary Delete user
+// @Description The user and the credentials deleted with them can be restored until the trash retention period ends
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id} [delete]
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	// Admins can't lock themselves out
+	if currentUserID, ok := middleware.GetUserID(c); ok && currentUserID == id {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "You can't delete your own account",
+		})
+		return
+	}
+
+	if err := h.userRepo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "User not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to delete user",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDeletedUsers handles retrieving the users in the trash (admin only)
+// @Summary Get deleted users
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.PublicUser
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/users/trash [get]
+func (h *AdminHandler) GetDeletedUsers(c *gin.Context) {
+	users, err := h.userRepo.FindDeleted()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve deleted users",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Convert to public format
+	publicUsers := make([]*models.PublicUser, 0, len(users))
+	for _, user := range users {
+		publicUsers = append(publicUsers, user.ToPublic())
+	}
+
+	c.JSON(http.StatusOK, publicUsers)
+}
+
+// RestoreUser handles taking a user and the credentials deleted with them out of the trash (admin only)
+// @Summary Restore deleted user
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/restore [post]
+func (h *AdminHandler) RestoreUser(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.userRepo.Restore(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deleted user not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to restore user",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAllCredentials handles retrieving all WiFi credentials, one page at a time (admin only)
 // @Summary Get all WiFi credentials
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.PublicWifiCredential
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort key: created_at, updated_at or ssid"
+// @Param order query string false "asc or desc"
+// @Param security_type query string false "Filter by security type"
+// @Param is_hidden query bool false "Filter by hidden flag"
+// @Param q query string false "Fuzzy SSID search"
+// @Param tag query []string false "Tag ID, repeat to require several tags"
+// @Param location_id query string false "Location ID, includes nested locations"
+// @Param organization_id query string false "Only this organization's credentials"
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
 // @Router /api/admin/credentials [get]
 func (h *AdminHandler) GetAllCredentials(c *gin.Context) {
-	credentials, err := h.wifiRepo.GetAll()
+	var req services.ListWifiRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.wifiService.List(nil, &req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) || errors.Is(err, services.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid query parameters",
+				Message: err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to retrieve WiFi credentials",
 			Message: err.Error(),
@@ -71,34 +227,39 @@ func (h *AdminHandler) GetAllCredentials(c *gin.Context) {
 
 	// Convert to public format with user info
 	type CredentialWithUser struct {
-		ID           string `json:"id"`
-		UserID       string `json:"user_id"`
-		UserEmail    string `json:"user_email"`
-		SSID         string `json:"ssid"`
-		SecurityType string `json:"security_type"`
-		IsHidden     bool   `json:"is_hidden"`
-		CreatedAt    string `json:"created_at"`
+		ID             string     `json:"id"`
+		UserID         string     `json:"user_id"`
+		UserEmail      string     `json:"user_email"`
+		OrganizationID *uuid.UUID `json:"organization_id"`
+		SSID           string     `json:"ssid"`
+		SecurityType   string     `json:"security_type"`
+		IsHidden       bool       `json:"is_hidden"`
+		CreatedAt      string     `json:"created_at"`
 	}
 
-	publicCredentials := make([]CredentialWithUser, 0, len(credentials))
-	for _, cred := range credentials {
+	publicCredentials := make([]CredentialWithUser, 0, len(result.Credentials))
+	for _, cred := range result.Credentials {
 		userEmail := ""
 		if cred.User != nil {
 			userEmail = cred.User.Email
 		}
 
 		publicCredentials = append(publicCredentials, CredentialWithUser{
-			ID:           cred.ID.String(),
-			UserID:       cred.UserID.String(),
-			UserEmail:    userEmail,
-			SSID:         cred.SSID,
-			SecurityType: string(cred.SecurityType),
-			IsHidden:     cred.IsHidden,
-			CreatedAt:    cred.CreatedAt.Format("2006-01-02 15:04:05"),
+			ID:             cred.ID.String(),
+			UserID:         cred.UserID.String(),
+			UserEmail:      userEmail,
+			OrganizationID: cred.OrganizationID,
+			SSID:           cred.SSID,
+			SecurityType:   string(cred.SecurityType),
+			IsHidden:       cred.IsHidden,
+			CreatedAt:      cred.CreatedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
 
-	c.JSON(http.StatusOK, publicCredentials)
+	c.JSON(http.StatusOK, ListResponse{
+		Data:       publicCredentials,
+		Pagination: result.Pagination,
+	})
 }
 
 // GetStats handles retrieving system statistics (admin only)
@@ -144,3 +305,35 @@ type StatsResponse struct {
 	TotalUsers       int `json:"total_users"`
 	TotalCredentials int `json:"total_credentials"`
 }
+
+// GetAuditLogs handles retrieving the most recent audit log entries (admin only)
+// @Summary Get audit logs
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of entries (default 100, max 500)"
+// @Success 200 {array} models.AuditLog
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/audit-logs [get]
+func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid limit",
+			Message: "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	entries, err := h.auditRepo.GetRecent(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve audit logs",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBackupBytes limits the size of an uploaded backup file
+const maxBackupBytes = 10 << 20
+
+// BackupHandler handles encrypted backup endpoints
+type BackupHandler struct {
+	backupService *services.BackupService
+}
+
+// NewBackupHandler creates a new backup handler
+func NewBackupHandler(backupService *services.BackupService) *BackupHandler {
+	return &BackupHandler{backupService: backupService}
+}
+
+// Export handles downloading an encrypted backup of the current user's personal credentials
+// @Summary Export encrypted backup
+// @Description The file holds every personal credential with its password, encrypted with AES-256-GCM under an Argon2id key derived from the passphrase. Like revealing a password, it requires the account password unless the token was issued within the re-authentication window. Counts against the daily export limit.
+// @Tags backup
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.ExportBackupRequest true "Passphrase (at least 12 characters) and account password"
+// @Success 200 {object} services.BackupFile
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/backup/export [post]
+func (h *BackupHandler) Export(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.ExportBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	issuedAt, _ := middleware.GetTokenIssuedAt(c)
+	backup, err := h.backupService.Export(services.ExportContext{
+		UserID:        userID,
+		TokenIssuedAt: issuedAt,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	}, &req)
+	if err != nil {
+		if respondReauthError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to export backup",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("wifiqr-backup-%s.json", time.Now().UTC().Format("2006-01-02"))
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/json", backup)
+}
+
+// Restore handles importing the credentials of an encrypted backup
+// @Summary Restore encrypted backup
+// @Description Credentials are re-encrypted under the server's key and created like a file import; existing credentials are left alone.
+// @Tags backup
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Backup file"
+// @Param passphrase formData string true "Passphrase the backup was exported with"
+// @Param mode query string false "atomic or partial"
+// @Param dry_run query bool false "Validate every credential without saving anything"
+// @Param organization_id query string false "Restore into this organization"
+// @Success 200 {object} services.ImportWifiResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} services.ImportWifiResult
+// @Failure 429 {object} ErrorResponse
+// @Router /api/backup/restore [post]
+func (h *BackupHandler) Restore(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var opts services.ImportWifiOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+		return
+	}
+	organizationID, ok := organizationQuery(c)
+	if !ok {
+		return
+	}
+	opts.OrganizationID = organizationID
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupBytes)
+	passphrase := c.PostForm("passphrase")
+	header, err := c.FormFile("file")
+	if err != nil || passphrase == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "A multipart file field and a passphrase field are required",
+		})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid backup file",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid backup file",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.backupService.Restore(userID, data, passphrase, opts)
+	if err != nil {
+		if respondOrganizationError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidBackup) || errors.Is(err, services.ErrBackupPassphrase) ||
+			errors.Is(err, services.ErrImportEmpty) || errors.Is(err, services.ErrImportTooLarge) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Failed to restore backup",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to restore backup",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// An atomic restore with invalid credentials saved nothing
+	status := http.StatusOK
+	if !result.DryRun && result.Mode == services.ImportModeAtomic && result.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, result)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles exporting credentials for other password managers
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// Export handles downloading the current user's personal credentials in a password manager format
+// @Summary Export WiFi credentials
+// @Description The file contains the passwords unencrypted. Import it into Bitwarden (JSON), KeePass (XML 2.x) or 1Password (CSV). Like revealing a password, it requires the account password unless the token was issued within the re-authentication window. Counts against the daily export limit.
+// @Tags wifi
+// @Accept json
+// @Produce json,xml,text/csv
+// @Security BearerAuth
+// @Param request body services.ExportWifiRequest true "Format (bitwarden, keepass or 1password) and account password"
+// @Success 200 {file} binary
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/wifi/export [post]
+func (h *ExportHandler) Export(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.ExportWifiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	issuedAt, _ := middleware.GetTokenIssuedAt(c)
+	file, err := h.exportService.Export(services.ExportContext{
+		UserID:        userID,
+		TokenIssuedAt: issuedAt,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	}, &req)
+	if err != nil {
+		if respondReauthError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrUnsupportedExportFormat) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid format",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to export WiFi credentials",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GrantHandler handles sharing WiFi credentials with other users
+type GrantHandler struct {
+	grantService *services.GrantService
+}
+
+// NewGrantHandler creates a new grant handler
+func NewGrantHandler(grantService *services.GrantService) *GrantHandler {
+	return &GrantHandler{grantService: grantService}
+}
+
+// GetAll handles listing who a WiFi credential is shared with
+// @Summary Get credential grants
+// @Tags grants
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Success 200 {array} models.PublicCredentialGrant
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/grants [get]
+func (h *GrantHandler) GetAll(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	grants, err := h.grantService.ListGrants(id, userID, middleware.IsAdmin(c))
+	if err != nil {
+		h.handleError(c, err, "Failed to retrieve grants")
+		return
+	}
+
+	publicGrants := make([]*models.PublicCredentialGrant, len(grants))
+	for i := range grants {
+		publicGrants[i] = grants[i].ToPublic()
+	}
+
+	c.JSON(http.StatusOK, publicGrants)
+}
+
+// Grant handles giving another user access to a WiFi credential
+// @Summary Share credential with a user
+// @Description Grants view, reveal or edit access; granting again changes the permission
+// @Tags grants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param request body services.GrantRequest true "User email and permission"
+// @Success 200 {object} models.PublicCredentialGrant
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/grants [post]
+func (h *GrantHandler) Grant(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.GrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	grant, err := h.grantService.Grant(id, userID, middleware.IsAdmin(c), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to share WiFi credential")
+		return
+	}
+
+	c.JSON(http.StatusOK, grant.ToPublic())
+}
+
+// Revoke handles removing a user's access to a WiFi credential
+// @Summary Revoke credential grant
+// @Description Owners can revoke any grant; grantees can remove their own
+// @Tags grants
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param userId path string true "Grantee user ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/grants/{userId} [delete]
+func (h *GrantHandler) Revoke(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	granteeID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.grantService.Revoke(id, granteeID, userID, middleware.IsAdmin(c)); err != nil {
+		h.handleError(c, err, "Failed to revoke grant")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleError maps grant service errors to responses
+func (h *GrantHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWifiNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "WiFi credential not found",
+		})
+	case errors.Is(err, services.ErrGrantNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Grant not found",
+		})
+	case errors.Is(err, services.ErrUnauthorizedAccess):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Only the owner can manage who this WiFi credential is shared with",
+		})
+	case errors.Is(err, services.ErrGranteeNotFound), errors.Is(err, services.ErrCannotGrantOwner):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
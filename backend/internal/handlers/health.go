@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler handles credential security health endpoints
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// GetUserReport handles the security health report for the current user's credentials
+// @Summary Get credential health report
+// @Tags wifi
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.HealthReport
+// @Failure 401 {object} ErrorResponse
+// @Router /api/wifi/health [get]
+func (h *HealthHandler) GetUserReport(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	report, err := h.healthService.UserReport(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to build health report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSystemReport handles the system-wide security health report (admin only)
+// @Summary Get system-wide credential health report
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.HealthReport
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/health [get]
+func (h *HealthHandler) GetSystemReport(c *gin.Context) {
+	report, err := h.healthService.SystemReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to build health report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// kioskRefreshInterval is how often a kiosk stream reloads its frame without being told
+// about a change. It catches validity windows opening and closing, changes made by
+// other server instances, and keeps proxies from closing the idle connection.
+const kioskRefreshInterval = 30 * time.Second
+
+var kioskPageTemplate = template.Must(template.ParseFS(templateFS, "templates/kiosk.html"))
+
+// kioskPageData is rendered by the public kiosk page
+type kioskPageData struct {
+	Unavailable bool
+	Error       string
+	Nonce       string // Allows the page's script under the Content-Security-Policy
+}
+
+// Kiosk handles the public kiosk page of a kiosk link. Browsers get a full-screen page
+// that connects back to the same URL for a server-sent event stream, which sends a "qr"
+// event with the current frame on connect and whenever the credential is edited,
+// rotated or its validity window opens or closes. An "unavailable" event ends the
+// stream once the link is revoked or expires, or the credential is moved to the trash.
+// @Summary Open kiosk link
+// @Description Public; an HTML page, or with Accept: text/event-stream a stream of services.KioskFrame "qr" events
+// @Tags shares
+// @Produce html
+// @Produce text/event-stream
+// @Param token path string true "Kiosk token"
+// @Success 200 {object} services.KioskFrame
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /kiosk/{token} [get]
+func (h *ShareHandler) Kiosk(c *gin.Context) {
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		h.kioskStream(c)
+		return
+	}
+
+	if _, err := h.shareService.LookupKiosk(c.Param("token")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrShareLinkNotFound):
+			h.renderKioskPage(c, http.StatusNotFound, &kioskPageData{Unavailable: true, Error: "This link doesn't exist."})
+		case errors.Is(err, services.ErrShareLinkUnavailable):
+			h.renderKioskPage(c, http.StatusGone, &kioskPageData{Unavailable: true, Error: "This link has expired or has been revoked."})
+		default:
+			h.renderKioskPage(c, http.StatusInternalServerError, &kioskPageData{Unavailable: true, Error: "Something went wrong. Please try again later."})
+		}
+		return
+	}
+
+	h.renderKioskPage(c, http.StatusOK, &kioskPageData{})
+}
+
+// kioskStream streams the frames of a kiosk link until the client disconnects or the
+// link becomes unavailable
+func (h *ShareHandler) kioskStream(c *gin.Context) {
+	link, changes, unsubscribe, err := h.shareService.OpenKiosk(c.Param("token"))
+	if err != nil {
+		h.handleViewerError(c, err)
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	ticker := time.NewTicker(kioskRefreshInterval)
+	defer ticker.Stop()
+
+	var last *services.KioskFrame
+	for {
+		frame, err := h.shareService.KioskFrame(link.ID, time.Now())
+		if err != nil {
+			message := "This link has expired or has been revoked."
+			if !errors.Is(err, services.ErrShareLinkNotFound) && !errors.Is(err, services.ErrShareLinkUnavailable) {
+				message = "Something went wrong. Please try again later."
+			}
+			c.SSEvent("unavailable", gin.H{"error": message})
+			c.Writer.Flush()
+			return
+		}
+
+		// Only send frames that changed; a comment keeps the connection alive otherwise
+		if last == nil || frame.Version != last.Version || frame.Status != last.Status {
+			c.SSEvent("qr", frame)
+		} else if _, err := io.WriteString(c.Writer, ": keepalive\n\n"); err != nil {
+			return
+		}
+		c.Writer.Flush()
+		last = frame
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-changes:
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderKioskPage writes the kiosk page with the same headers as the share page, allowing
+// only its own script and connections back to this server
+func (h *ShareHandler) renderKioskPage(c *gin.Context, status int, data *kioskPageData) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page")
+		return
+	}
+	data.Nonce = base64.StdEncoding.EncodeToString(nonce)
+
+	var buf bytes.Buffer
+	if err := kioskPageTemplate.Execute(&buf, data); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Content-Security-Policy", "default-src 'none'; img-src data:; style-src 'unsafe-inline'; script-src 'nonce-"+data.Nonce+"'; connect-src 'self'")
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
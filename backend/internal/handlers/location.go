@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LocationHandler handles location endpoints
+type LocationHandler struct {
+	locationService *services.LocationService
+}
+
+// NewLocationHandler creates a new location handler
+func NewLocationHandler(locationService *services.LocationService) *LocationHandler {
+	return &LocationHandler{locationService: locationService}
+}
+
+// GetAll handles retrieving the current user's or an organization's locations
+// @Summary Get locations
+// @Description Returns a flat list; parent_id links sites, buildings, floors and folders into a tree
+// @Tags locations
+// @Produce json
+// @Security BearerAuth
+// @Param organization_id query string false "List an organization's locations instead of personal ones"
+// @Success 200 {array} models.Location
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/locations [get]
+func (h *LocationHandler) GetAll(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	organizationID, ok := organizationQuery(c)
+	if !ok {
+		return
+	}
+
+	locations, err := h.locationService.GetAll(userID, organizationID)
+	if err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve locations",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// Create handles creating a location
+// @Summary Create location
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.LocationRequest true "Location details"
+// @Success 201 {object} models.Location
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/locations [post]
+func (h *LocationHandler) Create(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	location, err := h.locationService.Create(userID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create location")
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+}
+
+// Update handles renaming or moving a location
+// @Summary Update location
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Location ID"
+// @Param request body services.LocationRequest true "Location details"
+// @Success 200 {object} models.Location
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/locations/{id} [put]
+func (h *LocationHandler) Update(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	location, err := h.locationService.Update(id, userID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update location")
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// Delete handles deleting an empty location
+// @Summary Delete location
+// @Description Credentials in the location become unassigned; locations that still contain others can't be deleted
+// @Tags locations
+// @Security BearerAuth
+// @Param id path string true "Location ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/locations/{id} [delete]
+func (h *LocationHandler) Delete(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.locationService.Delete(id, userID); err != nil {
+		h.handleError(c, err, "Failed to delete location")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleError maps location service errors to responses
+func (h *LocationHandler) handleError(c *gin.Context, err error, message string) {
+	if respondOrganizationError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrLocationNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Location not found",
+		})
+	case errors.Is(err, services.ErrLocationHasChildren):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidLocationKind),
+		errors.Is(err, services.ErrInvalidLocationParent),
+		errors.Is(err, services.ErrLocationCycle):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
@@ -0,0 +1,636 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OrganizationHandler handles organizations, their members and invitations
+type OrganizationHandler struct {
+	orgService *services.OrganizationService
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(orgService *services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{orgService: orgService}
+}
+
+// GetAll handles listing the organizations the current user belongs to
+// @Summary Get organizations
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.PublicOrganization
+// @Failure 401 {object} ErrorResponse
+// @Router /api/organizations [get]
+func (h *OrganizationHandler) GetAll(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	memberships, err := h.orgService.ListForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve organizations",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	organizations := make([]*models.PublicOrganization, len(memberships))
+	for i := range memberships {
+		organizations[i] = memberships[i].Organization.ToPublic(memberships[i].Role)
+	}
+
+	c.JSON(http.StatusOK, organizations)
+}
+
+// Create handles creating an organization
+// @Summary Create organization
+// @Description The creator becomes its first owner
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.OrganizationRequest true "Organization details"
+// @Success 201 {object} models.PublicOrganization
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/organizations [post]
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	organization, err := h.orgService.Create(userID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create organization")
+		return
+	}
+
+	c.JSON(http.StatusCreated, organization.ToPublic(models.OrgRoleOwner))
+}
+
+// GetByID handles retrieving an organization
+// @Summary Get organization
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} models.PublicOrganization
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/organizations/{id} [get]
+func (h *OrganizationHandler) GetByID(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	organization, role, err := h.orgService.Get(id, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to retrieve organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, organization.ToPublic(role))
+}
+
+// Update handles renaming an organization
+// @Summary Update organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body services.OrganizationRequest true "Organization details"
+// @Success 200 {object} models.PublicOrganization
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/organizations/{id} [put]
+func (h *OrganizationHandler) Update(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	organization, role, err := h.orgService.Update(id, userID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, organization.ToPublic(role))
+}
+
+// Delete handles deleting an organization
+// @Summary Delete organization
+// @Description Owners only. The organization must have no credentials left, including in its trash.
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/organizations/{id} [delete]
+func (h *OrganizationHandler) Delete(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.orgService.Delete(id, userID); err != nil {
+		h.handleError(c, err, "Failed to delete organization")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMembers handles listing the members of an organization
+// @Summary Get organization members
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {array} models.PublicMember
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/organizations/{id}/members [get]
+func (h *OrganizationHandler) GetMembers(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	memberships, err := h.orgService.ListMembers(id, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to retrieve members")
+		return
+	}
+
+	members := make([]*models.PublicMember, len(memberships))
+	for i := range memberships {
+		members[i] = memberships[i].ToPublicMember()
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// UpdateMember handles changing a member's role
+// @Summary Change member role
+// @Description Admins manage admins, members and viewers; only owners can promote or demote owners
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param userId path string true "Member user ID"
+// @Param request body services.UpdateMemberRequest true "New role"
+// @Success 200 {object} models.Membership
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/organizations/{id}/members/{userId} [put]
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	membership, err := h.orgService.UpdateMemberRole(id, memberID, userID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update member")
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+// RemoveMember handles removing a member, or leaving an organization
+// @Summary Remove member
+// @Description Members can remove themselves; removing others takes an admin, or an owner for owners
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param userId path string true "Member user ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/organizations/{id}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.orgService.RemoveMember(id, memberID, userID); err != nil {
+		h.handleError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetInvitations handles listing the pending invitations of an organization
+// @Summary Get pending invitations
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {array} models.Invitation
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/organizations/{id}/invitations [get]
+func (h *OrganizationHandler) GetInvitations(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	invitations, err := h.orgService.ListInvitations(id, userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to retrieve invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// Invite handles inviting someone to an organization by email
+// @Summary Invite member
+// @Description Emails the invitee a link to accept the invitation, valid for 7 days
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body services.InviteRequest true "Invitee email and role"
+// @Success 201 {object} models.Invitation
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/organizations/{id}/invitations [post]
+func (h *OrganizationHandler) Invite(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	invitation, err := h.orgService.Invite(id, userID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// RevokeInvitation handles cancelling a pending invitation
+// @Summary Revoke invitation
+// @Tags organizations
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/organizations/{id}/invitations/{invitationId} [delete]
+func (h *OrganizationHandler) RevokeInvitation(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	invitationID, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "Invitation ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.orgService.RevokeInvitation(id, invitationID, userID); err != nil {
+		h.handleError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvitation handles joining an organization with an invitation token
+// @Summary Accept invitation
+// @Description The signed-in user's email must match the invitation
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.AcceptInvitationRequest true "Invitation token"
+// @Success 200 {object} models.PublicOrganization
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Router /api/invitations/accept [post]
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	organization, role, err := h.orgService.AcceptInvitation(userID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, organization.ToPublic(role))
+}
+
+// handleError maps organization service errors to responses
+func (h *OrganizationHandler) handleError(c *gin.Context, err error, message string) {
+	if respondOrganizationError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrMembershipNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Member not found",
+		})
+	case errors.Is(err, services.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Invitation not found",
+		})
+	case errors.Is(err, services.ErrInvitationExpired):
+		c.JSON(http.StatusGone, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvitationEmailMismatch):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrLastOwner),
+		errors.Is(err, services.ErrAlreadyMember),
+		errors.Is(err, services.ErrOrganizationNotEmpty):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
+
+// respondOrganizationError writes the response for errors from checking the caller's
+// organization role and reports whether err was one of them
+func respondOrganizationError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Organization not found",
+		})
+	case errors.Is(err, services.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "Insufficient organization role",
+			Message: err.Error(),
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// organizationQuery parses the optional organization_id query parameter, writing a
+// 400 response and returning false when it isn't a valid UUID
+func organizationQuery(c *gin.Context) (*uuid.UUID, bool) {
+	param := c.Query("organization_id")
+	if param == "" {
+		return nil, true
+	}
+
+	id, err := uuid.Parse(param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "organization_id must be a valid UUID",
+		})
+		return nil, false
+	}
+	return &id, true
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PassHandler handles Apple Wallet pass endpoints
+type PassHandler struct {
+	revealService *services.RevealService
+	passService   *services.PassService
+	quotaService  *services.QuotaService
+}
+
+// NewPassHandler creates a new pass handler
+func NewPassHandler(revealService *services.RevealService, passService *services.PassService, quotaService *services.QuotaService) *PassHandler {
+	return &PassHandler{
+		revealService: revealService,
+		passService:   passService,
+		quotaService:  quotaService,
+	}
+}
+
+// Download handles generating an Apple Wallet pass for a WiFi credential
+// @Summary Download Apple Wallet pass
+// @Description The pass holds the password, so like revealing it, it requires the account password unless the token was issued within the re-authentication window and is audited. Counts against the daily export limit of the user's plan.
+// @Tags wifi
+// @Accept json
+// @Produce application/vnd.apple.pkpass
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param request body services.RevealPasswordRequest false "Account password"
+// @Success 200 {file} binary
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/wifi/{id}/pass [post]
+func (h *PassHandler) Download(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	if !h.passService.IsConfigured() {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "Apple Wallet passes are not available",
+			Message: services.ErrPassSigningNotConfigured.Error(),
+		})
+		return
+	}
+
+	// An empty body relies on a fresh token
+	var req services.RevealPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	issuedAt, _ := middleware.GetTokenIssuedAt(c)
+
+	// The pass carries the password, so it needs the same checks as revealing it
+	credential, password, err := h.revealService.RevealForPass(id, &req, services.RevealContext{
+		UserID:        userID,
+		IsAdmin:       middleware.IsAdmin(c),
+		TokenIssuedAt: issuedAt,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to access this WiFi credential",
+			})
+			return
+		}
+		if respondReauthError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve WiFi credential",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	pass, err := h.passService.GeneratePass(credential, password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to generate pass",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Only count passes that were actually generated
+	err = h.quotaService.RecordExport(services.ExportContext{
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}, "wifi_credential", &credential.ID)
+	if err != nil {
+		if respondQuotaError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to generate pass",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", passFilename(credential.SSID)))
+	c.Data(http.StatusOK, "application/vnd.apple.pkpass", pass)
+}
+
+// passFilename builds a safe download filename from an SSID
+func passFilename(ssid string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, ssid)
+	if strings.Trim(name, "_") == "" {
+		name = "wifi"
+	}
+	return name + ".pkpass"
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// QuotaHandler handles plan and quota endpoints
+type QuotaHandler struct {
+	quotaService *services.QuotaService
+}
+
+// NewQuotaHandler creates a new quota handler
+func NewQuotaHandler(quotaService *services.QuotaService) *QuotaHandler {
+	return &QuotaHandler{quotaService: quotaService}
+}
+
+// GetUsage handles retrieving the current user's plan and quota usage
+// @Summary Get quota usage
+// @Description A limit of 0 means unlimited
+// @Tags quota
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.QuotaUsage
+// @Failure 401 {object} ErrorResponse
+// @Router /api/quota [get]
+func (h *QuotaHandler) GetUsage(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	usage, err := h.quotaService.Usage(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve quota usage",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetPlans handles retrieving every plan with its limits (admin only)
+// @Summary Get plans
+// @Description A limit of 0 means unlimited
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.PlanInfo
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/plans [get]
+func (h *QuotaHandler) GetPlans(c *gin.Context) {
+	c.JSON(http.StatusOK, h.quotaService.Plans())
+}
+
+// SetUserPlan handles changing the plan of a user (admin only)
+// @Summary Change user plan
+// @Description Data above the new limits is kept, but the user can't add more until back under them
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body services.SetPlanRequest true "New plan"
+// @Success 200 {object} models.PublicUser
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/plan [put]
+func (h *QuotaHandler) SetUserPlan(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.SetPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.quotaService.SetPlan(id, req.Plan)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPlan):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid plan",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "User not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "Failed to update plan",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user.ToPublic())
+}
+
+// SetOrganizationPlan handles changing the plan of an organization (admin only)
+// @Summary Change organization plan
+// @Description The plan limits the organization's credentials. Credentials above the new limit are kept, but members can't add more until back under it.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body services.SetPlanRequest true "New plan"
+// @Success 200 {object} models.Organization
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/organizations/{id}/plan [put]
+func (h *QuotaHandler) SetOrganizationPlan(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.SetPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	organization, err := h.quotaService.SetOrganizationPlan(id, req.Plan)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPlan):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid plan",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrOrganizationNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Organization not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "Failed to update plan",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// respondQuotaError writes a 402 response when err is a plan limit and reports whether it was
+func respondQuotaError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrQuotaExceeded) {
+		return false
+	}
+
+	c.JSON(http.StatusPaymentRequired, ErrorResponse{
+		Error:   "Plan limit reached",
+		Message: err.Error(),
+	})
+	return true
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RevealHandler handles revealing stored WiFi passwords
+type RevealHandler struct {
+	revealService *services.RevealService
+}
+
+// NewRevealHandler creates a new reveal handler
+func NewRevealHandler(revealService *services.RevealService) *RevealHandler {
+	return &RevealHandler{revealService: revealService}
+}
+
+// Reveal handles returning the plaintext password of a WiFi credential
+// @Summary Reveal WiFi password
+// @Description Returns the password with the notes and custom fields. Requires the account password unless the token was issued within the re-authentication window. Every reveal is audited.
+// @Tags wifi
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param request body services.RevealPasswordRequest false "Account password"
+// @Success 200 {object} services.RevealPasswordResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/wifi/{id}/reveal [post]
+func (h *RevealHandler) Reveal(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	// An empty body relies on a fresh token
+	var req services.RevealPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	issuedAt, _ := middleware.GetTokenIssuedAt(c)
+
+	revealed, err := h.revealService.Reveal(id, &req, services.RevealContext{
+		UserID:        userID,
+		IsAdmin:       middleware.IsAdmin(c),
+		TokenIssuedAt: issuedAt,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to access this WiFi credential",
+			})
+			return
+		}
+		if respondReauthError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to reveal WiFi password",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The plaintext must not linger in browser or proxy caches
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, revealed)
+}
+
+// respondReauthError writes a 401 response when err asks the caller to re-authenticate
+// and reports whether it did
+func respondReauthError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrInvalidPassword) && !errors.Is(err, services.ErrReauthenticationRequired) {
+		return false
+	}
+
+	c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Error:   "Re-authentication required",
+		Message: err.Error(),
+	})
+	return true
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ShareHandler handles share link endpoints, both for owners and for anonymous viewers
+type ShareHandler struct {
+	shareService *services.ShareService
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(shareService *services.ShareService) *ShareHandler {
+	return &ShareHandler{shareService: shareService}
+}
+
+// Create handles creating a share link for a WiFi credential
+// @Summary Create share link
+// @Description Returns the token and URL once; only a hash is stored. Kiosk links point to the live kiosk page.
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param request body services.CreateShareLinkRequest false "Type, expiry, view limit, PIN and password visibility"
+// @Success 201 {object} services.CreateShareLinkResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/shares [post]
+func (h *ShareHandler) Create(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	// An empty body creates a link without limits
+	var req services.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	link, err := h.shareService.Create(id, userID, middleware.IsAdmin(c), &req)
+	if err != nil {
+		if h.handleOwnerError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidShareExpiry) || errors.Is(err, services.ErrInvalidKioskLink) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Failed to create share link",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to create share link",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, link)
+}
+
+// GetAll handles listing the share links of a WiFi credential
+// @Summary Get share links
+// @Tags shares
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Success 200 {array} models.PublicShareLink
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/shares [get]
+func (h *ShareHandler) GetAll(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	links, err := h.shareService.ListByCredential(id, userID, middleware.IsAdmin(c))
+	if err != nil {
+		if h.handleOwnerError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve share links",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	publicLinks := make([]*models.PublicShareLink, len(links))
+	for i := range links {
+		publicLinks[i] = links[i].ToPublic()
+	}
+
+	c.JSON(http.StatusOK, publicLinks)
+}
+
+// Revoke handles revoking a share link
+// @Summary Revoke share link
+// @Tags shares
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param shareId path string true "Share link ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/shares/{shareId} [delete]
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	shareID, err := uuid.Parse(c.Param("shareId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "Share link ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.shareService.Revoke(id, shareID, userID, middleware.IsAdmin(c)); err != nil {
+		if h.handleOwnerError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to revoke share link",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetInfo handles describing a share link before it is opened. It doesn't count as a view.
+// @Summary Get share link info
+// @Description Public; tells the viewer whether a PIN is needed
+// @Tags shares
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} services.ShareLinkInfo
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/public/share/{token} [get]
+func (h *ShareHandler) GetInfo(c *gin.Context) {
+	info, err := h.shareService.Lookup(c.Param("token"))
+	if err != nil {
+		h.handleViewerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// View handles opening a share link
+// @Summary Open share link
+// @Description Public; returns the SSID and QR code (and the password if the link allows it) and counts a view
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param token path string true "Share token"
+// @Param request body services.ViewShareLinkRequest false "PIN, for protected links"
+// @Success 200 {object} services.SharedCredential
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/public/share/{token} [post]
+func (h *ShareHandler) View(c *gin.Context) {
+	var req services.ViewShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	shared, err := h.shareService.View(c.Param("token"), &req)
+	if err != nil {
+		h.handleViewerError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, shared)
+}
+
+// handleOwnerError writes the response for credential and share link lookup errors.
+// It reports whether err was handled.
+func (h *ShareHandler) handleOwnerError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrWifiNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "WiFi credential not found",
+		})
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Share link not found",
+		})
+	case errors.Is(err, services.ErrUnauthorizedAccess):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "You don't have permission to share this WiFi credential",
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// handleViewerError maps share link errors to responses for anonymous viewers
+func (h *ShareHandler) handleViewerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "Share link not found",
+		})
+	case errors.Is(err, services.ErrShareLinkUnavailable):
+		c.JSON(http.StatusGone, ErrorResponse{
+			Error:   "Share link is no longer available",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrSharePinRequired), errors.Is(err, services.ErrInvalidSharePin):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "PIN required",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to open share link",
+			Message: err.Error(),
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed templates/share.html templates/kiosk.html
+var templateFS embed.FS
+
+var sharePageTemplate = template.Must(template.ParseFS(templateFS, "templates/share.html"))
+
+// sharePageData is rendered by the public share page
+type sharePageData struct {
+	RequiresPin bool
+	Unavailable bool
+	Error       string
+	Credential  *services.SharedCredential
+	QRCodeURL   template.URL
+}
+
+// Page handles the public share page for browsers. Opening the page doesn't count as a
+// view, so chat apps that fetch links for previews don't use up the link; the viewer
+// confirms (and enters the PIN) with a form that posts to PageView.
+func (h *ShareHandler) Page(c *gin.Context) {
+	info, err := h.shareService.Lookup(c.Param("token"))
+	if err != nil {
+		h.renderPageError(c, err)
+		return
+	}
+
+	h.renderPage(c, http.StatusOK, &sharePageData{RequiresPin: info.RequiresPin})
+}
+
+// PageView handles the share page form, showing the network on success
+func (h *ShareHandler) PageView(c *gin.Context) {
+	var req services.ViewShareLinkRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderPage(c, http.StatusBadRequest, &sharePageData{Error: "Invalid request"})
+		return
+	}
+
+	shared, err := h.shareService.View(c.Param("token"), &req)
+	if err != nil {
+		h.renderPageError(c, err)
+		return
+	}
+
+	h.renderPage(c, http.StatusOK, &sharePageData{
+		Credential: shared,
+		// The QR code is a PNG generated by this server, so it is safe as a data URL
+		QRCodeURL: template.URL("data:image/png;base64," + shared.QRCodeData),
+	})
+}
+
+// renderPageError renders the share page for a failed lookup or view
+func (h *ShareHandler) renderPageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		h.renderPage(c, http.StatusNotFound, &sharePageData{Unavailable: true, Error: "This link doesn't exist."})
+	case errors.Is(err, services.ErrShareLinkUnavailable):
+		h.renderPage(c, http.StatusGone, &sharePageData{Unavailable: true, Error: "This link has expired or has been used up."})
+	case errors.Is(err, services.ErrSharePinRequired):
+		h.renderPage(c, http.StatusUnauthorized, &sharePageData{RequiresPin: true})
+	case errors.Is(err, services.ErrInvalidSharePin):
+		h.renderPage(c, http.StatusUnauthorized, &sharePageData{RequiresPin: true, Error: "Incorrect PIN."})
+	default:
+		h.renderPage(c, http.StatusInternalServerError, &sharePageData{Unavailable: true, Error: "Something went wrong. Please try again later."})
+	}
+}
+
+// renderPage writes the share page with headers that keep it out of caches, search engines and referrers
+func (h *ShareHandler) renderPage(c *gin.Context, status int, data *sharePageData) {
+	var buf bytes.Buffer
+	if err := sharePageTemplate.Execute(&buf, data); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Content-Security-Policy", "default-src 'none'; img-src data:; style-src 'unsafe-inline'; form-action 'self'")
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
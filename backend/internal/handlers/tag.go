@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TagHandler handles tag endpoints
+type TagHandler struct {
+	tagService *services.TagService
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(tagService *services.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+// GetAll handles retrieving the current user's or an organization's tags
+// @Summary Get tags
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Param organization_id query string false "List an organization's tags instead of personal ones"
+// @Success 200 {array} models.Tag
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tags [get]
+func (h *TagHandler) GetAll(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	organizationID, ok := organizationQuery(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.tagService.GetAll(userID, organizationID)
+	if err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve tags",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// Create handles creating a tag
+// @Summary Create tag
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.TagRequest true "Tag details"
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/tags [post]
+func (h *TagHandler) Create(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	tag, err := h.tagService.Create(userID, &req)
+	if err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTagNameTaken) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "Tag already exists",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to create tag",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// Update handles renaming or recoloring a tag
+// @Summary Update tag
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Param request body services.TagRequest true "Tag details"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/tags/{id} [put]
+func (h *TagHandler) Update(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req services.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	tag, err := h.tagService.Update(id, userID, &req)
+	if err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTagNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Tag not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrTagNameTaken) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "Tag already exists",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to update tag",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Delete handles deleting a tag
+// @Summary Delete tag
+// @Description The tag is removed from every credential carrying it
+// @Tags tags
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tags/{id} [delete]
+func (h *TagHandler) Delete(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	if err := h.tagService.Delete(id, userID); err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTagNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Tag not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to delete tag",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// BulkAssign handles adding and removing tags on many credentials at once
+// @Summary Bulk assign tags
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.BulkTagRequest true "Credentials and tags to add or remove"
+// @Success 200 {object} services.BulkTagResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/tags [post]
+func (h *TagHandler) BulkAssign(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.tagService.BulkAssign(userID, &req)
+	if err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrTagNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Tag not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to update these WiFi credentials",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to update tags",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
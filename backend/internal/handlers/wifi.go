@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"gin-quickstart/internal/middleware"
 	"gin-quickstart/internal/models"
@@ -14,16 +18,37 @@ import (
 
 // WifiHandler handles WiFi credential endpoints
 type WifiHandler struct {
-	wifiService *services.WifiService
+	wifiService   *services.WifiService
+	revealService *services.RevealService
 }
 
 // NewWifiHandler creates a new WiFi handler
-func NewWifiHandler(wifiService *services.WifiService) *WifiHandler {
+func NewWifiHandler(wifiService *services.WifiService, revealService *services.RevealService) *WifiHandler {
 	return &WifiHandler{
-		wifiService: wifiService,
+		wifiService:   wifiService,
+		revealService: revealService,
 	}
 }
 
+// CreateWifiResponse is the created credential, plus the password when it was generated
+type CreateWifiResponse struct {
+	*models.PublicWifiCredential
+	GeneratedPassword string `json:"generated_password,omitempty"`
+}
+
+// SharedWifiCredential is a credential another user has shared with the current user
+type SharedWifiCredential struct {
+	*models.PublicWifiCredential
+	OwnerEmail string                 `json:"owner_email"`
+	Permission models.GrantPermission `json:"permission"`
+}
+
+// ListResponse is the envelope for paginated lists
+type ListResponse struct {
+	Data       interface{}         `json:"data"`
+	Pagination services.Pagination `json:"pagination"`
+}
+
 // Create handles creating a new WiFi credential
 // @Summary Create WiFi credential
 // @Tags wifi
@@ -31,9 +56,12 @@ func NewWifiHandler(wifiService *services.WifiService) *WifiHandler {
 // @Produce json
 // @Security BearerAuth
 // @Param request body services.CreateWifiRequest true "WiFi credential details"
-// @Success 201 {object} models.PublicWifiCredential
+// @Success 201 {object} CreateWifiResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/wifi [post]
 func (h *WifiHandler) Create(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -55,6 +83,10 @@ func (h *WifiHandler) Create(c *gin.Context) {
 
 	credential, err := h.wifiService.Create(userID, &req)
 	if err != nil {
+		if respondOrganizationError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "Failed to create WiFi credential",
 			Message: err.Error(),
@@ -62,16 +94,33 @@ func (h *WifiHandler) Create(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, credential.ToPublic())
+	response := CreateWifiResponse{PublicWifiCredential: credential.ToPublic()}
+	if req.GeneratePassword {
+		response.GeneratedPassword = req.Password
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
-// GetAll handles retrieving all WiFi credentials for the current user
+// GetAll handles retrieving the current user's or an organization's WiFi credentials, one page at a time
 // @Summary Get user's WiFi credentials
 // @Tags wifi
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.PublicWifiCredential
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort key: created_at, updated_at or ssid"
+// @Param order query string false "asc or desc"
+// @Param security_type query string false "Filter by security type"
+// @Param is_hidden query bool false "Filter by hidden flag"
+// @Param q query string false "Fuzzy SSID search"
+// @Param tag query []string false "Tag ID, repeat to require several tags"
+// @Param location_id query string false "Location ID, includes nested locations"
+// @Param organization_id query string false "List an organization's credentials instead of personal ones"
+// @Success 200 {object} ListResponse{data=[]models.PublicWifiCredential}
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/wifi [get]
 func (h *WifiHandler) GetAll(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -82,8 +131,28 @@ func (h *WifiHandler) GetAll(c *gin.Context) {
 		return
 	}
 
-	credentials, err := h.wifiService.GetAllByUser(userID)
+	var req services.ListWifiRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.wifiService.List(&userID, &req)
 	if err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCursor) || errors.Is(err, services.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid query parameters",
+				Message: err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to retrieve WiFi credentials",
 			Message: err.Error(),
@@ -91,14 +160,86 @@ func (h *WifiHandler) GetAll(c *gin.Context) {
 		return
 	}
 
-	// Convert to public format
-	// Convert to public format
-	publicCredentials := make([]*models.PublicWifiCredential, 0, len(credentials))
-	for _, cred := range credentials {
-		publicCredentials = append(publicCredentials, cred.ToPublic())
+	// Convert to public format; organization viewers don't get the QR codes
+	publicCredentials := make([]*models.PublicWifiCredential, 0, len(result.Credentials))
+	for _, cred := range result.Credentials {
+		publicCredentials = append(publicCredentials, cred.ToPublicFor(result.Permission))
 	}
 
-	c.JSON(http.StatusOK, publicCredentials)
+	c.JSON(http.StatusOK, ListResponse{
+		Data:       publicCredentials,
+		Pagination: result.Pagination,
+	})
+}
+
+// GetShared handles retrieving the WiFi credentials other users have shared with the current user
+// @Summary Get credentials shared with me
+// @Description Accepts the same paging, sorting and filter parameters as GET /api/wifi. qr_code_data is only included on credentials shared with reveal or edit permission.
+// @Tags wifi
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param sort query string false "Sort key: created_at, updated_at or ssid"
+// @Param order query string false "asc or desc"
+// @Param q query string false "Fuzzy SSID search"
+// @Success 200 {object} ListResponse{data=[]SharedWifiCredential}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/wifi/shared [get]
+func (h *WifiHandler) GetShared(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.ListWifiRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.wifiService.ListSharedWith(userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) || errors.Is(err, services.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid query parameters",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve shared WiFi credentials",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	shared := make([]SharedWifiCredential, 0, len(result.Credentials))
+	for _, cred := range result.Credentials {
+		ownerEmail := ""
+		if cred.User != nil {
+			ownerEmail = cred.User.Email
+		}
+
+		shared = append(shared, SharedWifiCredential{
+			PublicWifiCredential: cred.ToPublicFor(result.Permissions[cred.ID]),
+			OwnerEmail:           ownerEmail,
+			Permission:           result.Permissions[cred.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, ListResponse{
+		Data:       shared,
+		Pagination: result.Pagination,
+	})
 }
 
 // GetByID handles retrieving a specific WiFi credential
@@ -106,8 +247,10 @@ func (h *WifiHandler) GetAll(c *gin.Context) {
 // @Tags wifi
 // @Produce json
 // @Security BearerAuth
+// @Description The ETag header holds the credential version to send as If-Match when updating or deleting it. qr_code_data is left out unless the caller may reveal the password.
 // @Param id path string true "WiFi credential ID"
 // @Success 200 {object} models.PublicWifiCredential
+// @Header 200 {string} ETag "Credential version"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
@@ -134,7 +277,7 @@ func (h *WifiHandler) GetByID(c *gin.Context) {
 
 	isAdmin := middleware.IsAdmin(c)
 
-	credential, err := h.wifiService.GetByID(id, userID, isAdmin)
+	credential, permission, err := h.wifiService.GetWithAccess(id, userID, isAdmin)
 	if err != nil {
 		if errors.Is(err, services.ErrWifiNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -156,19 +299,154 @@ func (h *WifiHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", credentialETag(credential))
+	c.JSON(http.StatusOK, credential.ToPublicFor(permission))
+}
+
+// Update handles changing some fields of a WiFi credential
+// @Summary Update WiFi credential
+// @Description Only the fields present in the request are changed; the QR code is regenerated. If-Match must hold the ETag of the version being edited.
+// @Tags wifi
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param If-Match header string true "ETag from GET /api/wifi/{id}, or * to skip the check"
+// @Param request body services.UpdateWifiRequest true "Fields to update"
+// @Success 200 {object} models.PublicWifiCredential
+// @Header 200 {string} ETag "New credential version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /api/wifi/{id} [patch]
+func (h *WifiHandler) Update(c *gin.Context) {
+	h.update(c, func() (*services.UpdateWifiRequest, error) {
+		var req services.UpdateWifiRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+		return &req, nil
+	})
+}
+
+// Replace handles replacing a WiFi credential
+// @Summary Replace WiFi credential
+// @Description Every field is replaced and fields that are left out are cleared, except the password, which is kept unless a new one is sent. The QR code is regenerated. If-Match must hold the ETag of the version being replaced.
+// @Tags wifi
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param If-Match header string true "ETag from GET /api/wifi/{id}, or * to skip the check"
+// @Param request body services.ReplaceWifiRequest true "New credential"
+// @Success 200 {object} models.PublicWifiCredential
+// @Header 200 {string} ETag "New credential version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /api/wifi/{id} [put]
+func (h *WifiHandler) Replace(c *gin.Context) {
+	h.update(c, func() (*services.UpdateWifiRequest, error) {
+		var req services.ReplaceWifiRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+		return req.ToUpdate(), nil
+	})
+}
+
+// update saves the changes that bind reads from the request body
+func (h *WifiHandler) update(c *gin.Context, bind func() (*services.UpdateWifiRequest, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	req, err := bind()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	isAdmin := middleware.IsAdmin(c)
+
+	credential, err := h.wifiService.Update(id, userID, isAdmin, version, req)
+	if err != nil {
+		if respondVersionMismatch(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to update this WiFi credential",
+			})
+			return
+		}
+		if services.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Failed to update WiFi credential",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to update WiFi credential",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", credentialETag(credential))
 	c.JSON(http.StatusOK, credential.ToPublic())
 }
 
-// Delete handles deleting a WiFi credential
+// Delete handles moving a WiFi credential to the trash
 // @Summary Delete WiFi credential
+// @Description The credential can be restored until the trash retention period ends. If-Match must hold the ETag of the current version.
 // @Tags wifi
 // @Security BearerAuth
 // @Param id path string true "WiFi credential ID"
+// @Param If-Match header string true "ETag from GET /api/wifi/{id}, or * to skip the check"
 // @Success 204
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Router /api/wifi/{id} [delete]
 func (h *WifiHandler) Delete(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -189,9 +467,17 @@ func (h *WifiHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	isAdmin := middleware.IsAdmin(c)
 
-	if err := h.wifiService.Delete(id, userID, isAdmin); err != nil {
+	if err := h.wifiService.Delete(id, userID, isAdmin, version); err != nil {
+		if respondVersionMismatch(c, err) {
+			return
+		}
 		if errors.Is(err, services.ErrWifiNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{
 				Error: "WiFi credential not found",
@@ -214,3 +500,467 @@ func (h *WifiHandler) Delete(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// GetTrash handles retrieving the current user's or an organization's deleted WiFi credentials
+// @Summary Get deleted WiFi credentials
+// @Description Deleted credentials stay in the trash until the retention period ends. An organization's trash is visible to its admins and owners.
+// @Tags wifi
+// @Produce json
+// @Security BearerAuth
+// @Param organization_id query string false "List an organization's trash instead of the personal one"
+// @Success 200 {array} models.PublicWifiCredential
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/trash [get]
+func (h *WifiHandler) GetTrash(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	organizationID, ok := organizationQuery(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.wifiService.ListTrash(userID, organizationID)
+	if err != nil {
+		if respondOrganizationError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve deleted WiFi credentials",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Convert to public format
+	publicCredentials := make([]*models.PublicWifiCredential, 0, len(credentials))
+	for _, cred := range credentials {
+		publicCredentials = append(publicCredentials, cred.ToPublic())
+	}
+
+	c.JSON(http.StatusOK, publicCredentials)
+}
+
+// Restore handles taking a WiFi credential out of the trash
+// @Summary Restore deleted WiFi credential
+// @Tags wifi
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Success 200 {object} models.PublicWifiCredential
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/restore [post]
+func (h *WifiHandler) Restore(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	isAdmin := middleware.IsAdmin(c)
+
+	credential, err := h.wifiService.Restore(id, userID, isAdmin)
+	if err != nil {
+		if respondQuotaError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deleted WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to restore this WiFi credential",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to restore WiFi credential",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, credential.ToPublic())
+}
+
+// GetVersions handles retrieving the change history of a WiFi credential
+// @Summary Get WiFi credential versions
+// @Tags wifi
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Success 200 {array} models.PublicWifiCredentialVersion
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/versions [get]
+func (h *WifiHandler) GetVersions(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	isAdmin := middleware.IsAdmin(c)
+
+	versions, err := h.wifiService.ListVersions(id, userID, isAdmin)
+	if err != nil {
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to access this WiFi credential",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to retrieve WiFi credential versions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// RestoreVersion handles rolling a WiFi credential back to an earlier version
+// @Summary Restore WiFi credential version
+// @Description Rolls back SSID, password, security type and hidden flag and regenerates the QR code. If-Match must hold the ETag of the current version.
+// @Tags wifi
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param version path int true "Version number"
+// @Param If-Match header string true "ETag from GET /api/wifi/{id}, or * to skip the check"
+// @Success 200 {object} models.PublicWifiCredential
+// @Header 200 {string} ETag "New credential version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /api/wifi/{id}/versions/{version}/restore [post]
+func (h *WifiHandler) RestoreVersion(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid version",
+			Message: "Version must be a positive integer",
+		})
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	isAdmin := middleware.IsAdmin(c)
+
+	credential, err := h.wifiService.RestoreVersion(id, version, userID, isAdmin, ifMatch)
+	if err != nil {
+		if respondVersionMismatch(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrVersionNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential version not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to update this WiFi credential",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to restore WiFi credential version",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", credentialETag(credential))
+	c.JSON(http.StatusOK, credential.ToPublic())
+}
+
+// Clone handles copying a WiFi credential
+// @Summary Clone WiFi credential
+// @Description Copies the credential, optionally with an SSID suffix, a generated password or into an organization or location. The copy is re-encrypted and gets a new QR code. Tags and the location are kept when copying within the same workspace.
+// @Tags wifi
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "WiFi credential ID"
+// @Param request body services.CloneWifiRequest false "Clone options"
+// @Success 201 {object} CreateWifiResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/wifi/{id}/clone [post]
+func (h *WifiHandler) Clone(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID format",
+			Message: "ID must be a valid UUID",
+		})
+		return
+	}
+
+	// An empty body makes an exact copy
+	var req services.CloneWifiRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	isAdmin := middleware.IsAdmin(c)
+
+	credential, generatedPassword, err := h.wifiService.Clone(id, userID, isAdmin, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrWifiNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "WiFi credential not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUnauthorizedAccess) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error: "You don't have permission to clone this WiFi credential",
+			})
+			return
+		}
+		if respondOrganizationError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+		if services.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Failed to clone WiFi credential",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to clone WiFi credential",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateWifiResponse{
+		PublicWifiCredential: credential.ToPublic(),
+		GeneratedPassword:    generatedPassword,
+	})
+}
+
+// DerivePSK handles deriving a raw WPA PSK from a passphrase and SSID
+// @Summary Derive WPA PSK
+// @Tags wifi
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.DerivePSKRequest true "Passphrase and SSID"
+// @Success 200 {object} services.DerivePSKResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/wifi/derive-psk [post]
+func (h *WifiHandler) DerivePSK(c *gin.Context) {
+	var req services.DerivePSKRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := services.DerivePSK(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to derive PSK",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GeneratePassword handles generating a strong WiFi password
+// @Summary Generate WiFi password
+// @Tags wifi
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.PasswordPolicy false "Password policy"
+// @Success 200 {object} services.GeneratedPassword
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/wifi/generate-password [post]
+func (h *WifiHandler) GeneratePassword(c *gin.Context) {
+	// An empty body uses the default policy
+	var policy services.PasswordPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	generated, err := h.wifiService.GeneratePassword(policy)
+	if err != nil {
+		if services.IsValidationError(err) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Failed to generate password",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to generate password",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, generated)
+}
+
+// credentialETag returns the ETag of a credential's current version
+func credentialETag(credential *models.WifiCredential) string {
+	return fmt.Sprintf("%q", strconv.Itoa(credential.Version))
+}
+
+// ifMatchVersion parses the If-Match header of a request that changes a credential.
+// "*" matches any version and yields 0. It writes a 428 response when the header is
+// missing and a 400 response when it isn't a credential ETag, returning false.
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, ErrorResponse{
+			Error:   "If-Match header required",
+			Message: "Send the ETag from GET /api/wifi/:id as If-Match",
+		})
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	version, convErr := strconv.Atoi(unquoted)
+	if err != nil || convErr != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid If-Match header",
+			Message: "If-Match must be an ETag returned by GET /api/wifi/:id",
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// respondVersionMismatch writes a 412 response when err is a failed If-Match check and reports whether it was
+func respondVersionMismatch(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrVersionMismatch) {
+		return false
+	}
+
+	c.JSON(http.StatusPreconditionFailed, ErrorResponse{
+		Error:   "WiFi credential was modified",
+		Message: err.Error(),
+	})
+	return true
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bulk handles applying one action to many WiFi credentials
+// @Summary Bulk WiFi credential action
+// @Description Applies delete, tag, move, rotate or export to up to 100 credentials. Each credential is checked like the single-credential endpoint and reported separately.
+// @Description Delete, move and rotate need versions with the ETag version of every credential, as If-Match does; credentials changed since then fail with the version mismatch error.
+// @Description The credentials that pass the checks are changed in one transaction: if writing one fails, it is reported as failed and the others as skipped.
+// @Description Exports return every credential with its password. Like revealing a password, they require the account password unless the token was issued within the re-authentication window, are audited per credential and count once against the daily export limit.
+// @Tags wifi
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.BulkWifiRequest true "Action, credential IDs and, for exports, the account password"
+// @Success 200 {object} services.BulkWifiResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/wifi/bulk [post]
+func (h *WifiHandler) Bulk(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var req services.BulkWifiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	isAdmin := middleware.IsAdmin(c)
+	issuedAt, _ := middleware.GetTokenIssuedAt(c)
+	ec := services.ExportContext{
+		UserID:        userID,
+		TokenIssuedAt: issuedAt,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	}
+
+	bulk := h.wifiService.Bulk
+	if req.Action == services.BulkActionExport {
+		bulk = h.revealService.BulkExport
+	}
+	result, err := bulk(ec, isAdmin, &req)
+	if err != nil {
+		if respondReauthError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidBulkAction) ||
+			errors.Is(err, services.ErrBulkTooLarge) ||
+			errors.Is(err, services.ErrBulkNoTags) ||
+			errors.Is(err, services.ErrBulkNoVersion) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid bulk request",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to apply bulk action",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Exports hold plain-text passwords
+	if req.Action == services.BulkActionExport {
+		c.Header("Cache-Control", "no-store")
+	}
+	c.JSON(http.StatusOK, result)
+}
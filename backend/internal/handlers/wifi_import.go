@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes limits the size of an import file
+const maxImportBytes = 2 << 20
+
+// Import handles creating WiFi credentials from a CSV or JSON file or a password manager export
+// @Summary Import WiFi credentials
+// @Description Upload the file as multipart field "file" or send it as the request body with Content-Type text/csv or application/json.
+// @Description In atomic mode (default) nothing is saved unless every row is valid; in partial mode the valid rows are saved.
+// @Tags wifi
+// @Accept text/csv,json,xml,mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file false "Import file"
+// @Param format query string false "csv, json, bitwarden, keepass or 1password; csv, json and keepass are detected from the file name or Content-Type when omitted"
+// @Param mode query string false "atomic or partial"
+// @Param dry_run query bool false "Validate every row without saving anything"
+// @Param organization_id query string false "Import into this organization"
+// @Success 200 {object} services.ImportWifiResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} services.ImportWifiResult
+// @Router /api/wifi/import [post]
+func (h *WifiHandler) Import(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Unauthorized",
+		})
+		return
+	}
+
+	var opts services.ImportWifiOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+		return
+	}
+	organizationID, ok := organizationQuery(c)
+	if !ok {
+		return
+	}
+	opts.OrganizationID = organizationID
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	file, format, err := importFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid import file",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	rows, err := services.ParseWifiImport(format, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid import file",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.wifiService.Import(userID, rows, opts)
+	if err != nil {
+		if respondOrganizationError(c, err) || respondQuotaError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to import WiFi credentials",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// An atomic import with invalid rows saved nothing
+	status := http.StatusOK
+	if !result.DryRun && result.Mode == services.ImportModeAtomic && result.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, result)
+}
+
+// importFile returns the uploaded import file and its format. The format comes from the
+// format query parameter, else the file name of a multipart upload, else the Content-Type.
+func importFile(c *gin.Context) (io.ReadCloser, services.ImportFormat, error) {
+	format := services.ImportFormat(strings.ToLower(c.Query("format")))
+
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("missing file field: %w", err)
+		}
+		if format == "" {
+			switch strings.ToLower(filepath.Ext(header.Filename)) {
+			case ".csv":
+				format = services.ImportFormatCSV
+			case ".json":
+				format = services.ImportFormatJSON
+			case ".xml":
+				format = services.ImportFormatKeePass
+			}
+		}
+		if err := validateImportFormat(format); err != nil {
+			return nil, "", err
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open file: %w", err)
+		}
+		return file, format, nil
+	}
+
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = services.ImportFormatCSV
+		case "application/json":
+			format = services.ImportFormatJSON
+		case "application/xml", "text/xml":
+			format = services.ImportFormatKeePass
+		}
+	}
+	if err := validateImportFormat(format); err != nil {
+		return nil, "", err
+	}
+	return c.Request.Body, format, nil
+}
+
+// validateImportFormat rejects formats the importer can't read
+func validateImportFormat(format services.ImportFormat) error {
+	switch format {
+	case services.ImportFormatCSV, services.ImportFormatJSON, services.ImportFormatBitwarden,
+		services.ImportFormatKeePass, services.ImportFormat1Password:
+		return nil
+	}
+	return errors.New("format must be csv, json, bitwarden, keepass or 1password")
+}
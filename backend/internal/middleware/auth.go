@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"gin-quickstart/internal/models"
 	"gin-quickstart/internal/services"
@@ -50,6 +51,9 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
+		if claims.IssuedAt != nil {
+			c.Set("token_issued_at", claims.IssuedAt.Time)
+		}
 
 		c.Next()
 	}
@@ -110,6 +114,21 @@ func GetUserRole(c *gin.Context) (models.UserRole, bool) {
 	return userRole, true
 }
 
+// GetTokenIssuedAt retrieves when the request's JWT token was issued
+func GetTokenIssuedAt(c *gin.Context) (time.Time, bool) {
+	issuedAt, exists := c.Get("token_issued_at")
+	if !exists {
+		return time.Time{}, false
+	}
+
+	t, ok := issuedAt.(time.Time)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
 // IsAdmin checks if the current user is an admin
 func IsAdmin(c *gin.Context) bool {
 	role, exists := GetUserRole(c)
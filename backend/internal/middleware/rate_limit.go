@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow tracks the requests of one client in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiter is a fixed-window, in-memory request counter keyed by client (user ID or IP)
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+	swept   time.Time
+}
+
+// newRateLimiter creates a limiter allowing limit requests per window for each key
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// allow counts a request and reports whether it is within the limit, and if
+// not, how long until the window resets
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop expired windows now and then so idle clients don't accumulate
+	if now.Sub(l.swept) > l.window {
+		for id, w := range l.windows {
+			if now.Sub(w.start) >= l.window {
+				delete(l.windows, id)
+			}
+		}
+		l.swept = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// RateLimit creates a middleware allowing each authenticated user at most limit
+// requests per window. It must run after AuthMiddleware. Counters live in memory,
+// so every instance of the API enforces the limit separately.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	limiter := newRateLimiter(limit, window)
+
+	return func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+			})
+			c.Abort()
+			return
+		}
+
+		limiter.handle(c, userID.String())
+	}
+}
+
+// RateLimitByIP creates a middleware allowing each client IP at most limit requests
+// per window, for endpoints that are reachable without logging in
+func RateLimitByIP(limit int, window time.Duration) gin.HandlerFunc {
+	limiter := newRateLimiter(limit, window)
+
+	return func(c *gin.Context) {
+		limiter.handle(c, c.ClientIP())
+	}
+}
+
+// handle counts the request under key and rejects it with 429 once the limit is reached
+func (l *rateLimiter) handle(c *gin.Context, key string) {
+	allowed, retryAfter := l.allow(key, time.Now())
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many requests, please try again later",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
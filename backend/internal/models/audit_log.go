@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditAction identifies what was done in an audit log entry
+type AuditAction string
+
+const (
+	AuditActionPasswordReveal       AuditAction = "password_reveal"
+	AuditActionPasswordRevealDenied AuditAction = "password_reveal_denied"
+	AuditActionExport               AuditAction = "export"
+)
+
+// AuditLog records access to sensitive data
+type AuditLog struct {
+	ID           uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID   `gorm:"type:uuid;not null;index" json:"user_id"`
+	Action       AuditAction `gorm:"type:varchar(50);not null" json:"action"`
+	ResourceType string      `gorm:"type:varchar(50);not null" json:"resource_type"`
+	ResourceID   *uuid.UUID  `gorm:"type:uuid;index" json:"resource_id,omitempty"`
+	IPAddress    string      `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent    string      `gorm:"type:varchar(500)" json:"user_agent"`
+	CreatedAt    time.Time   `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
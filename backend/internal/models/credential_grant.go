@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GrantPermission is the level of access a grant gives to another user.
+// Each level includes the ones before it.
+type GrantPermission string
+
+const (
+	GrantView   GrantPermission = "view"   // See the credential and its history
+	GrantReveal GrantPermission = "reveal" // Also reveal the password, see the QR code and download the Wallet pass
+	GrantEdit   GrantPermission = "edit"   // Also update the credential and roll back versions
+)
+
+// grantRank orders permissions so that higher ones include lower ones
+var grantRank = map[GrantPermission]int{
+	GrantView:   1,
+	GrantReveal: 2,
+	GrantEdit:   3,
+}
+
+// Includes reports whether p gives at least the required access
+func (p GrantPermission) Includes(required GrantPermission) bool {
+	return grantRank[required] > 0 && grantRank[p] >= grantRank[required]
+}
+
+// IsValidGrantPermission checks if the permission is valid
+func IsValidGrantPermission(p string) bool {
+	_, ok := grantRank[GrantPermission(p)]
+	return ok
+}
+
+// CredentialGrant gives a registered user access to another user's WiFi credential
+type CredentialGrant struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CredentialID uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_credential_grantee" json:"credential_id"`
+	GranteeID    uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_credential_grantee;index" json:"grantee_id"`
+	Permission   GrantPermission `gorm:"type:varchar(20);not null" json:"permission"`
+	GrantedBy    uuid.UUID       `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Grantee *User `gorm:"foreignKey:GranteeID" json:"-"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (g *CredentialGrant) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for CredentialGrant model
+func (CredentialGrant) TableName() string {
+	return "credential_grants"
+}
+
+// PublicCredentialGrant represents grant data safe for public consumption
+type PublicCredentialGrant struct {
+	ID           uuid.UUID       `json:"id"`
+	CredentialID uuid.UUID       `json:"credential_id"`
+	GranteeID    uuid.UUID       `json:"grantee_id"`
+	GranteeEmail string          `json:"grantee_email"`
+	Permission   GrantPermission `json:"permission"`
+	GrantedBy    uuid.UUID       `json:"granted_by"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// ToPublic converts CredentialGrant to PublicCredentialGrant
+func (g *CredentialGrant) ToPublic() *PublicCredentialGrant {
+	email := ""
+	if g.Grantee != nil {
+		email = g.Grantee.Email
+	}
+
+	return &PublicCredentialGrant{
+		ID:           g.ID,
+		CredentialID: g.CredentialID,
+		GranteeID:    g.GranteeID,
+		GranteeEmail: email,
+		Permission:   g.Permission,
+		GrantedBy:    g.GrantedBy,
+		CreatedAt:    g.CreatedAt,
+		UpdatedAt:    g.UpdatedAt,
+	}
+}
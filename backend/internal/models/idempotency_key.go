@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey remembers a request sent with an Idempotency-Key header and its
+// response, so a retried request gets the original response instead of running again
+type IdempotencyKey struct {
+	ID                    uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID                uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:uq_idempotency_keys_user_key" json:"user_id"`
+	Key                   string    `gorm:"size:255;not null;uniqueIndex:uq_idempotency_keys_user_key" json:"key"`
+	RequestHash           string    `gorm:"size:64;not null" json:"-"`        // SHA-256 of the method, URL and body
+	StatusCode            int       `gorm:"not null;default:0" json:"-"`      // 0 while the request is still running
+	ContentType           string    `gorm:"size:100" json:"-"`                // Of the stored response
+	EncryptedResponseBody string    `gorm:"type:text" json:"-"`               // Encrypted like WiFi passwords, as it may contain one
+	ExpiresAt             time.Time `gorm:"not null;index" json:"expires_at"` // Deleted by a background job afterwards
+	CreatedAt             time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for IdempotencyKey model
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
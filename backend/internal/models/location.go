@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LocationKind defines the level of a location in the hierarchy
+type LocationKind string
+
+const (
+	LocationSite     LocationKind = "site"     // Top level, e.g. a property
+	LocationBuilding LocationKind = "building" // Inside a site
+	LocationFloor    LocationKind = "floor"    // Inside a building
+	LocationFolder   LocationKind = "folder"   // Free-form grouping at any level
+)
+
+// Location is a node in a user's or organization's Site → Building → Floor hierarchy
+type Location struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	OrganizationID *uuid.UUID   `gorm:"type:uuid;index" json:"organization_id"`
+	ParentID       *uuid.UUID   `gorm:"type:uuid;index" json:"parent_id"`
+	Name           string       `gorm:"not null;size:100" json:"name"`
+	Kind           LocationKind `gorm:"type:varchar(20);not null" json:"kind"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (l *Location) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for Location model
+func (Location) TableName() string {
+	return "locations"
+}
+
+// IsValidLocationKind checks if the location kind is valid
+func IsValidLocationKind(kind string) bool {
+	switch LocationKind(kind) {
+	case LocationSite, LocationBuilding, LocationFloor, LocationFolder:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanContain reports whether a location of this kind may be the parent of child
+func (k LocationKind) CanContain(child LocationKind) bool {
+	switch child {
+	case LocationSite:
+		return false
+	case LocationBuilding:
+		return k == LocationSite || k == LocationFolder
+	case LocationFloor:
+		return k == LocationBuilding
+	case LocationFolder:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrgRole defines a member's role within an organization
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"  // Everything, including deleting the organization and managing owners
+	OrgRoleAdmin  OrgRole = "admin"  // Manage members, invitations and every credential
+	OrgRoleMember OrgRole = "member" // Create and edit credentials, tags and locations
+	OrgRoleViewer OrgRole = "viewer" // Read-only access to credentials
+)
+
+// orgRoleRank orders roles so that higher ones include lower ones
+var orgRoleRank = map[OrgRole]int{
+	OrgRoleViewer: 1,
+	OrgRoleMember: 2,
+	OrgRoleAdmin:  3,
+	OrgRoleOwner:  4,
+}
+
+// AtLeast reports whether r includes the minimum role
+func (r OrgRole) AtLeast(minimum OrgRole) bool {
+	return orgRoleRank[minimum] > 0 && orgRoleRank[r] >= orgRoleRank[minimum]
+}
+
+// CredentialPermission returns the access a role gives to the organization's credentials.
+// Admins and owners have full control, which no grant permission expresses.
+func (r OrgRole) CredentialPermission() GrantPermission {
+	if r == OrgRoleViewer {
+		return GrantView
+	}
+	return GrantEdit
+}
+
+// IsValidOrgRole checks if the role is valid
+func IsValidOrgRole(role string) bool {
+	_, ok := orgRoleRank[OrgRole(role)]
+	return ok
+}
+
+// Organization is a shared workspace whose members manage credentials together
+type Organization struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name      string    `gorm:"not null;size:100" json:"name"`
+	Plan      Plan      `gorm:"type:varchar(20);not null;default:'free'" json:"plan"` // Limits the organization's credentials
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	if o.Plan == "" {
+		o.Plan = PlanFree
+	}
+	return nil
+}
+
+// TableName specifies the table name for Organization model
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Membership links a user to an organization with a role
+type Membership struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_membership_org_user" json:"organization_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_membership_org_user;index" json:"user_id"`
+	Role           OrgRole   `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+	User         *User         `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (m *Membership) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for Membership model
+func (Membership) TableName() string {
+	return "organization_members"
+}
+
+// PublicMember represents a membership with the member's email
+type PublicMember struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      OrgRole   `json:"role"`
+	CreatedAt time.Time `json:"joined_at"`
+}
+
+// ToPublicMember converts Membership to PublicMember
+func (m *Membership) ToPublicMember() *PublicMember {
+	email := ""
+	if m.User != nil {
+		email = m.User.Email
+	}
+
+	return &PublicMember{
+		UserID:    m.UserID,
+		Email:     email,
+		Role:      m.Role,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// PublicOrganization represents an organization together with the caller's role in it
+type PublicOrganization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Plan      Plan      `json:"plan"`
+	Role      OrgRole   `json:"role"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ToPublic converts Organization to PublicOrganization for a member with the given role
+func (o *Organization) ToPublic(role OrgRole) *PublicOrganization {
+	return &PublicOrganization{
+		ID:        o.ID,
+		Name:      o.Name,
+		Plan:      o.Plan,
+		Role:      role,
+		CreatedBy: o.CreatedBy,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+	}
+}
+
+// Invitation asks the holder of an email address to join an organization. It is accepted
+// with a secret token; only a hash of the token is stored.
+type Invitation struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;not null;index" json:"organization_id"`
+	Email          string     `gorm:"not null;size:255" json:"email"`
+	Role           OrgRole    `gorm:"type:varchar(20);not null" json:"role"`
+	TokenHash      string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	InvitedBy      uuid.UUID  `gorm:"type:uuid;not null" json:"invited_by"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (i *Invitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for Invitation model
+func (Invitation) TableName() string {
+	return "organization_invitations"
+}
+
+// IsPending reports whether the invitation can still be accepted at now
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,23 @@
+package models
+
+// Plan is the subscription plan of a user, which sets their quotas
+type Plan string
+
+const (
+	PlanFree     Plan = "free"
+	PlanPro      Plan = "pro"
+	PlanBusiness Plan = "business"
+)
+
+// Plans lists every plan from the smallest to the largest
+var Plans = []Plan{PlanFree, PlanPro, PlanBusiness}
+
+// IsValidPlan checks if the given plan is valid
+func IsValidPlan(plan string) bool {
+	for _, p := range Plans {
+		if string(p) == plan {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareLinkStatus describes whether a share link can still be opened
+type ShareLinkStatus string
+
+const (
+	ShareLinkActive    ShareLinkStatus = "active"
+	ShareLinkExpired   ShareLinkStatus = "expired"
+	ShareLinkExhausted ShareLinkStatus = "exhausted" // Max views reached
+	ShareLinkRevoked   ShareLinkStatus = "revoked"
+)
+
+// ShareLinkType is what a share link opens
+type ShareLinkType string
+
+const (
+	ShareLinkTypeLink  ShareLinkType = "link"  // A page a guest opens once to see the network
+	ShareLinkTypeKiosk ShareLinkType = "kiosk" // A live QR code for a lobby screen, updated on every change
+)
+
+// ShareLink grants access to a WiFi credential without logging in, through a secret token.
+// Only a hash of the token is stored; the token itself is shown once when the link is created.
+type ShareLink struct {
+	ID                uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CredentialID      uuid.UUID     `gorm:"type:uuid;not null;index" json:"credential_id"`
+	UserID            uuid.UUID     `gorm:"type:uuid;not null" json:"user_id"` // Who created the link
+	Type              ShareLinkType `gorm:"type:varchar(20);not null;default:'link'" json:"type"`
+	TokenHash         string        `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	PinHash           string        `json:"-"` // bcrypt hash, empty when no PIN is required
+	ShowPassword      bool          `gorm:"default:false" json:"show_password"`
+	ExpiresAt         *time.Time    `json:"expires_at"`
+	MaxViews          *int          `json:"max_views"`
+	ViewCount         int           `gorm:"not null;default:0" json:"view_count"`
+	FailedPinAttempts int           `gorm:"not null;default:0" json:"-"`
+	LastViewedAt      *time.Time    `json:"last_viewed_at"`
+	RevokedAt         *time.Time    `json:"revoked_at"`
+	CreatedAt         time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (s *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for ShareLink model
+func (ShareLink) TableName() string {
+	return "share_links"
+}
+
+// Status reports whether the link can be opened at now
+func (s *ShareLink) Status(now time.Time) ShareLinkStatus {
+	switch {
+	case s.RevokedAt != nil:
+		return ShareLinkRevoked
+	case s.ExpiresAt != nil && !now.Before(*s.ExpiresAt):
+		return ShareLinkExpired
+	case s.MaxViews != nil && s.ViewCount >= *s.MaxViews:
+		return ShareLinkExhausted
+	default:
+		return ShareLinkActive
+	}
+}
+
+// ViewsRemaining returns how many more times the link can be opened, or nil if unlimited
+func (s *ShareLink) ViewsRemaining() *int {
+	if s.MaxViews == nil {
+		return nil
+	}
+	remaining := max(*s.MaxViews-s.ViewCount, 0)
+	return &remaining
+}
+
+// PublicShareLink represents share link data safe for the link's owner
+type PublicShareLink struct {
+	ID           uuid.UUID       `json:"id"`
+	CredentialID uuid.UUID       `json:"credential_id"`
+	UserID       uuid.UUID       `json:"user_id"`
+	Type         ShareLinkType   `json:"type"`
+	ShowPassword bool            `json:"show_password"`
+	RequiresPin  bool            `json:"requires_pin"`
+	ExpiresAt    *time.Time      `json:"expires_at"`
+	MaxViews     *int            `json:"max_views"`
+	ViewCount    int             `json:"view_count"`
+	LastViewedAt *time.Time      `json:"last_viewed_at"`
+	RevokedAt    *time.Time      `json:"revoked_at"`
+	Status       ShareLinkStatus `json:"status"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// ToPublic converts ShareLink to PublicShareLink
+func (s *ShareLink) ToPublic() *PublicShareLink {
+	return &PublicShareLink{
+		ID:           s.ID,
+		CredentialID: s.CredentialID,
+		UserID:       s.UserID,
+		Type:         s.Type,
+		ShowPassword: s.ShowPassword,
+		RequiresPin:  s.PinHash != "",
+		ExpiresAt:    s.ExpiresAt,
+		MaxViews:     s.MaxViews,
+		ViewCount:    s.ViewCount,
+		LastViewedAt: s.LastViewedAt,
+		RevokedAt:    s.RevokedAt,
+		Status:       s.Status(time.Now()),
+		CreatedAt:    s.CreatedAt,
+	}
+}
@@ -17,12 +17,14 @@ const (
 
 // User represents a user in the system
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Email        string    `gorm:"uniqueIndex;not null;size:255" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"` // Never expose password hash in JSON
-	Role         UserRole  `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
-	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email        string         `gorm:"uniqueIndex;not null;size:255" json:"email"`
+	PasswordHash string         `gorm:"not null" json:"-"` // Never expose password hash in JSON
+	Role         UserRole       `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	Plan         Plan           `gorm:"type:varchar(20);not null;default:'free'" json:"plan"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"` // Set while the account is in the trash
 
 	// Relationships
 	WifiCredentials []WifiCredential `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
@@ -37,6 +39,9 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.Role == "" {
 		u.Role = RoleUser
 	}
+	if u.Plan == "" {
+		u.Plan = PlanFree
+	}
 	return nil
 }
 
@@ -47,10 +52,12 @@ func (User) TableName() string {
 
 // PublicUser represents user data safe for public consumption
 type PublicUser struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Role      UserRole  `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID  `json:"id"`
+	Email     string     `json:"email"`
+	Role      UserRole   `json:"role"`
+	Plan      Plan       `json:"plan"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // ToPublic converts User to PublicUser
@@ -59,7 +66,9 @@ func (u *User) ToPublic() *PublicUser {
 		ID:        u.ID,
 		Email:     u.Email,
 		Role:      u.Role,
+		Plan:      u.Plan,
 		CreatedAt: u.CreatedAt,
+		DeletedAt: deletedAtPtr(u.DeletedAt),
 	}
 }
 
@@ -67,3 +76,11 @@ func (u *User) ToPublic() *PublicUser {
 func (u *User) IsAdmin() bool {
 	return u.Role == RoleAdmin
 }
+
+// deletedAtPtr returns the deletion time of a soft-deleted record, or nil if it isn't deleted
+func deletedAtPtr(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return &deletedAt.Time
+}
@@ -17,20 +17,60 @@ const (
 	SecurityNone SecurityType = "nopass"
 )
 
+// KeyFormat defines how a WiFi password is interpreted
+type KeyFormat string
+
+const (
+	KeyFormatPassphrase KeyFormat = "passphrase" // Text passphrase (WPA) or ASCII key (WEP)
+	KeyFormatHex        KeyFormat = "hex"        // Raw key as hex digits (64-digit WPA PSK, WEP hex key)
+)
+
+// ValidityStatus describes where the current time falls in a credential's validity window
+type ValidityStatus string
+
+const (
+	ValidityScheduled ValidityStatus = "scheduled" // Before valid_from
+	ValidityActive    ValidityStatus = "active"
+	ValidityExpired   ValidityStatus = "expired" // After valid_until
+)
+
+// CustomField is a free-form key/value pair kept with a credential, such as a router admin URL or VLAN
+type CustomField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 // WifiCredential represents a WiFi credential with QR code
 type WifiCredential struct {
-	ID                uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID            uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
-	SSID              string       `gorm:"column:ssid;not null;size:255" json:"ssid"`
-	EncryptedPassword string       `gorm:"not null" json:"-"` // Never expose encrypted password
-	SecurityType      SecurityType `gorm:"type:varchar(20);not null" json:"security_type"`
-	IsHidden          bool         `gorm:"default:false" json:"is_hidden"`
-	QRCodeData        string       `gorm:"type:text" json:"qr_code_data"` // Base64 encoded PNG
-	CreatedAt         time.Time    `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt         time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+	ID                  uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID              uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"` // Creator; the owner unless OrganizationID is set
+	OrganizationID      *uuid.UUID     `gorm:"type:uuid;index" json:"organization_id"`  // Owning organization, nil for personal credentials
+	SSID                string         `gorm:"column:ssid;not null;size:255" json:"ssid"`
+	EncryptedPassword   string         `gorm:"not null" json:"-"`      // Never expose encrypted password
+	PasswordFingerprint string         `gorm:"size:64;index" json:"-"` // Keyed hash for reuse detection
+	PasswordShort       *bool          `json:"-"`                      // Set when the password is saved, nil until checked
+	PasswordDictionary  *bool          `json:"-"`                      // Common, a dictionary word or the SSID; nil until checked
+	SecurityType        SecurityType   `gorm:"type:varchar(20);not null" json:"security_type"`
+	KeyFormat           KeyFormat      `gorm:"type:varchar(20);not null;default:'passphrase'" json:"key_format"`
+	IsHidden            bool           `gorm:"default:false" json:"is_hidden"`
+	QRCodeData          string         `gorm:"type:text" json:"qr_code_data"`                     // Base64 encoded PNG
+	EncryptedNotes      string         `gorm:"type:text" json:"-"`                                // Encrypted like the password, empty without notes
+	EncryptedFields     string         `gorm:"column:encrypted_custom_fields;type:text" json:"-"` // Encrypted JSON array of CustomField
+	LocationID          *uuid.UUID     `gorm:"type:uuid;index" json:"location_id"`
+	ValidFrom           *time.Time     `json:"valid_from"`
+	ValidUntil          *time.Time     `json:"valid_until"`
+	RotationSchedule    string         `gorm:"size:100" json:"rotation_schedule"` // "daily", "weekly" or a cron expression
+	NextRotationAt      *time.Time     `gorm:"index" json:"next_rotation_at"`
+	LastRotatedAt       *time.Time     `json:"last_rotated_at"`
+	Version             int            `gorm:"not null;default:1" json:"version"` // Incremented on every save, for optimistic concurrency; unrelated to the version history
+	CreatedAt           time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"` // Set while the credential is in the trash
 
 	// Relationships
-	User *User `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
+	User     *User     `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
+	Location *Location `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Tags     []Tag     `gorm:"many2many:wifi_credential_tags;joinForeignKey:CredentialID;joinReferences:TagID" json:"tags,omitempty"`
 }
 
 // BeforeCreate hook to generate UUID if not set
@@ -38,6 +78,9 @@ func (w *WifiCredential) BeforeCreate(tx *gorm.DB) error {
 	if w.ID == uuid.Nil {
 		w.ID = uuid.New()
 	}
+	if w.Version == 0 {
+		w.Version = 1
+	}
 	return nil
 }
 
@@ -48,28 +91,90 @@ func (WifiCredential) TableName() string {
 
 // PublicWifiCredential represents WiFi credential data safe for public consumption
 type PublicWifiCredential struct {
-	ID           uuid.UUID    `json:"id"`
-	UserID       uuid.UUID    `json:"user_id"`
-	SSID         string       `json:"ssid"`
-	SecurityType SecurityType `json:"security_type"`
-	IsHidden     bool         `json:"is_hidden"`
-	QRCodeData   string       `json:"qr_code_data"`
-	CreatedAt    time.Time    `json:"created_at"`
-	UpdatedAt    time.Time    `json:"updated_at"`
+	ID             uuid.UUID    `json:"id"`
+	UserID         uuid.UUID    `json:"user_id"`
+	OrganizationID *uuid.UUID   `json:"organization_id"`
+	SSID           string       `json:"ssid"`
+	SecurityType   SecurityType `json:"security_type"`
+	KeyFormat      KeyFormat    `json:"key_format"`
+	IsHidden       bool         `json:"is_hidden"`
+	QRCodeData     string       `json:"qr_code_data,omitempty"` // Encodes the password, so only for callers who may reveal it
+	LocationID     *uuid.UUID   `json:"location_id"`
+	Tags           []Tag        `json:"tags"`
+
+	// Notes and custom fields are encrypted and only returned by the reveal endpoint
+	HasNotes        bool `json:"has_notes"`
+	HasCustomFields bool `json:"has_custom_fields"`
+
+	ValidFrom        *time.Time     `json:"valid_from"`
+	ValidUntil       *time.Time     `json:"valid_until"`
+	Status           ValidityStatus `json:"status"`
+	RotationSchedule string         `json:"rotation_schedule,omitempty"`
+	NextRotationAt   *time.Time     `json:"next_rotation_at,omitempty"`
+	LastRotatedAt    *time.Time     `json:"last_rotated_at,omitempty"`
+
+	Version   int        `json:"version"` // Send as If-Match to update or delete
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // ToPublic converts WifiCredential to PublicWifiCredential
 func (w *WifiCredential) ToPublic() *PublicWifiCredential {
+	tags := w.Tags
+	if tags == nil {
+		tags = []Tag{}
+	}
+
 	return &PublicWifiCredential{
-		ID:           w.ID,
-		UserID:       w.UserID,
-		SSID:         w.SSID,
-		SecurityType: w.SecurityType,
-		IsHidden:     w.IsHidden,
-		QRCodeData:   w.QRCodeData,
-		CreatedAt:    w.CreatedAt,
-		UpdatedAt:    w.UpdatedAt,
+		ID:             w.ID,
+		UserID:         w.UserID,
+		OrganizationID: w.OrganizationID,
+		SSID:           w.SSID,
+		SecurityType:   w.SecurityType,
+		KeyFormat:      w.KeyFormat,
+		IsHidden:       w.IsHidden,
+		QRCodeData:     w.QRCodeData,
+		LocationID:     w.LocationID,
+		Tags:           tags,
+
+		HasNotes:        w.EncryptedNotes != "",
+		HasCustomFields: w.EncryptedFields != "",
+
+		ValidFrom:        w.ValidFrom,
+		ValidUntil:       w.ValidUntil,
+		Status:           w.ValidityStatus(time.Now()),
+		RotationSchedule: w.RotationSchedule,
+		NextRotationAt:   w.NextRotationAt,
+		LastRotatedAt:    w.LastRotatedAt,
+
+		Version:   w.Version,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+		DeletedAt: deletedAtPtr(w.DeletedAt),
+	}
+}
+
+// ToPublicFor converts WifiCredential to PublicWifiCredential for a caller holding
+// permission. The QR code holds the password in plain text, so it is left out unless
+// the permission allows revealing the password.
+func (w *WifiCredential) ToPublicFor(permission GrantPermission) *PublicWifiCredential {
+	public := w.ToPublic()
+	if !permission.Includes(GrantReveal) {
+		public.QRCodeData = ""
+	}
+	return public
+}
+
+// ValidityStatus reports whether the credential is valid at now
+func (w *WifiCredential) ValidityStatus(now time.Time) ValidityStatus {
+	if w.ValidFrom != nil && now.Before(*w.ValidFrom) {
+		return ValidityScheduled
+	}
+	if w.ValidUntil != nil && !now.Before(*w.ValidUntil) {
+		return ValidityExpired
 	}
+	return ValidityActive
 }
 
 // IsValidSecurityType checks if the security type is valid
@@ -81,3 +186,13 @@ func IsValidSecurityType(st string) bool {
 		return false
 	}
 }
+
+// IsValidKeyFormat checks if the key format is valid
+func IsValidKeyFormat(kf string) bool {
+	switch KeyFormat(kf) {
+	case KeyFormatPassphrase, KeyFormatHex:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VersionChange describes what produced a credential version
+type VersionChange string
+
+const (
+	VersionChangeCreated  VersionChange = "created"
+	VersionChangeUpdated  VersionChange = "updated"
+	VersionChangeRestored VersionChange = "restored"
+	VersionChangeRotated  VersionChange = "rotated" // Password replaced by the rotation scheduler
+)
+
+// WifiCredentialVersion is an immutable snapshot of a WiFi credential after a change
+type WifiCredentialVersion struct {
+	ID                  uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CredentialID        uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_credential_version" json:"credential_id"`
+	Version             int           `gorm:"not null;uniqueIndex:idx_credential_version" json:"version"`
+	SSID                string        `gorm:"column:ssid;not null;size:255" json:"ssid"`
+	EncryptedPassword   string        `gorm:"not null" json:"-"` // Never expose encrypted password
+	PasswordFingerprint string        `gorm:"size:64" json:"-"`
+	SecurityType        SecurityType  `gorm:"type:varchar(20);not null" json:"security_type"`
+	KeyFormat           KeyFormat     `gorm:"type:varchar(20);not null;default:'passphrase'" json:"key_format"`
+	IsHidden            bool          `gorm:"default:false" json:"is_hidden"`
+	ChangeType          VersionChange `gorm:"type:varchar(20);not null" json:"change_type"`
+	RestoredFrom        *int          `json:"restored_from,omitempty"` // Version rolled back to, for restores
+	ChangedBy           *uuid.UUID    `gorm:"type:uuid" json:"changed_by,omitempty"`
+	CreatedAt           time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID if not set
+func (v *WifiCredentialVersion) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for WifiCredentialVersion model
+func (WifiCredentialVersion) TableName() string {
+	return "wifi_credential_versions"
+}
+
+// PublicWifiCredentialVersion represents version data safe for public consumption
+type PublicWifiCredentialVersion struct {
+	Version         int           `json:"version"`
+	SSID            string        `json:"ssid"`
+	SecurityType    SecurityType  `json:"security_type"`
+	KeyFormat       KeyFormat     `json:"key_format"`
+	IsHidden        bool          `json:"is_hidden"`
+	PasswordChanged bool          `json:"password_changed"`
+	ChangeType      VersionChange `json:"change_type"`
+	RestoredFrom    *int          `json:"restored_from,omitempty"`
+	ChangedBy       *uuid.UUID    `json:"changed_by,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
+// ToPublic converts WifiCredentialVersion to PublicWifiCredentialVersion.
+// previous is the version before this one, or nil for the first version.
+func (v *WifiCredentialVersion) ToPublic(previous *WifiCredentialVersion) *PublicWifiCredentialVersion {
+	return &PublicWifiCredentialVersion{
+		Version:         v.Version,
+		SSID:            v.SSID,
+		SecurityType:    v.SecurityType,
+		KeyFormat:       v.KeyFormat,
+		IsHidden:        v.IsHidden,
+		PasswordChanged: previous != nil && previous.PasswordFingerprint != v.PasswordFingerprint,
+		ChangeType:      v.ChangeType,
+		RestoredFrom:    v.RestoredFrom,
+		ChangedBy:       v.ChangedBy,
+		CreatedAt:       v.CreatedAt,
+	}
+}
@@ -0,0 +1,55 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// quietZone is the light border width in modules required around a symbol
+const quietZone = 4
+
+// Image renders the code as a size x size black-on-white image including the quiet zone.
+// Every module is drawn with the same whole number of pixels and any leftover pixels
+// are split evenly around the symbol. If size is smaller than the number of modules a
+// larger image is returned.
+func (c *Code) Image(size int) image.Image {
+	realSize := c.Size + quietZone*2
+	if size < realSize {
+		size = realSize
+	}
+	pixelsPerModule := size / realSize
+	offset := (size - realSize*pixelsPerModule) / 2
+
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+
+	for my := 0; my < c.Size; my++ {
+		for mx := 0; mx < c.Size; mx++ {
+			if !c.modules[my][mx] {
+				continue
+			}
+			x0 := offset + (mx+quietZone)*pixelsPerModule
+			y0 := offset + (my+quietZone)*pixelsPerModule
+			for y := y0; y < y0+pixelsPerModule; y++ {
+				for x := x0; x < x0+pixelsPerModule; x++ {
+					img.Pix[img.PixOffset(x, y)] = 1
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// PNG renders the code as a PNG image, see Image for sizing
+func (c *Code) PNG(size int) ([]byte, error) {
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, c.Image(size)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
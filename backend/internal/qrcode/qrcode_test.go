@@ -0,0 +1,627 @@
+package qrcode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	zxingqr "github.com/makiuchi-d/gozxing/qrcode"
+)
+
+const (
+	L = Low
+	M = Medium
+	Q = Quartile
+	H = High
+)
+
+// Known-answer symbols were generated with rsc.io/qr/coding, an independent encoder,
+// at the same version, level and mask. Digests are SHA-256 of the module rows
+// written as 0 (light) and 1 (dark) and joined with newlines.
+
+// goldenSample is repeated and cut to length for the byte mode vectors
+const goldenSample = "WIFI:T:WPA;S:Guest Lobby;P:correct horse battery staple;H:;;"
+
+// Byte mode at every version and level, filling the symbol up to two bytes short of
+// capacity so some vectors need terminator and pad codewords
+var goldenByteVectors = []struct {
+	version int
+	level   Level
+	mask    int
+	length  int
+	digest  string
+}{
+	{1, L, 1, 16, "2894af6608d03b83ef4e7d68229682bed93bb98189f31a514d8abbd0e8dfede1"},
+	{1, M, 2, 13, "df8e9e91fda07c4dd63e8924c053fc74254c213bc9a2d7add48cf4bcfc81af64"},
+	{1, Q, 3, 10, "b9a76d05de77a08a50d002d344b065549f8493d1dac3b5336887c360fa834969"},
+	{1, H, 4, 6, "570f9fb0696606a4135b0375b66fdd8e58bc2e548bb00a16dea24b4a0f0a35a6"},
+	{2, L, 2, 30, "25dc81d72df7cda42347d8148a58a6d8107a56c044c8df998c0c877c7b14ed03"},
+	{2, M, 3, 24, "2aecfa8e188f647d04929a898c242ef49b66fc3a6d1fc4f481c206a881146c68"},
+	{2, Q, 4, 18, "56e96656e36bc38eb03e7e17ccb2e7fea30d2458f146acdb557486812b972a9e"},
+	{2, H, 5, 12, "937ef41295c19709e6c76a5b5b5ff142f08b8425305052e6709397c685db96ca"},
+	{3, L, 3, 53, "105754484a0f355a3a4f3bf8849e844c002ffa113de50a76b309529a13fdf06d"},
+	{3, M, 4, 42, "d9970d70b6bc152abfb233b5fdce3f42e3497895e1439b4f6806149a08014e9c"},
+	{3, Q, 5, 32, "78dbb66d7f29c6947e032f146916d83b0d7a7e80f9cddda014654d26043ee9a9"},
+	{3, H, 6, 24, "0b28dfdefd9228df486c108af43969f79547f80dd9f82bb8062a820f848094bf"},
+	{4, L, 4, 77, "23507aaeac236d7b489a8dc142d26f50e246f3799d1b29ed2af41d8c4a258668"},
+	{4, M, 5, 61, "690d8cdd6b1ad23021457990abfef53e5f5c8c37f617c434f73d46b7f4952ce2"},
+	{4, Q, 6, 45, "ba14e4f4ad4740d2acb8443c70ef7b4bc19451141f8a3868c1d92819fb3d4fd7"},
+	{4, H, 7, 33, "adc19c460eca410a64748e6d794aac4b06d32d726796533e9e624b22a17b6caa"},
+	{5, L, 5, 104, "465819d3e7c9962afd511a8dc49bf273339eea2b729c79c198ab5eef14eb8392"},
+	{5, M, 6, 82, "1c0c47c6538e92fa65f6dd1cfb4c39c279e3780172836a985a9be061aa03f1a7"},
+	{5, Q, 7, 58, "a19b50efa75edaf5f252876b0fb0f01dbaf5b03369468dd87bcee57b1b49da3e"},
+	{5, H, 0, 42, "79be8658bc6f22e11b3782fdf77fabb41195f4b8b7116847b3c928964bcb78ca"},
+	{6, L, 6, 134, "c3d90a9f9f43633df2c3a19e042217fd33c5475c454705abae993f51ef762ba2"},
+	{6, M, 7, 106, "75300ee61e696cae0f0efe45688d93fe3f0223ec2352e7b630eaaf47b7dd34dc"},
+	{6, Q, 0, 74, "dbc7c566edafe54618e9b012305b4fed6aed0c986242e3b28be1223b890faf1c"},
+	{6, H, 1, 58, "37fa281a58ef6430c76836659620561a7e6f69a4e01962caf8c12a749abdf71e"},
+	{7, L, 7, 153, "42d511914b52e181a87ba08c32f0bd3adbccf922ea8be337f678ed76005f4a50"},
+	{7, M, 0, 121, "469867ed29f58677606108ba0fc3e38250641d8743e75355abf759cb87bfaa43"},
+	{7, Q, 1, 85, "a76f7732b734978245c2b8f26aaa75f9be7427cbac80cedb3dfc753bb9cbfdb0"},
+	{7, H, 2, 63, "c6fc98e84406cd3ac00151e7968a87d4a492d5ed309ddf6fd90ff5cd5fdf4d06"},
+	{8, L, 0, 190, "4d94f43ca835b628034f3e37302c44be8c5edfd5546c90e5ac4fae532ae0840e"},
+	{8, M, 1, 150, "78c8068fe62bdc437824bf53e6a6105c9986201d2c634a4e19311db61915ad2f"},
+	{8, Q, 2, 106, "df6bd7d525ec7348d1001933b3c7cf3430f4f3753b2c74c42698d68eff74137b"},
+	{8, H, 3, 82, "070048bad11b78c379dba509ced0cfc2ca29c58c2fc6f85c6facb22f12e1aa98"},
+	{9, L, 1, 230, "732c86be80b75823b8abc926802c4b9a18ee976b2772536eb129e5febfff15aa"},
+	{9, M, 2, 180, "a45709d36bec5e46abed0f8b52eef918e39cfad939d35fe92854266fa9a7cc3f"},
+	{9, Q, 3, 130, "b7ed31f102170add300e3a3298a6586454efb47a132c5b5b0c5cd51cebe6cf49"},
+	{9, H, 4, 98, "f9f33a739a7b80d71401eed19d7cdca6851e8d4e6923e3fec57458821faea0cb"},
+	{10, L, 2, 270, "9c00973b3d63228d9c4a338136ef7f8d262b9991d78e944aee6cc29aa2ba5e26"},
+	{10, M, 3, 212, "f44ea9f0603fd7dd1c9de2de80cabcdd64fa13f429ac73442c43c11226d8a778"},
+	{10, Q, 4, 150, "81146ed40c539458e64a087e0f4bbacdd17cab2b8f83787f72861b31421722a0"},
+	{10, H, 5, 118, "45e7fbb09ab28d8ed017742baf0afbbd19fb1be5746a0a7414cf95b23bb407a0"},
+	{11, L, 3, 319, "b6fd9c53fc798126424747cb5c6f6a219b808159242755010ef4010dd8c26c9c"},
+	{11, M, 4, 249, "d01afe3294f893c8df304a3734e558ea8e4bd0169d703e42d892ac87354bf875"},
+	{11, Q, 5, 175, "92eb3af75893f2cc076ddf83628a1a2fdcfea4dbcc160c3ae1781e04a2028324"},
+	{11, H, 6, 135, "37585ec1e8c4dfae81cb625362be3c531295c17e8cece75eed2b69cf28908c51"},
+	{12, L, 4, 367, "5b108c4d4229b1f39b5bebb6a0174505ede699f98cd6642502505889d9bac10a"},
+	{12, M, 5, 287, "39be31de7a2fd9bd9713090517785f0761a49dc0ff3c7cf27242cf941d53cf13"},
+	{12, Q, 6, 203, "f23ce230317257307d77d90fb4bec53f5ea406e43c485282f34eacf20cf32453"},
+	{12, H, 7, 155, "4e266e5ea27ce8a83218d9695d465ff05245e65847d85b79533c38ae26cffd76"},
+	{13, L, 5, 424, "33d0d1e93bc1cf368cf01c71ad3e614f39c1536e81d2d8ba29cbb2d6261215eb"},
+	{13, M, 6, 330, "450fd4b4b8f75112df07257cdacf743872956230a2866972c8a9258524892f14"},
+	{13, Q, 7, 240, "dca8685368a70ae9f21588ef3b9c2dd7186c69361b379190f7b838890105d62d"},
+	{13, H, 0, 176, "be2e1956ac1cc2a3efc12ba455f1945379c4e7299dd19890f2392746ae376c9a"},
+	{14, L, 6, 456, "7b38583749ae864b6acadeb6c381f9dfb6aeec9e7f4c440d899f25a244addb0b"},
+	{14, M, 7, 360, "8dad2c7723efddf867f5fc5b4ed37390b76f72126251b83da70b8e888f40e078"},
+	{14, Q, 0, 256, "6a9a607d9f772ef4709b0131f6d1e0da80e02af860252f35240faa1b141d8a44"},
+	{14, H, 1, 192, "e30b036d5ecb22273ffca0dce049a4d68c356adc02e8342a9b08a3ed74b12078"},
+	{15, L, 7, 520, "e92e1d5d846254ff432abe07dcc29d0103fd424f4873bdc8f86a848521f11101"},
+	{15, M, 0, 412, "865b55c22f077aed3712a0d8738fec0fd275c48be5f6591e355315452e61bb2e"},
+	{15, Q, 1, 292, "54bd469185519a35c769c88de63b2511a64b7f4646e0e1a52b7f29b4a5c6cd89"},
+	{15, H, 2, 220, "c4c4911b6add293d9908f5b87184b215b528f90dd6298855f0f267b6590eaf03"},
+	{16, L, 0, 585, "39d97cfe54cbb958bd79b83173682fa3ef839d6aa78316df2ec3da1e898e250f"},
+	{16, M, 1, 449, "a5c2a763771bcacbcc99c2b686d1002626dfc7fde1f1b9b34fbd0bd2670c592c"},
+	{16, Q, 2, 321, "ef81e3c3d40126448ad9fe20c263076ca062a4da8a8487c84cf2cb358d028095"},
+	{16, H, 3, 249, "b150cccbbd3bd5ee2a56042509091c3df4841e38d6a96047b2bcbf06422dddc4"},
+	{17, L, 1, 642, "ef8e4f8a43892c18a7b6ab7d4ad76487101b48ea6cd93033de5efcc839060f31"},
+	{17, M, 2, 502, "b314a511886b75b86372389e5ca36130d095b5cadc71b37ca42926ac9c13080b"},
+	{17, Q, 3, 362, "4d660975f50ecfeaf1a99f6b7c1d6b9029e2b031b22d92ae9b347353874f218b"},
+	{17, H, 4, 278, "bd84c5655c0478f5efada6afbe7cb4bb0dc07a48753ebcc0a79a86a73989f5a0"},
+	{18, L, 2, 718, "67def6c33bbea77cba4e116d631dbbead812138bf32eb882113005a63235ff75"},
+	{18, M, 3, 560, "a958936f96a8cbb0d5a45f4a1ff092af6885d57ee33d1ff8beed6599d35d59b3"},
+	{18, Q, 4, 394, "4fbe4293c8ccd92b9280629299b0779ae9a09bad12ce7ef832b97bb2116156fb"},
+	{18, H, 5, 310, "783705f626356fbfa4dec32b0c41dc3e1013725959a504681c9868bad88a14a9"},
+	{19, L, 3, 791, "5b10e2b753dda0787ce2961c0f48369885d935d3940437de73c9d3a764a5711b"},
+	{19, M, 4, 623, "076f2e6e1b9cfec611639e333970ff575a469b062eb3b0b4233d6f887746ad27"},
+	{19, Q, 5, 441, "670815856e74a73f6f2424e3c7c606a622de3e866327de6f69685af6d1e8e746"},
+	{19, H, 6, 337, "7559c2b367e7dde94b61423e5578cd58d052bf8510b66fd755c5a2b038a6ebb0"},
+	{20, L, 4, 856, "c16ccc1de8ed3f39290ff62824a6f3a3cd53be4efeec2e2bbfb3b00041049861"},
+	{20, M, 5, 664, "402e1d6e4c04b30cdb7e2bb9a0b5768b82f5f9b7734333cef350c658610ac21a"},
+	{20, Q, 6, 480, "a4790b6ff61d87d987467a4f73c5d2dd8be24519c17c9e50fbb284c240db1ed0"},
+	{20, H, 7, 380, "1a418d8c338e89a3afa6cced2c53ac8e06f30eefacde4d81ace04229b35f9c3b"},
+	{21, L, 5, 929, "cada68d7937388ebde5da55911d9c1bf5905f492b287761107a0f9971441c460"},
+	{21, M, 6, 711, "f64be631bc2f045c370702dbaaeabe9ca7ec9bba20c7c941df643381e780337c"},
+	{21, Q, 7, 509, "0b71c91c9219d4f19589c7378acf5b7182d431b37ce04aaebe7ce04b04db00e7"},
+	{21, H, 0, 403, "d75d92b01f7e621f4a51908444c4f5101bfa331614eadbdc35ad00f3ae7dc16d"},
+	{22, L, 6, 1002, "f10a826c22f7bc0237c6e7b5195d741c7d8d81e4afa60e49f19e46201eb9e7d5"},
+	{22, M, 7, 778, "55b9087b29b269a209e681443f9c2ef2fa4f186ab95aca1c84a31b1e3fbe320d"},
+	{22, Q, 0, 564, "ef92aabdf7d352349003f7c770a0912b44953c2fc30b69ca66553bae65d1fd20"},
+	{22, H, 1, 438, "39d3c661fb80bb276df3ed0fa9e575568b02dd447984f63fd30db656d5d10867"},
+	{23, L, 7, 1089, "0a8dcbaf10f7da99272dd910e0da88ebdc0f4a5ffbdae3a66f8c4cf1f237fa9f"},
+	{23, M, 0, 855, "1e43b85b40f43d62b824d1ac3031d214aa72cf93951dec3cda3256a2fb44fda1"},
+	{23, Q, 1, 609, "81ce0d2509a5234784c435c559fa365202ec905cc9dd7fa170f3204e3118c704"},
+	{23, H, 2, 459, "8409a48205bab96bfaa2a898143253e253498b25698fbc4855d9affc6bc5295c"},
+	{24, L, 0, 1171, "bdd721cde62986d1b1b0fae2eaf1c1a39279c762e7de410fca72609ee720243f"},
+	{24, M, 1, 911, "f430b516b462ef5143d0ecc5935ad8c0c66f433e7fc7512a3e1347ebf0d29387"},
+	{24, Q, 2, 661, "33450a1b7ad811956c694c5b1330421575ef7455ad2df73894d84b6dec1643c9"},
+	{24, H, 3, 511, "c3affa4ca4007d01f5a0c426a7a0583518c214b55c5b0d3486055849595ce087"},
+	{25, L, 1, 1272, "ccae0f72356289461a3a7747e9e927a001325b40b057bb397bf057ad274fb6ca"},
+	{25, M, 2, 996, "dcea19f79df2b7d0a869387a984dbf22d5f8a80c240d81dd5479ee50da952aea"},
+	{25, Q, 3, 714, "70ae1b721e5f257a00f08024470df03e9fb54279af0d75005374cdf0bdd7d13a"},
+	{25, H, 4, 534, "fdf5e2f4269e49e49fdfba9b1f17068121cddd549032a556947fd9e74da15f6a"},
+	{26, L, 2, 1365, "f08bf71f164aafca11920f5925bb3203b7211abd56d9d112d8262a589f868fa9"},
+	{26, M, 3, 1057, "77bb90f58172851b2c4c3a291ab31e63d8fa2a52b536bff2c2ad09c84eff06c1"},
+	{26, Q, 4, 749, "d6357770c41a5018cfba1163b161ee3ee4d4eea29fee8a0c4fad5890860ff417"},
+	{26, H, 5, 591, "84e9ee8f1cedee85c5eb999b94b0e883baf3f49a968d5f4b32b8a84fba41c33c"},
+	{27, L, 3, 1465, "112ee9ef65cd8680cfe74a5ed3e57cd84db85e853fcea088d49e83ac59fee40d"},
+	{27, M, 4, 1125, "60ef6109d23e155d68708d0f375377090826041b77fadd90fb2a728d44d71384"},
+	{27, Q, 5, 805, "1bba5ee3106e138082c8e49fb352f7e5c93689aa441007ae649c4f5e7ad773b6"},
+	{27, H, 6, 625, "24a604706c8d27676d8c64ac23157781a0167ff7a92c503ae3c35b09370670b9"},
+	{28, L, 4, 1527, "666de344264b7b20419fb6f5098e69a1a97abfec70204ea1f053e4ae8f6eb268"},
+	{28, M, 5, 1189, "4598e4d84cda9e5db9dfae7639347c83ada499fc7ad8bf1c38790883d54645fa"},
+	{28, Q, 6, 867, "be162d726fe1d47f5a194ec1b20c05a83416361ec347c710b6a08d402d5d54a9"},
+	{28, H, 7, 657, "b6e7faa81d9459a42849cc8a40e32c5868ad9b14c867c4e5b8f57ff2ad41c514"},
+	{29, L, 5, 1626, "64331fe0516e3f68553bc47a377c1372873ee76f921a633053ccd487f7622028"},
+	{29, M, 6, 1262, "0efc62b6823c4b3b087454b3b91e0e82356225aa6948a564c52518360dd7c5c3"},
+	{29, Q, 7, 906, "fd542943007048e4b93dd946b7585686482ec63ac3f148130b2af65b329684da"},
+	{29, H, 0, 696, "c3c3912b6cb11c52e954051b9ae8fc0147f34602869960cf674dbec9a237b4d9"},
+	{30, L, 6, 1732, "fec4ad8d52443ef3d6f5d16f76a8d9cfd041b47ffbb2a84a14e0b999fdf473da"},
+	{30, M, 7, 1370, "2e758039c382c3028c235dbf1c781a7b24f0ccecbf6913d14bff6e2d04e848ee"},
+	{30, Q, 0, 982, "bd455291339d388b31953c81554c0183be3a6255b0b855ee58c6eac0cdd6cc21"},
+	{30, H, 1, 742, "0e6eac3aa2e5f6bafe92c0c1208eac44ffd30e20230740a91a77b6ba816365ea"},
+	{31, L, 7, 1839, "9ad603000fd489cd3373c93890958c81f4bc7b286cffae517f22ceba031a3752"},
+	{31, M, 0, 1451, "cda5bbe23b449b4c4d04793bd5148e3c72754fe43bde97301fa3de7363361191"},
+	{31, Q, 1, 1029, "356d75d892dfc1ecb47e85f823e3a2c9360a9b5626833734fd04f81827cef7db"},
+	{31, H, 2, 789, "b61ce9c9bae1b20845035b634eab7db0ad917f25b525551737322abc35f7bba9"},
+	{32, L, 0, 1950, "ac82e75cd2507c359d6339527f0fa3fe93212b7056b7f9ac88523d2dbced4108"},
+	{32, M, 1, 1536, "5ca25ac0c36e21a72a4e2de9b4d15e600922e97e1fcd600d8e89013da2b4b911"},
+	{32, Q, 2, 1110, "e55550479d23148e7f4364a8d68c34e5289554218067a8bd96fba48900a18530"},
+	{32, H, 3, 840, "942e7c7c830675b5a4af13d8b5484aaf56387d31726d5f278792e92c99a3dd7b"},
+	{33, L, 1, 2068, "a173d8ebcd4ca02c32cb9a33ea55c55a59b9f8b3aa05366c29e5fafe2d86a78b"},
+	{33, M, 2, 1628, "d062e42a933cb4339f06a7741abee8fc6227a974487c4a751c752b56c863ce88"},
+	{33, Q, 3, 1168, "bb767a4c1a4e280c6a297cfead12351f5eaa48c71567cb19592ceaf0178fec0e"},
+	{33, H, 4, 898, "dce4aefac442707bf751e4c5c60ec1b9cfa602301c2eb1b53b1d9b01c9f505e5"},
+	{34, L, 2, 2187, "6ea7b20c8462a8edb3c2fcf5b681f61f669a22567f61178ca707e2484af31b9b"},
+	{34, M, 3, 1721, "47ccae11c9dd0f12bf468eddce1e15e4dcb0d4187492090f351b402677a96e6d"},
+	{34, Q, 4, 1227, "0436820a42f20c3cc72268cb24c196bcc746aae5c5766424ddf030dd3acd7da6"},
+	{34, H, 5, 957, "0c2466c28f4c203377e7e612c19bd52a0d1a50f55b8e8f63b2061cca550ed8fe"},
+	{35, L, 3, 2301, "aa1d7adbb5c0fb7e83b5003af9118d683cd013f6efa6f00e1c32d908f850bbd9"},
+	{35, M, 4, 1807, "d92ac06b86f08ecf8ec8b83046682e6e6cea409c6025296c574cc1bbe88d44ba"},
+	{35, Q, 5, 1281, "6dcfa3a983b8f0c633502314a22d7231b9342d0160201856b9ddf487fe486249"},
+	{35, H, 6, 981, "786269005960fd04ad03ed6141c9bca9e666f342e29a96f0184fef805a4a7739"},
+	{36, L, 4, 2431, "bc2cf7baa0aea8d59af50953a1af470c5c4dccf594b1fd7281bfe474baa814a7"},
+	{36, M, 5, 1911, "09011524e895e2effd8b958efba34d1e7b2106083b7c50fab79f2a92914651c0"},
+	{36, Q, 6, 1351, "2723094946a5d16dc685b1d3d981914c2bd69f7b841f303231a459e19d635666"},
+	{36, H, 7, 1051, "26c2c6ec8196cd81a071ac6b14c61858307af4e9f7bfe0abea7ead4352247586"},
+	{37, L, 5, 2562, "d641de5e767137424fc3403dd3cf6545179886b815aecd5dc557a6d85fb7c57f"},
+	{37, M, 6, 1988, "614c95cd0955136b9da9aa1dfa692722cd5d0153fcb27da9462afbe2974668bc"},
+	{37, Q, 7, 1422, "c03b31d8aa634fb77cbc2e13271d24e49407cb8f42b8c77133b47f7d8b674fd3"},
+	{37, H, 0, 1092, "85c080ab0a430b9d90ca80cb6d8c5aca7ef123bac3425e5fadf790a1bdc1f8e8"},
+	{38, L, 6, 2697, "ee79ba7bc039ebcaeb37050459f99c464218c3773a31602404613a30a8e5eaa5"},
+	{38, M, 7, 2097, "792bb687ee9a33949bdcd9d4b45dc62fc9bfaabfa99dc888c26ff5a0e4440003"},
+	{38, Q, 0, 1497, "72b019b84adfa17539fa59e170789d5cbe0e0f6b654b5f6d95c1a1743be164ce"},
+	{38, H, 1, 1137, "a90abe75c21a2de2f0f22d1fce015d327d9654e23703cb6da135bf9f870d3ac8"},
+	{39, L, 7, 2809, "3c2cf6f48ce492ba3e6fe2ddaf75e888ee9f4d2e3854eb440e87a89173bbb480"},
+	{39, M, 0, 2213, "87267d65c4b126b81368584d873a63cc4dcf83785ee6037558c6492f3bb34768"},
+	{39, Q, 1, 1579, "f4f9fbf60c89465704483bf1912926012e3187d6250daf72d83a760e75f5e43d"},
+	{39, H, 2, 1219, "1c5dc9677ef16aef2d8751b53f4c1595654c8d6fb714d798a5844b09e265b1f5"},
+	{40, L, 0, 2952, "82e46b6275ed70b36dafc0be6cd0ea19d0342cdb4febc5adf390cbb06c9d7086"},
+	{40, M, 1, 2330, "60b89970e4a66c026800ebbdc8b96fb6b67fe9431f5e4596463f80038014ed20"},
+	{40, Q, 2, 1662, "77bbbe77ebbbe9bfe99f9df3f4e02765a505705974e0bf3ddf8fc4f66eb75024"},
+	{40, H, 3, 1272, "e352f32967362872ea49df9815a2d6792373773d3492a673dfa5aeaebdc94106"},
+}
+
+const (
+	goldenDigits = "3141592653589793238462643383279502884197"
+	goldenAlnum  = "HTTP://EXAMPLE.COM/WIFI/ID-42 $%*+-./:"
+)
+
+// Numeric and alphanumeric mode around the character count width changes at versions 10 and 27
+var goldenModeVectors = []struct {
+	version int
+	level   Level
+	mask    int
+	mode    Mode
+	length  int
+	digest  string
+}{
+	{1, M, 1, ModeNumeric, 34, "4eec1beba7e7c48c87be3f8ee500b8174216189347b7942900674739c048f5fc"},
+	{1, M, 1, ModeAlphanumeric, 20, "d0670b6b47bac7ce715381bb0ca7e31b4b5e2fa5bbf53825c7da5fe8a75f60c3"},
+	{9, M, 1, ModeNumeric, 432, "a2d6a02d628efb82e39360cdeea05cb34e71b256f95f6b4c37d430352e96b8d6"},
+	{9, M, 1, ModeAlphanumeric, 262, "325ab88ae3cc8944aea1a1dcf38229b4caa70fa5560115a2005d9dd66c93961a"},
+	{10, Q, 2, ModeNumeric, 364, "11eeed1af9addc01975d09fe030bf712df421a88028841b937161924e81b90e5"},
+	{10, Q, 2, ModeAlphanumeric, 221, "8efebc9ba6fa7c7e79fda74e35eaba2a1673fb66ec026dca3275dfd6978869a3"},
+	{26, Q, 2, ModeNumeric, 1804, "1e5e426996efca01183222c8523fa75c4801ced81dc6e4ed56ecf494059548a9"},
+	{26, Q, 2, ModeAlphanumeric, 1094, "365470f42f187551df11a8c3671d61e0ca7f43fb3d65af0f87e33a240a7c64e6"},
+	{27, H, 3, ModeNumeric, 1501, "5ef59425f93144ecddc001bf9d23386fd70a0ea9425eab2cee250e91578c1f2f"},
+	{27, H, 3, ModeAlphanumeric, 910, "2a56ad5c85ff9cf25c3c76581b281dac28ce8282e99b3778f69b54847c846284"},
+	{40, L, 0, ModeNumeric, 7089, "90e7401184fa6a624edd0fa800ff4444cc6dd6c4a7dc2f244063ecb9897d04d5"},
+	{40, L, 0, ModeAlphanumeric, 4296, "ec056ebecec3a16fcd468a4252497dc3fc20bd7c11a7349d5a76103847b8763d"},
+}
+
+// goldenHelloWorld is "HELLO WORLD" at version 1-M with mask 2
+var goldenHelloWorld = []string{
+	"111111100100101111111",
+	"100000100111101000001",
+	"101110101100101011101",
+	"101110101011001011101",
+	"101110101101101011101",
+	"100000101110101000001",
+	"111111101010101111111",
+	"000000001001100000000",
+	"101111100010101111100",
+	"101111011000110011111",
+	"001001110011000101001",
+	"001100001000000100000",
+	"011101111011000000100",
+	"000000001011111001011",
+	"111111100110101011101",
+	"100000101111111100110",
+	"101110101010100001110",
+	"101110101010100101100",
+	"101110101001010011000",
+	"100000100000000000101",
+	"111111101011010010000",
+}
+
+// repeatTo repeats sample and cuts it to n bytes
+func repeatTo(sample string, n int) []byte {
+	return []byte(strings.Repeat(sample, n/len(sample)+1)[:n])
+}
+
+// moduleRows writes the modules as rows of 0 and 1
+func moduleRows(c *Code) []string {
+	rows := make([]string, c.Size)
+	for y := range rows {
+		var b strings.Builder
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				b.WriteByte('1')
+			} else {
+				b.WriteByte('0')
+			}
+		}
+		rows[y] = b.String()
+	}
+	return rows
+}
+
+func moduleDigest(c *Code) string {
+	sum := sha256.Sum256([]byte(strings.Join(moduleRows(c), "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// buildMasked encodes segments at a fixed version and mask
+func buildMasked(t *testing.T, segments []Segment, level Level, version, mask int) *Code {
+	t.Helper()
+	code, err := buildSymbol(segments, level, version)
+	if err != nil {
+		t.Fatalf("buildSymbol: %v", err)
+	}
+	code.setMask(mask)
+	return code
+}
+
+func TestGoldenByteMode(t *testing.T) {
+	for _, v := range goldenByteVectors {
+		segment := MakeBytes(repeatTo(goldenSample, v.length))
+		code := buildMasked(t, []Segment{segment}, v.level, v.version, v.mask)
+		if got := moduleDigest(code); got != v.digest {
+			t.Errorf("version %d level %d mask %d: digest %s, want %s", v.version, v.level, v.mask, got, v.digest)
+		}
+	}
+}
+
+func TestGoldenNumericAndAlphanumeric(t *testing.T) {
+	for _, v := range goldenModeVectors {
+		var segment Segment
+		if v.mode == ModeNumeric {
+			segment = MakeNumeric(repeatTo(goldenDigits, v.length))
+		} else {
+			segment = MakeAlphanumeric(repeatTo(goldenAlnum, v.length))
+		}
+		code := buildMasked(t, []Segment{segment}, v.level, v.version, v.mask)
+		if got := moduleDigest(code); got != v.digest {
+			t.Errorf("version %d level %d mode %d: digest %s, want %s", v.version, v.level, v.mode, got, v.digest)
+		}
+	}
+}
+
+func TestGoldenHelloWorld(t *testing.T) {
+	code := buildMasked(t, []Segment{MakeAlphanumeric([]byte("HELLO WORLD"))}, Medium, 1, 2)
+	got := moduleRows(code)
+	for y := range goldenHelloWorld {
+		if got[y] != goldenHelloWorld[y] {
+			t.Errorf("row %d: %s, want %s", y, got[y], goldenHelloWorld[y])
+		}
+	}
+}
+
+// Codewords from the worked examples of ISO/IEC 18004 Annex I and the Thonky QR tutorial
+func TestErrorCorrectionCodewords(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		ecc  []byte
+	}{
+		{
+			name: "01234567 1-M",
+			data: []byte{16, 32, 12, 86, 97, 128, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17},
+			ecc:  []byte{165, 36, 212, 193, 237, 54, 199, 135, 44, 85},
+		},
+		{
+			name: "HELLO WORLD 1-M",
+			data: []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			ecc:  []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+	}
+
+	for _, tt := range tests {
+		got := reedSolomonRemainder(tt.data, reedSolomonDivisor(len(tt.ecc)))
+		if string(got) != string(tt.ecc) {
+			t.Errorf("%s: ECC %v, want %v", tt.name, got, tt.ecc)
+		}
+	}
+}
+
+func TestDataCodewordCapacity(t *testing.T) {
+	// ISO/IEC 18004 Table 7
+	tests := []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, Low, 19}, {1, Medium, 16}, {1, Quartile, 13}, {1, High, 9},
+		{7, Medium, 124}, {10, Quartile, 154}, {27, High, 628},
+		{40, Low, 2956}, {40, Medium, 2334}, {40, Quartile, 1666}, {40, High, 1276},
+	}
+
+	for _, tt := range tests {
+		if got := numDataCodewords(tt.version, tt.level); got != tt.want {
+			t.Errorf("version %d level %d: %d data codewords, want %d", tt.version, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	// ISO/IEC 18004 Annex E
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		14: {6, 26, 46, 66},
+		32: {6, 34, 60, 86, 112, 138},
+		36: {6, 24, 50, 76, 102, 128, 154},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+
+	for version, want := range tests {
+		got := alignmentPatternPositions(version)
+		if len(got) != len(want) {
+			t.Errorf("version %d: positions %v, want %v", version, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("version %d: positions %v, want %v", version, got, want)
+				break
+			}
+		}
+	}
+}
+
+// formatInformation lists the masked 15-bit format information of ISO/IEC 18004
+// Annex C, indexed by [level][mask]
+var formatInformation = [4][8]uint32{
+	Low:      {0x77C4, 0x72F3, 0x7DAA, 0x789D, 0x662F, 0x6318, 0x6C41, 0x6976},
+	Medium:   {0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0},
+	Quartile: {0x355F, 0x3068, 0x3F31, 0x3A06, 0x24B4, 0x2183, 0x2EDA, 0x2BED},
+	High:     {0x1689, 0x13BE, 0x1CE7, 0x19D0, 0x0762, 0x0255, 0x0D0C, 0x083B},
+}
+
+func TestFormatBits(t *testing.T) {
+	for level := Low; level <= High; level++ {
+		for mask := 0; mask < 8; mask++ {
+			code := buildMasked(t, []Segment{MakeBytes([]byte("format"))}, level, 1, mask)
+
+			// Bit 14 is the most significant bit of each copy
+			var first, second uint32
+			for i := 0; i <= 5; i++ {
+				first |= bitOf(code.Dark(8, i)) << i
+			}
+			first |= bitOf(code.Dark(8, 7)) << 6
+			first |= bitOf(code.Dark(8, 8)) << 7
+			first |= bitOf(code.Dark(7, 8)) << 8
+			for i := 9; i < 15; i++ {
+				first |= bitOf(code.Dark(14-i, 8)) << i
+			}
+			for i := 0; i < 8; i++ {
+				second |= bitOf(code.Dark(code.Size-1-i, 8)) << i
+			}
+			for i := 8; i < 15; i++ {
+				second |= bitOf(code.Dark(8, code.Size-15+i)) << i
+			}
+
+			want := formatInformation[level][mask]
+			if first != want || second != want {
+				t.Errorf("level %d mask %d: format %015b and %015b, want %015b", level, mask, first, second, want)
+			}
+			if !code.Dark(8, code.Size-8) {
+				t.Errorf("level %d mask %d: dark module is light", level, mask)
+			}
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	// ISO/IEC 18004 Annex D, versions 7 to 40
+	want := []uint32{
+		0x07C94, 0x085BC, 0x09A99, 0x0A4D3, 0x0BBF6, 0x0C762, 0x0D847, 0x0E60D,
+		0x0F928, 0x10B78, 0x1145D, 0x12A17, 0x13532, 0x149A6, 0x15683, 0x168C9,
+		0x177EC, 0x18EC4, 0x191E1, 0x1AFAB, 0x1B08E, 0x1CC1A, 0x1D33F, 0x1ED75,
+		0x1F250, 0x209D5, 0x216F0, 0x228BA, 0x2379F, 0x24B0B, 0x2542E, 0x26A64,
+		0x27541, 0x28C69,
+	}
+
+	for version := 7; version <= maxVersion; version++ {
+		code := buildMasked(t, []Segment{MakeBytes([]byte("version"))}, Low, version, 0)
+
+		var topRight, bottomLeft uint32
+		for i := 0; i < 18; i++ {
+			a, b := code.Size-11+i%3, i/3
+			topRight |= bitOf(code.Dark(a, b)) << i
+			bottomLeft |= bitOf(code.Dark(b, a)) << i
+		}
+		if topRight != want[version-7] || bottomLeft != want[version-7] {
+			t.Errorf("version %d: version information %05X and %05X, want %05X", version, topRight, bottomLeft, want[version-7])
+		}
+	}
+}
+
+func TestBestMaskHasLowestPenalty(t *testing.T) {
+	for _, text := range []string{"HELLO WORLD", goldenSample, strings.Repeat(goldenSample, 10)} {
+		code, err := Encode(text, Medium)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		chosen := code.penaltyScore()
+
+		segments := MakeSegments([]byte(text), code.Version)
+		for mask := 0; mask < 8; mask++ {
+			other := buildMasked(t, segments, Medium, code.Version, mask)
+			if penalty := other.penaltyScore(); penalty < chosen {
+				t.Errorf("%.20q: mask %d has penalty %d, below chosen mask %d with %d", text, mask, penalty, code.Mask, chosen)
+			}
+		}
+	}
+}
+
+// decode reads a rendered symbol back with the ZXing decoder
+func decode(t *testing.T, img image.Image) string {
+	t.Helper()
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		t.Fatalf("NewBinaryBitmapFromImage: %v", err)
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_PURE_BARCODE: true}
+	result, err := zxingqr.NewQRCodeReader().Decode(bitmap, hints)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return result.GetText()
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"wifi", "WIFI:T:WPA;S:Guest Lobby;P:correct horse battery staple;H:;;"},
+		{"utf-8 with ECI", "WIFI:T:WPA;S:Café ☕ Гостевая;P:пароль;H:true;;"},
+		{"numeric", "0123456789012345678901234567890123456789"},
+		{"mixed modes", "WIFI:S:0123456789;P:ABCDEFGHIJKLMNOP;T:WPA;;"},
+		{"large", strings.Repeat(goldenSample, 15)},
+	}
+
+	for _, tt := range tests {
+		for level := Low; level <= High; level++ {
+			code, err := Encode(tt.text, level)
+			if err != nil {
+				t.Fatalf("%s level %d: Encode: %v", tt.name, level, err)
+			}
+			if got := decode(t, code.Image(0)); got != tt.text {
+				t.Errorf("%s level %d version %d: decoded %q", tt.name, level, code.Version, got)
+			}
+		}
+	}
+}
+
+// fillVersion repeats unit up to the most whole characters that fit in version at level
+func fillVersion(unit string, version int, level Level) string {
+	runes := []rune(strings.Repeat(unit, 8000/len(unit)+1))
+	fits := func(n int) bool {
+		text := string(runes[:n])
+		var segments []Segment
+		if needsUTF8ECI(text) {
+			eci, _ := MakeECI(ECIUTF8)
+			segments = append(segments, eci)
+		}
+		used := segmentsBitLength(append(segments, MakeSegments([]byte(text), version)...), version)
+		return used >= 0 && used <= numDataCodewords(version, level)*8
+	}
+
+	low, high := 1, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if fits(mid) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return string(runes[:low])
+}
+
+func TestRoundTripEveryVersionLevelAndMode(t *testing.T) {
+	payloads := []struct {
+		name string
+		unit string
+	}{
+		{"numeric", "0123456789"},
+		{"alphanumeric", "HELLO WORLD $%*+-./:"},
+		{"byte", "guest;wifi?pass"},
+		{"mixed modes", "WIFI:S:0123456789012;P:ABCDEFGHIJKLMNOP;T:wpa;"},
+		{"utf-8 with ECI", "Café ☕ Šiauliai 0123456789012 ABCDEFGHIJ "},
+	}
+
+	for _, p := range payloads {
+		for version := minVersion; version <= maxVersion; version++ {
+			for level := Low; level <= High; level++ {
+				text := fillVersion(p.unit, version, level)
+				code, err := Encode(text, level)
+				if err != nil {
+					t.Fatalf("%s version %d level %d: Encode: %v", p.name, version, level, err)
+				}
+				if code.Version != version {
+					t.Errorf("%s version %d level %d: encoded %d characters at version %d", p.name, version, level, len(text), code.Version)
+				}
+				if got := decode(t, code.Image(0)); got != text {
+					t.Errorf("%s version %d level %d: decoded %q, want %q", p.name, version, level, got, text)
+				}
+			}
+		}
+	}
+}
+
+func TestEveryMaskDecodes(t *testing.T) {
+	segments := MakeSegments([]byte(goldenSample), 5)
+	for mask := 0; mask < 8; mask++ {
+		code := buildMasked(t, segments, Quartile, 5, mask)
+		if got := decode(t, code.Image(0)); got != goldenSample {
+			t.Errorf("mask %d: decoded %q", mask, got)
+		}
+	}
+}
+
+func TestImageScaling(t *testing.T) {
+	code, err := Encode("HELLO WORLD", Medium)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	// 21 modules plus the quiet zone is 29, so 256 pixels give 8 per module and 12 left over
+	const size = 256
+	img := code.Image(size)
+	if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+		t.Fatalf("image is %v, want %dx%d", b, size, size)
+	}
+	pixelsPerModule := size / (code.Size + quietZone*2)
+	offset := (size - (code.Size+quietZone*2)*pixelsPerModule) / 2
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			mx := (x-offset)/pixelsPerModule - quietZone
+			my := (y-offset)/pixelsPerModule - quietZone
+			want := x >= offset && y >= offset && mx >= 0 && my >= 0 && mx < code.Size && my < code.Size && code.Dark(mx, my)
+			if r, _, _, _ := img.At(x, y).RGBA(); (r == 0) != want {
+				t.Fatalf("pixel (%d, %d) dark = %v, want %v", x, y, r == 0, want)
+			}
+		}
+	}
+
+	if got := decode(t, img); got != "HELLO WORLD" {
+		t.Errorf("decoded %q", got)
+	}
+}
+
+func bitOf(dark bool) uint32 {
+	if dark {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,273 @@
+package qrcode
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// Mode defines the encoding mode of a QR code data segment
+type Mode int
+
+const (
+	ModeNumeric Mode = iota
+	ModeAlphanumeric
+	ModeByte
+	ModeECI
+)
+
+// ECIUTF8 is the ECI assignment number for UTF-8 encoded byte segments
+const ECIUTF8 = 26
+
+// alphanumericCharset lists the characters available in alphanumeric mode, in value order
+const alphanumericCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// modeIndicator returns the 4-bit mode indicator written before a segment
+func (m Mode) modeIndicator() uint32 {
+	switch m {
+	case ModeNumeric:
+		return 0x1
+	case ModeAlphanumeric:
+		return 0x2
+	case ModeByte:
+		return 0x4
+	case ModeECI:
+		return 0x7
+	default:
+		return 0
+	}
+}
+
+// charCountBits returns the width of the character count field for a version
+func (m Mode) charCountBits(version int) int {
+	band := 0
+	switch {
+	case version >= 27:
+		band = 2
+	case version >= 10:
+		band = 1
+	}
+
+	switch m {
+	case ModeNumeric:
+		return [3]int{10, 12, 14}[band]
+	case ModeAlphanumeric:
+		return [3]int{9, 11, 13}[band]
+	case ModeByte:
+		return [3]int{8, 16, 16}[band]
+	default:
+		return 0
+	}
+}
+
+// Segment is a run of data encoded in a single mode
+type Segment struct {
+	Mode     Mode
+	NumChars int
+	data     bitBuffer
+}
+
+// bitBuffer is an append-only sequence of bits
+type bitBuffer []bool
+
+// appendBits appends the low length bits of value, most significant first
+func (b *bitBuffer) appendBits(value uint32, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>uint(i))&1 != 0)
+	}
+}
+
+// MakeNumeric encodes a string of decimal digits in numeric mode
+func MakeNumeric(digits []byte) Segment {
+	var buf bitBuffer
+	for i := 0; i < len(digits); i += 3 {
+		n := len(digits) - i
+		if n > 3 {
+			n = 3
+		}
+		var value uint32
+		for _, d := range digits[i : i+n] {
+			value = value*10 + uint32(d-'0')
+		}
+		buf.appendBits(value, n*3+1)
+	}
+	return Segment{Mode: ModeNumeric, NumChars: len(digits), data: buf}
+}
+
+// MakeAlphanumeric encodes characters from the QR alphanumeric charset
+func MakeAlphanumeric(text []byte) Segment {
+	var buf bitBuffer
+	i := 0
+	for ; i+1 < len(text); i += 2 {
+		value := alphanumericValue(text[i])*45 + alphanumericValue(text[i+1])
+		buf.appendBits(value, 11)
+	}
+	if i < len(text) {
+		buf.appendBits(alphanumericValue(text[i]), 6)
+	}
+	return Segment{Mode: ModeAlphanumeric, NumChars: len(text), data: buf}
+}
+
+// MakeBytes encodes arbitrary bytes in byte mode
+func MakeBytes(data []byte) Segment {
+	var buf bitBuffer
+	for _, b := range data {
+		buf.appendBits(uint32(b), 8)
+	}
+	return Segment{Mode: ModeByte, NumChars: len(data), data: buf}
+}
+
+// MakeECI creates an Extended Channel Interpretation designator segment
+func MakeECI(assignment int) (Segment, error) {
+	var buf bitBuffer
+	switch {
+	case assignment < 0:
+		return Segment{}, errors.New("ECI assignment value out of range")
+	case assignment < 1<<7:
+		buf.appendBits(uint32(assignment), 8)
+	case assignment < 1<<14:
+		buf.appendBits(0x2, 2)
+		buf.appendBits(uint32(assignment), 14)
+	case assignment < 1000000:
+		buf.appendBits(0x6, 3)
+		buf.appendBits(uint32(assignment), 21)
+	default:
+		return Segment{}, errors.New("ECI assignment value out of range")
+	}
+	return Segment{Mode: ModeECI, NumChars: 0, data: buf}, nil
+}
+
+// MakeSegments splits data into the mode segments that give the shortest
+// bit stream for the given version, switching between numeric, alphanumeric
+// and byte mode wherever the saved bits outweigh the extra segment header.
+func MakeSegments(data []byte, version int) []Segment {
+	if len(data) == 0 {
+		return nil
+	}
+
+	modes := optimalModes(data, version)
+
+	var segments []Segment
+	start := 0
+	for i := 1; i <= len(data); i++ {
+		if i < len(data) && modes[i] == modes[start] {
+			continue
+		}
+		run := data[start:i]
+		switch modes[start] {
+		case ModeNumeric:
+			segments = append(segments, MakeNumeric(run))
+		case ModeAlphanumeric:
+			segments = append(segments, MakeAlphanumeric(run))
+		default:
+			segments = append(segments, MakeBytes(run))
+		}
+		start = i
+	}
+	return segments
+}
+
+// optimalModes picks the mode for every byte of data using dynamic programming.
+// Costs are kept in sixths of a bit so numeric (10/3 bits) and alphanumeric
+// (11/2 bits) characters can be compared exactly.
+func optimalModes(data []byte, version int) []Mode {
+	const infinity = int(^uint(0) >> 2)
+	candidates := [3]Mode{ModeNumeric, ModeAlphanumeric, ModeByte}
+
+	var headCosts [3]int
+	for i, m := range candidates {
+		headCosts[i] = (4 + m.charCountBits(version)) * 6
+	}
+
+	// chosen[i][j] is the mode byte i is encoded in when the state after byte i is mode j
+	chosen := make([][3]Mode, len(data))
+	valid := make([][3]bool, len(data))
+	prevCosts := headCosts
+
+	for i, c := range data {
+		curCosts := [3]int{infinity, infinity, infinity}
+
+		curCosts[2] = prevCosts[2] + 48
+		chosen[i][2], valid[i][2] = ModeByte, true
+
+		if alphanumericIndex(c) >= 0 {
+			curCosts[1] = prevCosts[1] + 33
+			chosen[i][1], valid[i][1] = ModeAlphanumeric, true
+		}
+		if c >= '0' && c <= '9' {
+			curCosts[0] = prevCosts[0] + 20
+			chosen[i][0], valid[i][0] = ModeNumeric, true
+		}
+
+		// Consider ending the current segment here and starting a new one
+		for j := range candidates {
+			for k := range candidates {
+				if !valid[i][k] {
+					continue
+				}
+				newCost := (curCosts[k]+5)/6*6 + headCosts[j]
+				if newCost < curCosts[j] {
+					curCosts[j] = newCost
+					chosen[i][j], valid[i][j] = candidates[k], true
+				}
+			}
+		}
+
+		prevCosts = curCosts
+	}
+
+	// Backtrack from the cheapest final state
+	state := 0
+	for j := range candidates {
+		if prevCosts[j] < prevCosts[state] {
+			state = j
+		}
+	}
+
+	result := make([]Mode, len(data))
+	for i := len(data) - 1; i >= 0; i-- {
+		mode := chosen[i][state]
+		result[i] = mode
+		state = int(mode)
+	}
+	return result
+}
+
+// segmentsBitLength returns the total encoded length of segments for a version,
+// or -1 if a segment's character count does not fit its count field
+func segmentsBitLength(segments []Segment, version int) int {
+	total := 0
+	for _, seg := range segments {
+		ccBits := seg.Mode.charCountBits(version)
+		if seg.NumChars >= 1<<uint(ccBits) && seg.Mode != ModeECI {
+			return -1
+		}
+		total += 4 + ccBits + len(seg.data)
+	}
+	return total
+}
+
+// needsUTF8ECI reports whether text contains multi-byte UTF-8 sequences.
+// Scanners assume ISO-8859-1 for byte mode unless an ECI designator says otherwise.
+func needsUTF8ECI(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+// alphanumericIndex returns the value of c in alphanumeric mode, or -1
+func alphanumericIndex(c byte) int {
+	for i := 0; i < len(alphanumericCharset); i++ {
+		if alphanumericCharset[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// alphanumericValue returns the value of a character known to be alphanumeric
+func alphanumericValue(c byte) uint32 {
+	return uint32(alphanumericIndex(c))
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository handles database operations for audit logs
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Create stores a new audit log entry
+func (r *AuditLogRepository) Create(entry *models.AuditLog) error {
+	if err := r.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// GetRecent retrieves the most recent audit log entries (admin functionality)
+func (r *AuditLogRepository) GetRecent(limit int) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	err := r.db.Preload("User").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+	return entries, nil
+}
+
+// CountByUserAndActionSince returns how often a user performed an action since the given time
+func (r *AuditLogRepository) CountByUserAndActionSince(userID uuid.UUID, action models.AuditAction, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AuditLog{}).
+		Where("user_id = ? AND action = ? AND created_at > ?", userID, action, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	return count, nil
+}
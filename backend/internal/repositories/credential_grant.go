@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CredentialGrantRepository handles database operations for credential grants
+type CredentialGrantRepository struct {
+	db *gorm.DB
+}
+
+// NewCredentialGrantRepository creates a new credential grant repository
+func NewCredentialGrantRepository(db *gorm.DB) *CredentialGrantRepository {
+	return &CredentialGrantRepository{db: db}
+}
+
+// Upsert creates a grant, or changes the permission of the existing grant for the same user
+func (r *CredentialGrantRepository) Upsert(grant *models.CredentialGrant) error {
+	err := r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "credential_id"}, {Name: "grantee_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "granted_by", "updated_at"}),
+	}).Create(grant).Error
+	if err != nil {
+		return fmt.Errorf("failed to save credential grant: %w", err)
+	}
+	return nil
+}
+
+// FindByCredentialAndGrantee finds the grant a user holds on a credential
+func (r *CredentialGrantRepository) FindByCredentialAndGrantee(credentialID, granteeID uuid.UUID) (*models.CredentialGrant, error) {
+	var grant models.CredentialGrant
+	err := r.db.First(&grant, "credential_id = ? AND grantee_id = ?", credentialID, granteeID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find credential grant: %w", err)
+	}
+	return &grant, nil
+}
+
+// FindByCredentialID retrieves all grants on a credential with their grantees, oldest first
+func (r *CredentialGrantRepository) FindByCredentialID(credentialID uuid.UUID) ([]models.CredentialGrant, error) {
+	var grants []models.CredentialGrant
+	err := r.db.Preload("Grantee").
+		Where("credential_id = ?", credentialID).
+		Order("created_at ASC").
+		Find(&grants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find credential grants by credential ID: %w", err)
+	}
+	return grants, nil
+}
+
+// FindByGranteeAndCredentialIDs retrieves a user's grants on the given credentials
+func (r *CredentialGrantRepository) FindByGranteeAndCredentialIDs(granteeID uuid.UUID, credentialIDs []uuid.UUID) ([]models.CredentialGrant, error) {
+	var grants []models.CredentialGrant
+	if len(credentialIDs) == 0 {
+		return grants, nil
+	}
+	err := r.db.Where("grantee_id = ? AND credential_id IN ?", granteeID, credentialIDs).
+		Find(&grants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find credential grants by grantee: %w", err)
+	}
+	return grants, nil
+}
+
+// Delete removes a user's grant on a credential
+func (r *CredentialGrantRepository) Delete(credentialID, granteeID uuid.UUID) error {
+	result := r.db.Where("credential_id = ? AND grantee_id = ?", credentialID, granteeID).
+		Delete(&models.CredentialGrant{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete credential grant: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyKeyRepository handles database operations for idempotency keys
+type IdempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyKeyRepository creates a new idempotency key repository
+func NewIdempotencyKeyRepository(db *gorm.DB) *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{db: db}
+}
+
+// Reserve stores a new idempotency key and reports whether it was stored. It returns
+// false without an error when the user already has a record for the key.
+func (r *IdempotencyKeyRepository) Reserve(key *models.IdempotencyKey) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindByUserAndKey finds a user's record for an idempotency key
+func (r *IdempotencyKeyRepository) FindByUserAndKey(userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.db.First(&record, "user_id = ? AND key = ?", userID, key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+	return &record, nil
+}
+
+// Complete stores the response of the request an idempotency key was reserved for
+func (r *IdempotencyKeyRepository) Complete(id uuid.UUID, statusCode int, contentType, encryptedBody string) error {
+	result := r.db.Model(&models.IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status_code":             statusCode,
+			"content_type":            contentType,
+			"encrypted_response_body": encryptedBody,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete removes an idempotency key record
+func (r *IdempotencyKeyRepository) Delete(id uuid.UUID) error {
+	if err := r.db.Delete(&models.IdempotencyKey{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the idempotency keys that expired before now
+func (r *IdempotencyKeyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvitationRepository handles database operations for organization invitations
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *InvitationRepository) WithTx(tx *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: tx}
+}
+
+// Create creates a new invitation
+func (r *InvitationRepository) Create(invitation *models.Invitation) error {
+	if err := r.db.Omit("Organization").Create(invitation).Error; err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+// FindByID finds an invitation by ID
+func (r *InvitationRepository) FindByID(id uuid.UUID) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.First(&invitation, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find invitation by ID: %w", err)
+	}
+	return &invitation, nil
+}
+
+// FindByTokenHash finds an invitation by the hash of its token
+func (r *InvitationRepository) FindByTokenHash(tokenHash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.First(&invitation, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find invitation by token: %w", err)
+	}
+	return &invitation, nil
+}
+
+// FindPendingByOrganizationID retrieves the invitations of an organization that were
+// neither accepted nor expired at now, newest first
+func (r *InvitationRepository) FindPendingByOrganizationID(organizationID uuid.UUID, now time.Time) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.db.Where("organization_id = ? AND accepted_at IS NULL AND expires_at > ?", organizationID, now).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find invitations by organization ID: %w", err)
+	}
+	return invitations, nil
+}
+
+// MarkAccepted records that an invitation was accepted. It reports false if the
+// invitation was already accepted, so a token can't be redeemed twice.
+func (r *InvitationRepository) MarkAccepted(id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL", id).
+		UpdateColumn("accepted_at", now)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to accept invitation: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete deletes an invitation
+func (r *InvitationRepository) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&models.Invitation{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete invitation: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
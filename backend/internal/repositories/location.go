@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// locationSubtreeSQL selects the IDs of a location and all of its descendants
+const locationSubtreeSQL = `WITH RECURSIVE subtree AS (
+	SELECT id FROM locations WHERE id = ?
+	UNION ALL
+	SELECT l.id FROM locations l INNER JOIN subtree s ON l.parent_id = s.id
+) SELECT id FROM subtree`
+
+// LocationRepository handles database operations for locations
+type LocationRepository struct {
+	db *gorm.DB
+}
+
+// NewLocationRepository creates a new location repository
+func NewLocationRepository(db *gorm.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+// Create creates a new location
+func (r *LocationRepository) Create(location *models.Location) error {
+	if err := r.db.Create(location).Error; err != nil {
+		return fmt.Errorf("failed to create location: %w", err)
+	}
+	return nil
+}
+
+// FindByID finds a location by ID
+func (r *LocationRepository) FindByID(id uuid.UUID) (*models.Location, error) {
+	var location models.Location
+	err := r.db.First(&location, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find location by ID: %w", err)
+	}
+	return &location, nil
+}
+
+// FindByScope retrieves all locations of a user or organization, ordered by name
+func (r *LocationRepository) FindByScope(scope Scope) ([]models.Location, error) {
+	var locations []models.Location
+	err := scope.apply(r.db).
+		Order("name ASC").
+		Find(&locations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find locations by scope: %w", err)
+	}
+	return locations, nil
+}
+
+// Update updates a location
+func (r *LocationRepository) Update(location *models.Location) error {
+	if err := r.db.Save(location).Error; err != nil {
+		return fmt.Errorf("failed to update location: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a location. Credentials in it are unassigned by the ON DELETE SET NULL foreign key.
+func (r *LocationRepository) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&models.Location{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete location: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// HasChildren reports whether any location is nested directly inside id
+func (r *LocationRepository) HasChildren(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Location{}).Where("parent_id = ?", id).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to count child locations: %w", err)
+	}
+	return count > 0, nil
+}
+
+// IsInSubtree reports whether id is root or one of its descendants
+func (r *LocationRepository) IsInSubtree(root, id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Raw("SELECT COUNT(*) FROM ("+locationSubtreeSQL+") t WHERE t.id = ?", root, id).
+		Scan(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check location hierarchy: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MembershipRepository handles database operations for organization memberships
+type MembershipRepository struct {
+	db *gorm.DB
+}
+
+// NewMembershipRepository creates a new membership repository
+func NewMembershipRepository(db *gorm.DB) *MembershipRepository {
+	return &MembershipRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *MembershipRepository) WithTx(tx *gorm.DB) *MembershipRepository {
+	return &MembershipRepository{db: tx}
+}
+
+// Create creates a new membership
+func (r *MembershipRepository) Create(membership *models.Membership) error {
+	if err := r.db.Omit("Organization", "User").Create(membership).Error; err != nil {
+		return fmt.Errorf("failed to create membership: %w", err)
+	}
+	return nil
+}
+
+// FindByOrganizationAndUser finds a user's membership of an organization
+func (r *MembershipRepository) FindByOrganizationAndUser(organizationID, userID uuid.UUID) (*models.Membership, error) {
+	var membership models.Membership
+	err := r.db.First(&membership, "organization_id = ? AND user_id = ?", organizationID, userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find membership: %w", err)
+	}
+	return &membership, nil
+}
+
+// FindByOrganizationID retrieves all members of an organization with their users, oldest first
+func (r *MembershipRepository) FindByOrganizationID(organizationID uuid.UUID) ([]models.Membership, error) {
+	var memberships []models.Membership
+	err := r.db.Preload("User").
+		Where("organization_id = ?", organizationID).
+		Order("created_at ASC").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find memberships by organization ID: %w", err)
+	}
+	return memberships, nil
+}
+
+// FindByUserID retrieves all memberships of a user with their organizations, ordered by organization name
+func (r *MembershipRepository) FindByUserID(userID uuid.UUID) ([]models.Membership, error) {
+	var memberships []models.Membership
+	err := r.db.Joins("Organization").
+		Where("organization_members.user_id = ?", userID).
+		Order("\"Organization\".name ASC").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find memberships by user ID: %w", err)
+	}
+	return memberships, nil
+}
+
+// UpdateRole changes a member's role
+func (r *MembershipRepository) UpdateRole(organizationID, userID uuid.UUID, role models.OrgRole) error {
+	result := r.db.Model(&models.Membership{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update membership role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete removes a user from an organization
+func (r *MembershipRepository) Delete(organizationID, userID uuid.UUID) error {
+	result := r.db.Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Delete(&models.Membership{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete membership: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CountByRole returns the number of members of an organization with the given role.
+// The rows are locked so concurrent demotions can't remove the last owner.
+func (r *MembershipRepository) CountByRole(organizationID uuid.UUID, role models.OrgRole) (int64, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.Membership{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND role = ?", organizationID, role).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count members by role: %w", err)
+	}
+	return int64(len(ids)), nil
+}
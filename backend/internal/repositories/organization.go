@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrganizationRepository handles database operations for organizations
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *OrganizationRepository) WithTx(tx *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: tx}
+}
+
+// Create creates a new organization
+func (r *OrganizationRepository) Create(organization *models.Organization) error {
+	if err := r.db.Create(organization).Error; err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+	return nil
+}
+
+// FindByID finds an organization by ID
+func (r *OrganizationRepository) FindByID(id uuid.UUID) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.First(&organization, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find organization by ID: %w", err)
+	}
+	return &organization, nil
+}
+
+// FindByIDForUpdate finds an organization by ID and locks the row until the transaction ends
+func (r *OrganizationRepository) FindByIDForUpdate(id uuid.UUID) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&organization, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find organization by ID: %w", err)
+	}
+	return &organization, nil
+}
+
+// UpdatePlan changes the plan of an organization
+func (r *OrganizationRepository) UpdatePlan(id uuid.UUID, plan models.Plan) error {
+	result := r.db.Model(&models.Organization{}).Where("id = ?", id).UpdateColumn("plan", plan)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update organization plan: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Update updates an organization
+func (r *OrganizationRepository) Update(organization *models.Organization) error {
+	if err := r.db.Save(organization).Error; err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes an organization. Memberships, invitations, tags and locations are
+// removed by ON DELETE CASCADE foreign keys; credentials must be gone already.
+func (r *OrganizationRepository) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&models.Organization{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete organization: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
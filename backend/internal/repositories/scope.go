@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scope selects the records of one workspace: an organization when OrganizationID
+// is set, otherwise the personal records of UserID. Personal queries never see
+// organization records, even ones the user created.
+type Scope struct {
+	UserID         uuid.UUID
+	OrganizationID *uuid.UUID
+}
+
+// ScopeOf returns the scope of a record created by userID in organizationID (nil for personal records)
+func ScopeOf(userID uuid.UUID, organizationID *uuid.UUID) Scope {
+	return Scope{UserID: userID, OrganizationID: organizationID}
+}
+
+// IsOrganization reports whether the scope is an organization rather than a personal workspace
+func (s Scope) IsOrganization() bool {
+	return s.OrganizationID != nil
+}
+
+// Contains reports whether a record with the given creator and organization lies in the scope
+func (s Scope) Contains(userID uuid.UUID, organizationID *uuid.UUID) bool {
+	if s.OrganizationID != nil {
+		return organizationID != nil && *organizationID == *s.OrganizationID
+	}
+	return organizationID == nil && userID == s.UserID
+}
+
+// apply restricts a query on a table with user_id and organization_id columns to the scope
+func (s Scope) apply(db *gorm.DB) *gorm.DB {
+	if s.OrganizationID != nil {
+		return db.Where("organization_id = ?", *s.OrganizationID)
+	}
+	return db.Where("user_id = ? AND organization_id IS NULL", s.UserID)
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareLinkRepository handles database operations for share links
+type ShareLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewShareLinkRepository creates a new share link repository
+func NewShareLinkRepository(db *gorm.DB) *ShareLinkRepository {
+	return &ShareLinkRepository{db: db}
+}
+
+// Create creates a new share link
+func (r *ShareLinkRepository) Create(link *models.ShareLink) error {
+	if err := r.db.Create(link).Error; err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+	return nil
+}
+
+// FindByID finds a share link by ID
+func (r *ShareLinkRepository) FindByID(id uuid.UUID) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := r.db.First(&link, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find share link by ID: %w", err)
+	}
+	return &link, nil
+}
+
+// FindByTokenHash finds a share link by the hash of its token
+func (r *ShareLinkRepository) FindByTokenHash(tokenHash string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := r.db.First(&link, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find share link by token: %w", err)
+	}
+	return &link, nil
+}
+
+// FindByCredentialID retrieves all share links of a credential, newest first
+func (r *ShareLinkRepository) FindByCredentialID(credentialID uuid.UUID) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	err := r.db.Where("credential_id = ?", credentialID).
+		Order("created_at DESC").
+		Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find share links by credential ID: %w", err)
+	}
+	return links, nil
+}
+
+// CountActiveByUserID returns the number of share links a user created that can still be opened
+func (r *ShareLinkRepository) CountActiveByUserID(userID uuid.UUID, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ShareLink{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("max_views IS NULL OR view_count < max_views").
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active share links by user ID: %w", err)
+	}
+	return count, nil
+}
+
+// Revoke marks a share link as revoked. Revoking an already revoked link keeps the original time.
+func (r *ShareLinkRepository) Revoke(id uuid.UUID, now time.Time) error {
+	err := r.db.Model(&models.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		UpdateColumn("revoked_at", now).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return nil
+}
+
+// RecordView counts a view of a share link, but only if the link is still active. The check and
+// the increment happen in one statement so concurrent views can't exceed the view limit.
+// It reports whether the view was counted.
+func (r *ShareLinkRepository) RecordView(id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("max_views IS NULL OR view_count < max_views").
+		UpdateColumns(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record share link view: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordFailedPin counts a wrong PIN and revokes the link once maxAttempts is reached
+func (r *ShareLinkRepository) RecordFailedPin(id uuid.UUID, maxAttempts int, now time.Time) error {
+	err := r.db.Model(&models.ShareLink{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"failed_pin_attempts": gorm.Expr("failed_pin_attempts + 1"),
+			"revoked_at":          gorm.Expr("CASE WHEN failed_pin_attempts + 1 >= ? THEN COALESCE(revoked_at, ?) ELSE revoked_at END", maxAttempts, now),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record failed share link PIN: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// credentialTag is a row of the wifi_credential_tags join table
+type credentialTag struct {
+	CredentialID uuid.UUID
+	TagID        uuid.UUID
+}
+
+// TableName specifies the join table name
+func (credentialTag) TableName() string {
+	return "wifi_credential_tags"
+}
+
+// TagRepository handles database operations for tags
+type TagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *TagRepository) WithTx(tx *gorm.DB) *TagRepository {
+	return &TagRepository{db: tx}
+}
+
+// Create creates a new tag
+func (r *TagRepository) Create(tag *models.Tag) error {
+	if err := r.db.Create(tag).Error; err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+	return nil
+}
+
+// FindByID finds a tag by ID
+func (r *TagRepository) FindByID(id uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.First(&tag, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find tag by ID: %w", err)
+	}
+	return &tag, nil
+}
+
+// FindByName finds a tag in the scope by name, ignoring case
+func (r *TagRepository) FindByName(scope Scope, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := scope.apply(r.db).Where("LOWER(name) = LOWER(?)", name).First(&tag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find tag by name: %w", err)
+	}
+	return &tag, nil
+}
+
+// FindByScope retrieves all tags of a user or organization, ordered by name
+func (r *TagRepository) FindByScope(scope Scope) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := scope.apply(r.db).
+		Order("name ASC").
+		Find(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tags by scope: %w", err)
+	}
+	return tags, nil
+}
+
+// FindByIDs retrieves the tags with the given IDs that lie in the scope
+func (r *TagRepository) FindByIDs(scope Scope, ids []uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	err := scope.apply(r.db).Where("id IN ?", ids).
+		Order("name ASC").
+		Find(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tags by IDs: %w", err)
+	}
+	return tags, nil
+}
+
+// Update updates a tag
+func (r *TagRepository) Update(tag *models.Tag) error {
+	if err := r.db.Save(tag).Error; err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a tag. Its assignments are removed by the ON DELETE CASCADE foreign key.
+func (r *TagRepository) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&models.Tag{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete tag: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Assign adds every tag to every credential, skipping assignments that already exist
+func (r *TagRepository) Assign(credentialIDs, tagIDs []uuid.UUID) error {
+	rows := make([]credentialTag, 0, len(credentialIDs)*len(tagIDs))
+	for _, credentialID := range credentialIDs {
+		for _, tagID := range tagIDs {
+			rows = append(rows, credentialTag{CredentialID: credentialID, TagID: tagID})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to assign tags: %w", err)
+	}
+	return nil
+}
+
+// Unassign removes every tag from every credential
+func (r *TagRepository) Unassign(credentialIDs, tagIDs []uuid.UUID) error {
+	if len(credentialIDs) == 0 || len(tagIDs) == 0 {
+		return nil
+	}
+
+	err := r.db.Where("credential_id IN ? AND tag_id IN ?", credentialIDs, tagIDs).
+		Delete(&credentialTag{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to unassign tags: %w", err)
+	}
+	return nil
+}
+
+// ReplaceForCredential sets the tags of a credential to exactly tagIDs
+func (r *TagRepository) ReplaceForCredential(credentialID uuid.UUID, tagIDs []uuid.UUID) error {
+	if err := r.db.Where("credential_id = ?", credentialID).Delete(&credentialTag{}).Error; err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+	return r.Assign([]uuid.UUID{credentialID}, tagIDs)
+}
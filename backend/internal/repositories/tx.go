@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+)
+
+// Transactor runs work across several repositories in one database transaction.
+// Repositories join the transaction through their WithTx method.
+type Transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor creates a new transactor
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTransaction calls fn inside a transaction, committing if fn returns nil and rolling back otherwise
+func (t *Transactor) WithinTransaction(fn func(tx *gorm.DB) error) error {
+	return t.db.Transaction(fn)
+}
@@ -3,11 +3,13 @@ package repositories
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"gin-quickstart/internal/models"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository handles database operations for users
@@ -20,6 +22,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 // Create creates a new user
 func (r *UserRepository) Create(user *models.User) error {
 	if err := r.db.Create(user).Error; err != nil {
@@ -54,6 +61,19 @@ func (r *UserRepository) FindByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// FindByIDForUpdate finds a user by ID and locks the row until the transaction ends
+func (r *UserRepository) FindByIDForUpdate(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find user by ID: %w", err)
+	}
+	return &user, nil
+}
+
 // GetAll retrieves all users (admin functionality)
 func (r *UserRepository) GetAll() ([]models.User, error) {
 	var users []models.User
@@ -72,11 +92,11 @@ func (r *UserRepository) Update(user *models.User) error {
 	return nil
 }
 
-// Delete deletes a user
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	result := r.db.Delete(&models.User{}, "id = ?", id)
+// UpdatePlan changes the plan of a user
+func (r *UserRepository) UpdatePlan(id uuid.UUID, plan models.Plan) error {
+	result := r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("plan", plan)
 	if result.Error != nil {
-		return fmt.Errorf("failed to delete user: %w", result.Error)
+		return fmt.Errorf("failed to update user plan: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
@@ -84,10 +104,82 @@ func (r *UserRepository) Delete(id uuid.UUID) error {
 	return nil
 }
 
-// EmailExists checks if an email already exists
+// Delete moves a user and their WiFi credentials to the trash (soft delete).
+// The credentials share the user's deletion time so RestoreUser can bring back
+// exactly those, leaving credentials the user had trashed earlier in the trash.
+func (r *UserRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		deletedAt := time.Now()
+
+		result := tx.Model(&models.User{}).Where("id = ?", id).Update("deleted_at", deletedAt)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err := tx.Model(&models.WifiCredential{}).Where("user_id = ?", id).Update("deleted_at", deletedAt).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete WiFi credentials of user: %w", err)
+		}
+		return nil
+	})
+}
+
+// FindDeleted retrieves all users in the trash, most recently deleted first
+func (r *UserRepository) FindDeleted() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deleted users: %w", err)
+	}
+	return users, nil
+}
+
+// Restore takes a user out of the trash together with the credentials deleted along with them
+func (r *UserRepository) Restore(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.WifiCredential{}).
+			Where("user_id = ? AND deleted_at = (SELECT deleted_at FROM users WHERE id = ?)", id, id).
+			UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return fmt.Errorf("failed to restore WiFi credentials of user: %w", err)
+		}
+
+		result := tx.Unscoped().Model(&models.User{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			UpdateColumn("deleted_at", nil)
+		if result.Error != nil {
+			return fmt.Errorf("failed to restore user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// PurgeDeleted permanently removes users that were trashed before the cutoff.
+// Their WiFi credentials are removed by the ON DELETE CASCADE foreign key.
+func (r *UserRepository) PurgeDeleted(cutoff time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&models.User{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// EmailExists checks if an email already exists. Users in the trash still
+// hold their email so they can be restored.
 func (r *UserRepository) EmailExists(email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count).Error
 	if err != nil {
 		return false, fmt.Errorf("failed to check email existence: %w", err)
 	}
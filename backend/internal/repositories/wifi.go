@@ -3,11 +3,14 @@ package repositories
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"gin-quickstart/internal/models"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // WifiRepository handles database operations for WiFi credentials
@@ -20,9 +23,15 @@ func NewWifiRepository(db *gorm.DB) *WifiRepository {
 	return &WifiRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *WifiRepository) WithTx(tx *gorm.DB) *WifiRepository {
+	return &WifiRepository{db: tx}
+}
+
 // Create creates a new WiFi credential
 func (r *WifiRepository) Create(credential *models.WifiCredential) error {
-	if err := r.db.Create(credential).Error; err != nil {
+	// Tags are assigned through TagRepository; never upsert related rows from here
+	if err := r.db.Omit(clause.Associations).Create(credential).Error; err != nil {
 		return fmt.Errorf("failed to create WiFi credential: %w", err)
 	}
 	return nil
@@ -31,7 +40,7 @@ func (r *WifiRepository) Create(credential *models.WifiCredential) error {
 // FindByID finds a WiFi credential by ID
 func (r *WifiRepository) FindByID(id uuid.UUID) (*models.WifiCredential, error) {
 	var credential models.WifiCredential
-	err := r.db.First(&credential, "id = ?", id).Error
+	err := r.db.Preload("Tags").First(&credential, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -41,10 +50,22 @@ func (r *WifiRepository) FindByID(id uuid.UUID) (*models.WifiCredential, error)
 	return &credential, nil
 }
 
-// FindByUserID retrieves all WiFi credentials for a specific user
+// FindByIDs retrieves the WiFi credentials with the given IDs
+func (r *WifiRepository) FindByIDs(ids []uuid.UUID) ([]models.WifiCredential, error) {
+	var credentials []models.WifiCredential
+	if len(ids) == 0 {
+		return credentials, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&credentials).Error; err != nil {
+		return nil, fmt.Errorf("failed to find WiFi credentials by IDs: %w", err)
+	}
+	return credentials, nil
+}
+
+// FindByUserID retrieves the personal WiFi credentials of a user; organization credentials are excluded
 func (r *WifiRepository) FindByUserID(userID uuid.UUID) ([]models.WifiCredential, error) {
 	var credentials []models.WifiCredential
-	err := r.db.Where("user_id = ?", userID).
+	err := r.db.Where("user_id = ? AND organization_id IS NULL", userID).
 		Order("created_at DESC").
 		Find(&credentials).Error
 	if err != nil {
@@ -65,17 +86,153 @@ func (r *WifiRepository) GetAll() ([]models.WifiCredential, error) {
 	return credentials, nil
 }
 
-// Update updates a WiFi credential
+// WifiSortColumns maps the sort keys accepted by List to their columns
+var WifiSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"ssid":       "ssid",
+}
+
+// ListCursor marks the last row of a page: its sort column value and ID
+type ListCursor struct {
+	Value string    // For text sort columns
+	Time  time.Time // For timestamp sort columns
+	ID    uuid.UUID
+}
+
+// WifiListFilter selects and orders a page of WiFi credentials
+type WifiListFilter struct {
+	Scope        *Scope     // A user's personal or an organization's credentials; nil lists every credential
+	SharedWith   *uuid.UUID // Only credentials this user has been granted access to
+	SecurityType models.SecurityType
+	IsHidden     *bool
+	Search       string      // Fuzzy SSID match
+	TagIDs       []uuid.UUID // Credentials must carry every one of these tags
+	LocationID   *uuid.UUID  // Credentials in this location or any location nested inside it
+	SortBy       string      // Key of WifiSortColumns
+	Descending   bool
+	Limit        int
+	After        *ListCursor
+	PreloadUser  bool
+}
+
+// List returns one page of WiFi credentials using keyset pagination on (sort column, id),
+// along with the total number of rows matching the filter. It fetches one row more than
+// the limit so callers can tell whether another page follows.
+func (r *WifiRepository) List(filter WifiListFilter) ([]models.WifiCredential, int64, error) {
+	column, ok := WifiSortColumns[filter.SortBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sort column: %s", filter.SortBy)
+	}
+
+	query := r.db.Model(&models.WifiCredential{})
+	if filter.Scope != nil {
+		query = filter.Scope.apply(query)
+	}
+	if filter.SharedWith != nil {
+		query = query.Where("id IN (SELECT credential_id FROM credential_grants WHERE grantee_id = ?)", *filter.SharedWith)
+	}
+	if filter.SecurityType != "" {
+		query = query.Where("security_type = ?", filter.SecurityType)
+	}
+	if filter.IsHidden != nil {
+		query = query.Where("is_hidden = ?", *filter.IsHidden)
+	}
+	if filter.Search != "" {
+		// Both operators are served by the gin_trgm_ops index: % catches typos,
+		// ILIKE catches short substrings that fall below the similarity threshold
+		query = query.Where("(ssid % ? OR ssid ILIKE ?)", filter.Search, "%"+escapeLike(filter.Search)+"%")
+	}
+
+	if len(filter.TagIDs) > 0 {
+		query = query.Where(
+			"id IN (SELECT credential_id FROM wifi_credential_tags WHERE tag_id IN ? GROUP BY credential_id HAVING COUNT(DISTINCT tag_id) = ?)",
+			filter.TagIDs, len(filter.TagIDs),
+		)
+	}
+	if filter.LocationID != nil {
+		query = query.Where("location_id IN ("+locationSubtreeSQL+")", *filter.LocationID)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count WiFi credentials: %w", err)
+	}
+
+	if filter.After != nil {
+		var value interface{} = filter.After.Value
+		if column != "ssid" {
+			value = filter.After.Time
+		}
+		operator := ">"
+		if filter.Descending {
+			operator = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, operator), value, filter.After.ID)
+	}
+
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+	query = query.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(filter.Limit+1).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") })
+	if filter.PreloadUser {
+		query = query.Preload("User")
+	}
+
+	var credentials []models.WifiCredential
+	if err := query.Find(&credentials).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list WiFi credentials: %w", err)
+	}
+	return credentials, total, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Update saves a WiFi credential and increments its version, provided the stored version
+// is still the one the credential was loaded with. Otherwise someone else changed it in
+// the meantime and gorm.ErrRecordNotFound is returned.
 func (r *WifiRepository) Update(credential *models.WifiCredential) error {
-	if err := r.db.Save(credential).Error; err != nil {
-		return fmt.Errorf("failed to update WiFi credential: %w", err)
+	loaded := credential.Version
+	credential.Version++
+
+	result := r.db.Model(credential).
+		Where("version = ?", loaded).
+		Select("*").Omit(clause.Associations).
+		Updates(credential)
+	if result.Error != nil {
+		credential.Version = loaded
+		return fmt.Errorf("failed to update WiFi credential: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		credential.Version = loaded
+		return gorm.ErrRecordNotFound
 	}
 	return nil
 }
 
-// Delete deletes a WiFi credential
-func (r *WifiRepository) Delete(id uuid.UUID) error {
-	result := r.db.Delete(&models.WifiCredential{}, "id = ?", id)
+// FindDueForRotation retrieves up to limit credentials whose next rotation is at or before now,
+// most overdue first. Trashed credentials are skipped.
+func (r *WifiRepository) FindDueForRotation(now time.Time, limit int) ([]models.WifiCredential, error) {
+	var credentials []models.WifiCredential
+	err := r.db.Where("next_rotation_at IS NOT NULL AND next_rotation_at <= ?", now).
+		Order("next_rotation_at ASC").
+		Limit(limit).
+		Find(&credentials).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find WiFi credentials due for rotation: %w", err)
+	}
+	return credentials, nil
+}
+
+// Delete moves a WiFi credential to the trash (soft delete) if it is still at version
+func (r *WifiRepository) Delete(id uuid.UUID, version int) error {
+	result := r.db.Delete(&models.WifiCredential{}, "id = ? AND version = ?", id, version)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete WiFi credential: %w", result.Error)
 	}
@@ -85,6 +242,59 @@ func (r *WifiRepository) Delete(id uuid.UUID) error {
 	return nil
 }
 
+// FindDeletedByID finds a WiFi credential in the trash by ID
+func (r *WifiRepository) FindDeletedByID(id uuid.UUID) (*models.WifiCredential, error) {
+	var credential models.WifiCredential
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&credential, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find deleted WiFi credential by ID: %w", err)
+	}
+	return &credential, nil
+}
+
+// FindDeletedByScope retrieves the WiFi credentials in a user's or organization's trash, most recently deleted first
+func (r *WifiRepository) FindDeletedByScope(scope Scope) ([]models.WifiCredential, error) {
+	var credentials []models.WifiCredential
+	err := scope.apply(r.db.Unscoped()).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&credentials).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deleted WiFi credentials by scope: %w", err)
+	}
+	return credentials, nil
+}
+
+// Restore takes a WiFi credential out of the trash
+func (r *WifiRepository) Restore(id uuid.UUID) error {
+	result := r.db.Unscoped().Model(&models.WifiCredential{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("failed to restore WiFi credential: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes WiFi credentials that were trashed before the cutoff
+func (r *WifiRepository) PurgeDeleted(cutoff time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&models.WifiCredential{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge deleted WiFi credentials: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // DeleteByUserID deletes all WiFi credentials for a user
 func (r *WifiRepository) DeleteByUserID(userID uuid.UUID) error {
 	if err := r.db.Where("user_id = ?", userID).Delete(&models.WifiCredential{}).Error; err != nil {
@@ -103,14 +313,82 @@ func (r *WifiRepository) Count() (int64, error) {
 	return count, nil
 }
 
-// CountByUserID returns the number of WiFi credentials for a user
-func (r *WifiRepository) CountByUserID(userID uuid.UUID) (int64, error) {
+// CountByScope returns the number of WiFi credentials in a scope, excluding the trash
+func (r *WifiRepository) CountByScope(scope Scope) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.WifiCredential{}).
-		Where("user_id = ?", userID).
+	err := scope.apply(r.db.Model(&models.WifiCredential{})).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count WiFi credentials: %w", err)
+	}
+	return count, nil
+}
+
+// CountByOrganizationID returns the number of WiFi credentials of an organization, including those in the trash
+func (r *WifiRepository) CountByOrganizationID(organizationID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.WifiCredential{}).
+		Where("organization_id = ?", organizationID).
 		Count(&count).Error
 	if err != nil {
-		return 0, fmt.Errorf("failed to count WiFi credentials by user ID: %w", err)
+		return 0, fmt.Errorf("failed to count WiFi credentials by organization ID: %w", err)
 	}
 	return count, nil
 }
+
+// ReusedPassword identifies a password fingerprint shared by several SSIDs of one user
+type ReusedPassword struct {
+	UserID              uuid.UUID
+	PasswordFingerprint string
+	SSIDCount           int64
+}
+
+// FindReusedPasswords returns fingerprints used for more than one SSID.
+// Passwords are only compared within each user's own credentials; pass nil to check all users.
+// A user's check covers their personal credentials only.
+func (r *WifiRepository) FindReusedPasswords(userID *uuid.UUID) ([]ReusedPassword, error) {
+	var reused []ReusedPassword
+	query := r.db.Model(&models.WifiCredential{}).
+		Select("user_id, password_fingerprint, COUNT(DISTINCT ssid) AS ssid_count").
+		Where("password_fingerprint <> ''")
+	if userID != nil {
+		query = query.Where("user_id = ? AND organization_id IS NULL", *userID)
+	}
+	err := query.Group("user_id, password_fingerprint").
+		Having("COUNT(DISTINCT ssid) > 1").
+		Scan(&reused).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reused passwords: %w", err)
+	}
+	return reused, nil
+}
+
+// FindUncheckedPasswords returns up to limit credentials, including trashed ones, whose
+// password has no fingerprint or weakness flags yet, ordered by ID after afterID
+func (r *WifiRepository) FindUncheckedPasswords(afterID uuid.UUID, limit int) ([]models.WifiCredential, error) {
+	var credentials []models.WifiCredential
+	err := r.db.Unscoped().
+		Where("id > ?", afterID).
+		Where("password_short IS NULL OR password_dictionary IS NULL OR (COALESCE(password_fingerprint, '') = '' AND encrypted_password <> '')").
+		Order("id").
+		Limit(limit).
+		Find(&credentials).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unchecked passwords: %w", err)
+	}
+	return credentials, nil
+}
+
+// UpdatePasswordChecks sets the password fingerprint and weakness flags without touching other columns
+func (r *WifiRepository) UpdatePasswordChecks(credential *models.WifiCredential) error {
+	err := r.db.Unscoped().Model(&models.WifiCredential{}).
+		Where("id = ?", credential.ID).
+		UpdateColumns(map[string]interface{}{
+			"password_fingerprint": credential.PasswordFingerprint,
+			"password_short":       credential.PasswordShort,
+			"password_dictionary":  credential.PasswordDictionary,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update password checks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WifiVersionRepository handles database operations for WiFi credential versions
+type WifiVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewWifiVersionRepository creates a new WiFi credential version repository
+func NewWifiVersionRepository(db *gorm.DB) *WifiVersionRepository {
+	return &WifiVersionRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *WifiVersionRepository) WithTx(tx *gorm.DB) *WifiVersionRepository {
+	return &WifiVersionRepository{db: tx}
+}
+
+// Create stores a new version. Versions are never updated.
+func (r *WifiVersionRepository) Create(version *models.WifiCredentialVersion) error {
+	if err := r.db.Create(version).Error; err != nil {
+		return fmt.Errorf("failed to create WiFi credential version: %w", err)
+	}
+	return nil
+}
+
+// FindByCredentialID retrieves every version of a credential, oldest first
+func (r *WifiVersionRepository) FindByCredentialID(credentialID uuid.UUID) ([]models.WifiCredentialVersion, error) {
+	var versions []models.WifiCredentialVersion
+	err := r.db.Where("credential_id = ?", credentialID).
+		Order("version ASC").
+		Find(&versions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find WiFi credential versions: %w", err)
+	}
+	return versions, nil
+}
+
+// FindByVersion finds one version of a credential
+func (r *WifiVersionRepository) FindByVersion(credentialID uuid.UUID, version int) (*models.WifiCredentialVersion, error) {
+	var v models.WifiCredentialVersion
+	err := r.db.Where("credential_id = ? AND version = ?", credentialID, version).First(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find WiFi credential version: %w", err)
+	}
+	return &v, nil
+}
+
+// LatestVersion returns the highest version number of a credential, or 0 if it has no history
+func (r *WifiVersionRepository) LatestVersion(credentialID uuid.UUID) (int, error) {
+	var latest int
+	err := r.db.Model(&models.WifiCredentialVersion{}).
+		Where("credential_id = ?", credentialID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest WiFi credential version: %w", err)
+	}
+	return latest, nil
+}
@@ -1,9 +1,13 @@
 package routes
 
 import (
+	"log"
+	"time"
+
 	"gin-quickstart/internal/config"
 	"gin-quickstart/internal/handlers"
 	"gin-quickstart/internal/middleware"
+	"gin-quickstart/internal/models"
 	"gin-quickstart/internal/repositories"
 	"gin-quickstart/internal/services"
 
@@ -16,16 +20,89 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
 	wifiRepo := repositories.NewWifiRepository(db)
+	auditRepo := repositories.NewAuditLogRepository(db)
+	versionRepo := repositories.NewWifiVersionRepository(db)
+	tagRepo := repositories.NewTagRepository(db)
+	locationRepo := repositories.NewLocationRepository(db)
+	shareRepo := repositories.NewShareLinkRepository(db)
+	grantRepo := repositories.NewCredentialGrantRepository(db)
+	orgRepo := repositories.NewOrganizationRepository(db)
+	membershipRepo := repositories.NewMembershipRepository(db)
+	invitationRepo := repositories.NewInvitationRepository(db)
+	idempotencyKeyRepo := repositories.NewIdempotencyKeyRepository(db)
+	transactor := repositories.NewTransactor(db)
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
 	qrCodeService := services.NewQRCodeService()
-	wifiService := services.NewWifiService(wifiRepo, qrCodeService, cfg.EncryptionKey)
+	passwordGenerator := services.NewPasswordGenerator()
+	passwordChecker := services.NewPasswordChecker(passwordGenerator)
+	quotaService := services.NewQuotaService(userRepo, orgRepo, wifiRepo, shareRepo, auditRepo, map[models.Plan]services.PlanLimits{
+		models.PlanFree: {
+			MaxCredentials:   cfg.PlanFreeMaxCredentials,
+			MaxShareLinks:    cfg.PlanFreeMaxShareLinks,
+			MaxExportsPerDay: cfg.PlanFreeMaxExportsPerDay,
+		},
+		models.PlanPro: {
+			MaxCredentials:   cfg.PlanProMaxCredentials,
+			MaxShareLinks:    cfg.PlanProMaxShareLinks,
+			MaxExportsPerDay: cfg.PlanProMaxExportsPerDay,
+		},
+		models.PlanBusiness: {
+			MaxCredentials:   cfg.PlanBusinessMaxCredentials,
+			MaxShareLinks:    cfg.PlanBusinessMaxShareLinks,
+			MaxExportsPerDay: cfg.PlanBusinessMaxExportsPerDay,
+		},
+	})
+	credentialEvents := services.NewCredentialEvents()
+	wifiService := services.NewWifiService(wifiRepo, versionRepo, tagRepo, locationRepo, grantRepo, membershipRepo, transactor, quotaService, qrCodeService, passwordGenerator, passwordChecker, credentialEvents, cfg.EncryptionKey)
+	passService, err := services.NewPassService(qrCodeService, services.PassConfig{
+		TypeIdentifier:   cfg.PassTypeIdentifier,
+		TeamIdentifier:   cfg.PassTeamIdentifier,
+		OrganizationName: cfg.PassOrganizationName,
+		CertPath:         cfg.PassCertPath,
+		KeyPath:          cfg.PassKeyPath,
+		WWDRCertPath:     cfg.PassWWDRCertPath,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize Apple Wallet pass service: %v", err)
+	}
+	healthService := services.NewHealthService(wifiRepo, cfg.PasswordMaxAgeDays)
+	auditService := services.NewAuditService(auditRepo)
+	revealService := services.NewRevealService(wifiService, authService, auditService, cfg.ReauthWindowMinutes)
+	tagService := services.NewTagService(tagRepo, wifiRepo, membershipRepo, transactor)
+	locationService := services.NewLocationService(locationRepo, membershipRepo)
+	grantService := services.NewGrantService(grantRepo, userRepo, wifiService)
+	mailer := services.NewMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	orgService := services.NewOrganizationService(orgRepo, membershipRepo, invitationRepo, userRepo, wifiRepo, transactor, mailer, cfg.FrontendURL)
+	shareService := services.NewShareService(shareRepo, wifiRepo, wifiService, quotaService, credentialEvents, cfg.PublicURL)
+	purgeService := services.NewPurgeService(userRepo, wifiRepo, cfg.TrashRetentionDays)
+	rotationService := services.NewRotationService(wifiRepo, wifiService)
+	backupService := services.NewBackupService(wifiService, quotaService, revealService)
+	exportService := services.NewExportService(wifiService, quotaService, revealService)
+	idempotencyService := services.NewIdempotencyService(idempotencyKeyRepo, wifiService, cfg.IdempotencyKeyTTLHours)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	wifiHandler := handlers.NewWifiHandler(wifiService)
-	adminHandler := handlers.NewAdminHandler(userRepo, wifiRepo)
+	wifiHandler := handlers.NewWifiHandler(wifiService, revealService)
+	adminHandler := handlers.NewAdminHandler(userRepo, wifiRepo, auditRepo, wifiService)
+	passHandler := handlers.NewPassHandler(revealService, passService, quotaService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	revealHandler := handlers.NewRevealHandler(revealService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	locationHandler := handlers.NewLocationHandler(locationService)
+	shareHandler := handlers.NewShareHandler(shareService)
+	grantHandler := handlers.NewGrantHandler(grantService)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	quotaHandler := handlers.NewQuotaHandler(quotaService)
+	backupHandler := handlers.NewBackupHandler(backupService)
+	exportHandler := handlers.NewExportHandler(exportService)
+
+	// One limiter for the JSON and HTML share endpoints, which serve the same links
+	shareRateLimit := middleware.RateLimitByIP(cfg.ShareRateLimit, time.Minute)
+
+	// Lets clients retry requests that create credentials without creating duplicates
+	idempotency := middleware.Idempotency(idempotencyService)
 
 	// API route group
 	api := router.Group("/api")
@@ -42,9 +119,101 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		wifi.Use(middleware.AuthMiddleware(authService))
 		{
 			wifi.GET("", wifiHandler.GetAll)
-			wifi.POST("", wifiHandler.Create)
+			wifi.POST("", idempotency, wifiHandler.Create)
+			wifi.POST("/import", idempotency, wifiHandler.Import)
+			wifi.POST("/export", middleware.RateLimit(cfg.RevealRateLimit, time.Minute), exportHandler.Export)
+			wifi.POST("/derive-psk", wifiHandler.DerivePSK)
+			wifi.POST("/generate-password", wifiHandler.GeneratePassword)
+			wifi.GET("/health", healthHandler.GetUserReport)
+			wifi.GET("/trash", wifiHandler.GetTrash)
+			wifi.GET("/shared", wifiHandler.GetShared)
+			wifi.POST("/tags", tagHandler.BulkAssign)
+			wifi.POST("/bulk", middleware.RateLimit(cfg.RevealRateLimit, time.Minute), wifiHandler.Bulk)
 			wifi.GET("/:id", wifiHandler.GetByID)
+			wifi.PUT("/:id", wifiHandler.Replace)
+			wifi.PATCH("/:id", wifiHandler.Update)
 			wifi.DELETE("/:id", wifiHandler.Delete)
+			wifi.POST("/:id/pass", middleware.RateLimit(cfg.RevealRateLimit, time.Minute), passHandler.Download)
+			wifi.POST("/:id/restore", wifiHandler.Restore)
+			wifi.POST("/:id/clone", wifiHandler.Clone)
+			wifi.GET("/:id/versions", wifiHandler.GetVersions)
+			wifi.POST("/:id/versions/:version/restore", wifiHandler.RestoreVersion)
+			wifi.POST("/:id/reveal", middleware.RateLimit(cfg.RevealRateLimit, time.Minute), revealHandler.Reveal)
+			wifi.GET("/:id/shares", shareHandler.GetAll)
+			wifi.POST("/:id/shares", shareHandler.Create)
+			wifi.DELETE("/:id/shares/:shareId", shareHandler.Revoke)
+			wifi.GET("/:id/grants", grantHandler.GetAll)
+			wifi.POST("/:id/grants", grantHandler.Grant)
+			wifi.DELETE("/:id/grants/:userId", grantHandler.Revoke)
+		}
+
+		// Public share link routes (no login, rate-limited per IP)
+		share := api.Group("/public/share")
+		share.Use(shareRateLimit)
+		{
+			share.GET("/:token", shareHandler.GetInfo)
+			share.POST("/:token", shareHandler.View)
+		}
+
+		// Protected tag routes
+		tags := api.Group("/tags")
+		tags.Use(middleware.AuthMiddleware(authService))
+		{
+			tags.GET("", tagHandler.GetAll)
+			tags.POST("", tagHandler.Create)
+			tags.PUT("/:id", tagHandler.Update)
+			tags.DELETE("/:id", tagHandler.Delete)
+		}
+
+		// Protected location routes
+		locations := api.Group("/locations")
+		locations.Use(middleware.AuthMiddleware(authService))
+		{
+			locations.GET("", locationHandler.GetAll)
+			locations.POST("", locationHandler.Create)
+			locations.PUT("/:id", locationHandler.Update)
+			locations.DELETE("/:id", locationHandler.Delete)
+		}
+
+		// Protected organization routes
+		organizations := api.Group("/organizations")
+		organizations.Use(middleware.AuthMiddleware(authService))
+		{
+			organizations.GET("", orgHandler.GetAll)
+			organizations.POST("", orgHandler.Create)
+			organizations.GET("/:id", orgHandler.GetByID)
+			organizations.PUT("/:id", orgHandler.Update)
+			organizations.DELETE("/:id", orgHandler.Delete)
+			organizations.GET("/:id/members", orgHandler.GetMembers)
+			organizations.PUT("/:id/members/:userId", orgHandler.UpdateMember)
+			organizations.DELETE("/:id/members/:userId", orgHandler.RemoveMember)
+			organizations.GET("/:id/invitations", orgHandler.GetInvitations)
+			organizations.POST("/:id/invitations", orgHandler.Invite)
+			organizations.DELETE("/:id/invitations/:invitationId", orgHandler.RevokeInvitation)
+		}
+
+		// Invitations are accepted by the invitee, who isn't a member yet
+		invitations := api.Group("/invitations")
+		invitations.Use(middleware.AuthMiddleware(authService))
+		{
+			invitations.POST("/accept", orgHandler.AcceptInvitation)
+		}
+
+		// Protected backup routes. Deriving the backup key is deliberately expensive, so
+		// both endpoints are rate-limited per user.
+		backup := api.Group("/backup")
+		backup.Use(middleware.AuthMiddleware(authService))
+		backup.Use(middleware.RateLimit(5, time.Minute))
+		{
+			backup.POST("/export", backupHandler.Export)
+			backup.POST("/restore", idempotency, backupHandler.Restore)
+		}
+
+		// Protected quota routes
+		quota := api.Group("/quota")
+		quota.Use(middleware.AuthMiddleware(authService))
+		{
+			quota.GET("", quotaHandler.GetUsage)
 		}
 
 		// Admin routes
@@ -53,8 +222,29 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		admin.Use(middleware.AdminMiddleware())
 		{
 			admin.GET("/users", adminHandler.GetAllUsers)
+			admin.GET("/users/trash", adminHandler.GetDeletedUsers)
+			admin.DELETE("/users/:id", adminHandler.DeleteUser)
+			admin.POST("/users/:id/restore", adminHandler.RestoreUser)
+			admin.PUT("/users/:id/plan", quotaHandler.SetUserPlan)
+			admin.PUT("/organizations/:id/plan", quotaHandler.SetOrganizationPlan)
+			admin.GET("/plans", quotaHandler.GetPlans)
 			admin.GET("/credentials", adminHandler.GetAllCredentials)
 			admin.GET("/stats", adminHandler.GetStats)
+			admin.GET("/health", healthHandler.GetSystemReport)
+			admin.GET("/audit-logs", adminHandler.GetAuditLogs)
 		}
 	}
+
+	// Public share page for browsers
+	router.GET("/share/:token", shareRateLimit, shareHandler.Page)
+	router.POST("/share/:token", shareRateLimit, shareHandler.PageView)
+
+	// Public kiosk page and its live QR code stream, for lobby screens
+	router.GET("/kiosk/:token", shareRateLimit, shareHandler.Kiosk)
+
+	// Background jobs
+	go wifiService.BackfillPasswordChecks()
+	go purgeService.Run(time.Hour)
+	go rotationService.Run(time.Minute)
+	go idempotencyService.Run(time.Hour)
 }
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"fmt"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+)
+
+// AuditService records access to sensitive data
+type AuditService struct {
+	auditRepo *repositories.AuditLogRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditRepo *repositories.AuditLogRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// Record stores an audit log entry
+func (s *AuditService) Record(entry *models.AuditLog) error {
+	if err := s.auditRepo.Create(entry); err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrEmailAlreadyExists = errors.New("email already exists")
 	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidPassword    = errors.New("invalid password")
 )
 
 // JWTClaims represents JWT token claims
@@ -171,6 +172,24 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return nil, ErrInvalidToken
 }
 
+// VerifyPassword checks a user's account password, used to re-authenticate
+// before sensitive operations
+func (s *AuthService) VerifyPassword(userID uuid.UUID, password string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return ErrInvalidPassword
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
+
 // GetUserByID retrieves a user by ID
 func (s *AuthService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	user, err := s.userRepo.FindByID(id)
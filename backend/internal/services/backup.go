@@ -0,0 +1,247 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrInvalidBackup    = errors.New("invalid backup file")
+	ErrBackupPassphrase = errors.New("wrong passphrase or corrupted backup")
+)
+
+const (
+	backupFormat  = "wifiqr-backup"
+	backupVersion = 1
+	backupCipher  = "AES-256-GCM"
+	backupKDF     = "argon2id"
+
+	// Argon2id parameters for new backups, following the RFC 9106 second recommendation
+	backupArgonTime    = 3
+	backupArgonMemory  = 64 * 1024 // KiB
+	backupArgonThreads = 4
+	backupSaltBytes    = 16
+
+	// Bounds for parameters read from uploaded backups, so a crafted file can't
+	// make the server spend unbounded memory or time deriving a key
+	maxBackupArgonTime    = 10
+	maxBackupArgonMemory  = 256 * 1024 // KiB
+	maxBackupArgonThreads = 16
+)
+
+// BackupFile is the encrypted backup a user downloads. The header fields are
+// public; all but CreatedAt are authenticated as additional data.
+type BackupFile struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	KDF        BackupKDF `json:"kdf"`
+	Cipher     string    `json:"cipher"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// BackupKDF describes how the encryption key is derived from the passphrase
+type BackupKDF struct {
+	Name    string `json:"name"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// backupContents is the plaintext inside a backup
+type backupContents struct {
+	Credentials []ImportWifiRow `json:"credentials"`
+}
+
+// ExportBackupRequest represents a request to download an encrypted backup.
+// Password may be omitted when the caller's token was issued recently.
+type ExportBackupRequest struct {
+	Passphrase string `json:"passphrase" binding:"required,min=12"`
+	Password   string `json:"password"` // Account password, as for revealing a password
+}
+
+// BackupService exports a user's credentials into a file encrypted with their own
+// passphrase and restores such files, independent of the server's encryption key
+type BackupService struct {
+	wifiService   *WifiService
+	quotaService  *QuotaService
+	revealService *RevealService
+}
+
+// NewBackupService creates a new backup service
+func NewBackupService(wifiService *WifiService, quotaService *QuotaService, revealService *RevealService) *BackupService {
+	return &BackupService{
+		wifiService:   wifiService,
+		quotaService:  quotaService,
+		revealService: revealService,
+	}
+}
+
+// Export encrypts all personal WiFi credentials of a user, with their passwords, under
+// a key derived from the passphrase. The user must re-authenticate as for revealing a
+// password, and the export counts against the daily export limit.
+func (s *BackupService) Export(ec ExportContext, req *ExportBackupRequest) ([]byte, error) {
+	if err := s.revealService.Reauthenticate(&RevealPasswordRequest{Password: req.Password}, ec.revealContext(), "backup"); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.wifiService.exportRows(ec.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := sealBackup(&backupContents{Credentials: rows}, req.Passphrase, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Only count the export once the file is ready
+	if err := s.quotaService.RecordExport(ec, "backup", nil); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backup: %w", err)
+	}
+	return data, nil
+}
+
+// Restore decrypts a backup with passphrase and imports its credentials, re-encrypted
+// under the server's key, with the same options and checks as a file import
+func (s *BackupService) Restore(userID uuid.UUID, data []byte, passphrase string, opts ImportWifiOptions) (*ImportWifiResult, error) {
+	contents, err := openBackup(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(contents.Credentials) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(contents.Credentials) > maxImportRows {
+		return nil, ErrImportTooLarge
+	}
+	for i := range contents.Credentials {
+		contents.Credentials[i].Row = i + 1
+	}
+
+	return s.wifiService.Import(userID, contents.Credentials, opts)
+}
+
+// sealBackup encrypts the contents of a backup under a key derived from passphrase
+func sealBackup(contents *backupContents, passphrase string, now time.Time) (*BackupFile, error) {
+	plaintext, err := json.Marshal(contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backup: %w", err)
+	}
+
+	file := &BackupFile{
+		Format:    backupFormat,
+		Version:   backupVersion,
+		CreatedAt: now.UTC(),
+		KDF: BackupKDF{
+			Name:    backupKDF,
+			Salt:    make([]byte, backupSaltBytes),
+			Time:    backupArgonTime,
+			Memory:  backupArgonMemory,
+			Threads: backupArgonThreads,
+		},
+		Cipher: backupCipher,
+	}
+	if _, err := io.ReadFull(rand.Reader, file.KDF.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := backupAEAD(passphrase, file.KDF)
+	if err != nil {
+		return nil, err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plaintext, file.additionalData())
+	return file, nil
+}
+
+// openBackup checks the header of a backup file and decrypts its contents with passphrase
+func openBackup(data []byte, passphrase string) (*backupContents, error) {
+	var file BackupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if err := file.validate(); err != nil {
+		return nil, err
+	}
+
+	gcm, err := backupAEAD(passphrase, file.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: bad nonce", ErrInvalidBackup)
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, file.additionalData())
+	if err != nil {
+		return nil, ErrBackupPassphrase
+	}
+
+	var contents backupContents
+	if err := json.Unmarshal(plaintext, &contents); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	return &contents, nil
+}
+
+// validate checks the header of an uploaded backup before any key is derived
+func (f *BackupFile) validate() error {
+	switch {
+	case f.Format != backupFormat:
+		return fmt.Errorf("%w: not a WiFi QR backup", ErrInvalidBackup)
+	case f.Version != backupVersion:
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, f.Version)
+	case f.Cipher != backupCipher || f.KDF.Name != backupKDF:
+		return fmt.Errorf("%w: unsupported cipher or key derivation", ErrInvalidBackup)
+	case len(f.KDF.Salt) < backupSaltBytes:
+		return fmt.Errorf("%w: salt too short", ErrInvalidBackup)
+	case f.KDF.Time < 1 || f.KDF.Time > maxBackupArgonTime,
+		f.KDF.Memory < 8*uint32(f.KDF.Threads) || f.KDF.Memory > maxBackupArgonMemory,
+		f.KDF.Threads < 1 || f.KDF.Threads > maxBackupArgonThreads:
+		return fmt.Errorf("%w: key derivation parameters out of range", ErrInvalidBackup)
+	}
+	return nil
+}
+
+// additionalData encodes the header fields the ciphertext is bound to, so they
+// can't be changed without the passphrase
+func (f *BackupFile) additionalData() []byte {
+	return []byte(fmt.Sprintf("%s|%d|%s|%s|%x|%d|%d|%d",
+		f.Format, f.Version, f.Cipher, f.KDF.Name, f.KDF.Salt, f.KDF.Time, f.KDF.Memory, f.KDF.Threads))
+}
+
+// backupAEAD derives the AES-256-GCM cipher for a passphrase with Argon2id
+func backupAEAD(passphrase string, kdf BackupKDF) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, 32)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
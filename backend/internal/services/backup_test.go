@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gin-quickstart/internal/models"
+)
+
+const testBackupPassphrase = "a long passphrase only you know"
+
+// testBackupContents covers every field a backup carries
+func testBackupContents() *backupContents {
+	validFrom := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	validUntil := time.Date(2026, 5, 3, 20, 0, 0, 0, time.UTC)
+	return &backupContents{Credentials: []ImportWifiRow{
+		{
+			SSID:         "Home",
+			Password:     "correct horse battery staple",
+			SecurityType: models.SecurityWPA2,
+			KeyFormat:    models.KeyFormatPassphrase,
+			Notes:        "Router is in the hallway cupboard",
+			CustomFields: []models.CustomField{{Key: "Router admin", Value: "admin / hunter2"}},
+		},
+		{
+			SSID:             "Café Gäste 📶",
+			Password:         "Ümlaut-und-Emoji-🔑",
+			SecurityType:     models.SecurityWPA,
+			IsHidden:         true,
+			ValidFrom:        &validFrom,
+			ValidUntil:       &validUntil,
+			RotationSchedule: "daily",
+		},
+		{
+			SSID:         "Lobby",
+			SecurityType: models.SecurityNone,
+		},
+	}}
+}
+
+// sealTestBackup returns a backup of testBackupContents as the exported JSON
+func sealTestBackup(t *testing.T) []byte {
+	t.Helper()
+
+	file, err := sealBackup(testBackupContents(), testBackupPassphrase, time.Now())
+	if err != nil {
+		t.Fatalf("sealBackup: %v", err)
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return data
+}
+
+// modifyBackup decodes a backup file, applies modify and encodes it again
+func modifyBackup(t *testing.T, data []byte, modify func(file *BackupFile)) []byte {
+	t.Helper()
+
+	var file BackupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	modify(&file)
+	modified, err := json.Marshal(&file)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return modified
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	data := sealTestBackup(t)
+
+	contents, err := openBackup(data, testBackupPassphrase)
+	if err != nil {
+		t.Fatalf("openBackup: %v", err)
+	}
+	if want := testBackupContents(); !reflect.DeepEqual(contents, want) {
+		t.Errorf("restored contents differ\n got: %+v\nwant: %+v", contents, want)
+	}
+
+	var file BackupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if file.Format != backupFormat || file.Version != backupVersion || file.Cipher != backupCipher {
+		t.Errorf("header = %s v%d %s", file.Format, file.Version, file.Cipher)
+	}
+	if file.KDF.Name != backupKDF || file.KDF.Time != backupArgonTime || file.KDF.Memory != backupArgonMemory ||
+		file.KDF.Threads != backupArgonThreads || len(file.KDF.Salt) != backupSaltBytes {
+		t.Errorf("KDF = %+v", file.KDF)
+	}
+
+	// Nothing secret may appear in the clear
+	for _, secret := range []string{"correct horse battery staple", "hunter2", "hallway", "Home", "Lobby"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("backup contains %q in the clear", secret)
+		}
+	}
+}
+
+func TestBackupUsesFreshSaltAndNonce(t *testing.T) {
+	first, err := sealBackup(testBackupContents(), testBackupPassphrase, time.Now())
+	if err != nil {
+		t.Fatalf("sealBackup: %v", err)
+	}
+	second, err := sealBackup(testBackupContents(), testBackupPassphrase, time.Now())
+	if err != nil {
+		t.Fatalf("sealBackup: %v", err)
+	}
+
+	if bytes.Equal(first.KDF.Salt, second.KDF.Salt) {
+		t.Error("two backups share a salt")
+	}
+	if bytes.Equal(first.Nonce, second.Nonce) {
+		t.Error("two backups share a nonce")
+	}
+	if bytes.Equal(first.Ciphertext, second.Ciphertext) {
+		t.Error("two backups of the same contents have the same ciphertext")
+	}
+}
+
+func TestBackupWrongPassphrase(t *testing.T) {
+	data := sealTestBackup(t)
+
+	for _, passphrase := range []string{"", "a long passphrase only you knew", testBackupPassphrase + " "} {
+		if _, err := openBackup(data, passphrase); !errors.Is(err, ErrBackupPassphrase) {
+			t.Errorf("passphrase %q: error = %v, want ErrBackupPassphrase", passphrase, err)
+		}
+	}
+}
+
+func TestBackupTampered(t *testing.T) {
+	data := sealTestBackup(t)
+
+	tests := []struct {
+		name    string
+		modify  func(file *BackupFile)
+		wantErr error
+	}{
+		{
+			name:    "ciphertext byte flipped",
+			modify:  func(f *BackupFile) { f.Ciphertext[len(f.Ciphertext)/2] ^= 0x01 },
+			wantErr: ErrBackupPassphrase,
+		},
+		{
+			name:    "authentication tag cut off",
+			modify:  func(f *BackupFile) { f.Ciphertext = f.Ciphertext[:len(f.Ciphertext)-16] },
+			wantErr: ErrBackupPassphrase,
+		},
+		{
+			name:    "nonce changed",
+			modify:  func(f *BackupFile) { f.Nonce[0] ^= 0x01 },
+			wantErr: ErrBackupPassphrase,
+		},
+		{
+			name:    "nonce truncated",
+			modify:  func(f *BackupFile) { f.Nonce = f.Nonce[:8] },
+			wantErr: ErrInvalidBackup,
+		},
+		{
+			name:    "salt changed",
+			modify:  func(f *BackupFile) { f.KDF.Salt[0] ^= 0x01 },
+			wantErr: ErrBackupPassphrase,
+		},
+		{
+			name:    "iterations lowered within range",
+			modify:  func(f *BackupFile) { f.KDF.Time = 1 },
+			wantErr: ErrBackupPassphrase,
+		},
+		{
+			name:    "threads changed within range",
+			modify:  func(f *BackupFile) { f.KDF.Threads = 1 },
+			wantErr: ErrBackupPassphrase,
+		},
+		{
+			name:    "format changed",
+			modify:  func(f *BackupFile) { f.Format = "other-backup" },
+			wantErr: ErrInvalidBackup,
+		},
+		{
+			name:    "version changed",
+			modify:  func(f *BackupFile) { f.Version = backupVersion + 1 },
+			wantErr: ErrInvalidBackup,
+		},
+		{
+			name:    "cipher changed",
+			modify:  func(f *BackupFile) { f.Cipher = "AES-128-GCM" },
+			wantErr: ErrInvalidBackup,
+		},
+		{
+			name:    "key derivation changed",
+			modify:  func(f *BackupFile) { f.KDF.Name = "scrypt" },
+			wantErr: ErrInvalidBackup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := modifyBackup(t, data, tt.modify)
+			if _, err := openBackup(tampered, testBackupPassphrase); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("creation time is not authenticated", func(t *testing.T) {
+		tampered := modifyBackup(t, data, func(f *BackupFile) { f.CreatedAt = f.CreatedAt.Add(-time.Hour) })
+		if _, err := openBackup(tampered, testBackupPassphrase); err != nil {
+			t.Errorf("openBackup: %v", err)
+		}
+	})
+
+	t.Run("not a backup", func(t *testing.T) {
+		for _, input := range []string{"", "not json", "[]", `{"format": 1}`} {
+			if _, err := openBackup([]byte(input), testBackupPassphrase); !errors.Is(err, ErrInvalidBackup) {
+				t.Errorf("%q: error = %v, want ErrInvalidBackup", input, err)
+			}
+		}
+	})
+}
+
+// TestBackupCiphertextBoundToHeader checks the additional data on its own: the right key
+// can't open the ciphertext under a header that differs in any authenticated field
+func TestBackupCiphertextBoundToHeader(t *testing.T) {
+	file, err := sealBackup(testBackupContents(), testBackupPassphrase, time.Now())
+	if err != nil {
+		t.Fatalf("sealBackup: %v", err)
+	}
+	gcm, err := backupAEAD(testBackupPassphrase, file.KDF)
+	if err != nil {
+		t.Fatalf("backupAEAD: %v", err)
+	}
+
+	if _, err := gcm.Open(nil, file.Nonce, file.Ciphertext, file.additionalData()); err != nil {
+		t.Fatalf("open with the original header: %v", err)
+	}
+
+	headers := map[string]func(f *BackupFile){
+		"format":  func(f *BackupFile) { f.Format += "x" },
+		"version": func(f *BackupFile) { f.Version++ },
+		"cipher":  func(f *BackupFile) { f.Cipher += "x" },
+		"kdf":     func(f *BackupFile) { f.KDF.Name += "x" },
+		"salt":    func(f *BackupFile) { f.KDF.Salt = append([]byte{}, f.KDF.Salt...); f.KDF.Salt[0] ^= 0x01 },
+		"time":    func(f *BackupFile) { f.KDF.Time++ },
+		"memory":  func(f *BackupFile) { f.KDF.Memory++ },
+		"threads": func(f *BackupFile) { f.KDF.Threads++ },
+	}
+	for name, modify := range headers {
+		header := *file
+		modify(&header)
+		if _, err := gcm.Open(nil, file.Nonce, file.Ciphertext, header.additionalData()); err == nil {
+			t.Errorf("ciphertext opened with a different %s", name)
+		}
+	}
+}
+
+func TestBackupKDFParameters(t *testing.T) {
+	valid := BackupKDF{
+		Name:    backupKDF,
+		Salt:    make([]byte, backupSaltBytes),
+		Time:    backupArgonTime,
+		Memory:  backupArgonMemory,
+		Threads: backupArgonThreads,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(kdf *BackupKDF)
+		valid  bool
+	}{
+		{name: "defaults", modify: func(kdf *BackupKDF) {}, valid: true},
+		{name: "smallest", modify: func(kdf *BackupKDF) { kdf.Time, kdf.Memory, kdf.Threads = 1, 8, 1 }, valid: true},
+		{name: "largest", modify: func(kdf *BackupKDF) {
+			kdf.Time, kdf.Memory, kdf.Threads = maxBackupArgonTime, maxBackupArgonMemory, maxBackupArgonThreads
+		}, valid: true},
+		{name: "longer salt", modify: func(kdf *BackupKDF) { kdf.Salt = make([]byte, 32) }, valid: true},
+		{name: "no iterations", modify: func(kdf *BackupKDF) { kdf.Time = 0 }},
+		{name: "too many iterations", modify: func(kdf *BackupKDF) { kdf.Time = maxBackupArgonTime + 1 }},
+		{name: "huge iterations", modify: func(kdf *BackupKDF) { kdf.Time = 1 << 31 }},
+		{name: "no memory", modify: func(kdf *BackupKDF) { kdf.Memory = 0 }},
+		{name: "too much memory", modify: func(kdf *BackupKDF) { kdf.Memory = maxBackupArgonMemory + 1 }},
+		{name: "huge memory", modify: func(kdf *BackupKDF) { kdf.Memory = 1 << 31 }},
+		{name: "less memory than 8 KiB per thread", modify: func(kdf *BackupKDF) { kdf.Memory, kdf.Threads = 31, 4 }},
+		{name: "no threads", modify: func(kdf *BackupKDF) { kdf.Threads = 0 }},
+		{name: "too many threads", modify: func(kdf *BackupKDF) { kdf.Threads = maxBackupArgonThreads + 1 }},
+		{name: "short salt", modify: func(kdf *BackupKDF) { kdf.Salt = make([]byte, backupSaltBytes-1) }},
+		{name: "no salt", modify: func(kdf *BackupKDF) { kdf.Salt = nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kdf := valid
+			tt.modify(&kdf)
+			file := &BackupFile{Format: backupFormat, Version: backupVersion, Cipher: backupCipher, KDF: kdf}
+
+			err := file.validate()
+			if tt.valid {
+				if err != nil {
+					t.Errorf("validate: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidBackup) {
+				t.Fatalf("validate error = %v, want ErrInvalidBackup", err)
+			}
+
+			// openBackup must refuse the file before deriving a key
+			data, err := json.Marshal(file)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			start := time.Now()
+			if _, err := openBackup(data, testBackupPassphrase); !errors.Is(err, ErrInvalidBackup) {
+				t.Errorf("openBackup error = %v, want ErrInvalidBackup", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("openBackup took %s, as if it derived a key", elapsed)
+			}
+		})
+	}
+}
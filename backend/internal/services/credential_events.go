@@ -0,0 +1,59 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// CredentialEvents tells subscribers when a credential changes, so live views such as
+// kiosk screens can reload it. Events only reach subscribers in the same process.
+type CredentialEvents struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan struct{}]struct{}
+}
+
+// NewCredentialEvents creates a new credential event broker
+func NewCredentialEvents() *CredentialEvents {
+	return &CredentialEvents{
+		subscribers: make(map[uuid.UUID]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe returns a channel that receives a value after credentialID changes, and a
+// function to stop the subscription. Changes made while the subscriber is busy are
+// coalesced into one value.
+func (e *CredentialEvents) Subscribe(credentialID uuid.UUID) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	e.mu.Lock()
+	if e.subscribers[credentialID] == nil {
+		e.subscribers[credentialID] = make(map[chan struct{}]struct{})
+	}
+	e.subscribers[credentialID][ch] = struct{}{}
+	e.mu.Unlock()
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subscribers[credentialID], ch)
+		if len(e.subscribers[credentialID]) == 0 {
+			delete(e.subscribers, credentialID)
+		}
+	}
+}
+
+// Publish notifies the subscribers of the given credentials without blocking
+func (e *CredentialEvents) Publish(credentialIDs ...uuid.UUID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, id := range credentialIDs {
+		for ch := range e.subscribers[id] {
+			select {
+			case ch <- struct{}{}:
+			default: // A change is already pending
+			}
+		}
+	}
+}
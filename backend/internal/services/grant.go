@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGrantNotFound    = errors.New("credential grant not found")
+	ErrGranteeNotFound  = errors.New("no user is registered with that email")
+	ErrCannotGrantOwner = errors.New("the owner already has full access to the credential")
+)
+
+// GrantService manages grants that give other users access to a WiFi credential
+type GrantService struct {
+	grantRepo   *repositories.CredentialGrantRepository
+	userRepo    *repositories.UserRepository
+	wifiService *WifiService
+}
+
+// NewGrantService creates a new grant service
+func NewGrantService(grantRepo *repositories.CredentialGrantRepository, userRepo *repositories.UserRepository, wifiService *WifiService) *GrantService {
+	return &GrantService{
+		grantRepo:   grantRepo,
+		userRepo:    userRepo,
+		wifiService: wifiService,
+	}
+}
+
+// GrantRequest represents a request to give a user access to a credential
+type GrantRequest struct {
+	Email      string                 `json:"email" binding:"required,email"`
+	Permission models.GrantPermission `json:"permission" binding:"required,oneof=view reveal edit"`
+}
+
+// Grant gives the user registered with req.Email access to a credential, replacing any
+// permission they already had. Only the owner or an admin can grant access.
+func (s *GrantService) Grant(credentialID uuid.UUID, userID uuid.UUID, isAdmin bool, req *GrantRequest) (*models.CredentialGrant, error) {
+	credential, err := s.wifiService.GetAsOwner(credentialID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	grantee, err := s.userRepo.FindByEmail(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if grantee == nil {
+		return nil, ErrGranteeNotFound
+	}
+	if grantee.ID == credential.UserID {
+		return nil, ErrCannotGrantOwner
+	}
+
+	grant := &models.CredentialGrant{
+		CredentialID: credentialID,
+		GranteeID:    grantee.ID,
+		Permission:   req.Permission,
+		GrantedBy:    userID,
+	}
+	if err := s.grantRepo.Upsert(grant); err != nil {
+		return nil, err
+	}
+
+	// Reload so an updated grant reports its original ID and creation time
+	saved, err := s.grantRepo.FindByCredentialAndGrantee(credentialID, grantee.ID)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, ErrGrantNotFound
+	}
+	saved.Grantee = grantee
+	return saved, nil
+}
+
+// ListGrants retrieves the grants on a credential. Only the owner or an admin can see them.
+func (s *GrantService) ListGrants(credentialID uuid.UUID, userID uuid.UUID, isAdmin bool) ([]models.CredentialGrant, error) {
+	if _, err := s.wifiService.GetAsOwner(credentialID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	grants, err := s.grantRepo.FindByCredentialID(credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credential grants: %w", err)
+	}
+	return grants, nil
+}
+
+// Revoke removes a user's access to a credential. The owner or an admin can revoke any
+// grant; grantees can also remove their own.
+func (s *GrantService) Revoke(credentialID, granteeID uuid.UUID, userID uuid.UUID, isAdmin bool) error {
+	if granteeID != userID {
+		if _, err := s.wifiService.GetAsOwner(credentialID, userID, isAdmin); err != nil {
+			return err
+		}
+	}
+
+	if err := s.grantRepo.Delete(credentialID, granteeID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGrantNotFound
+		}
+		return err
+	}
+	return nil
+}
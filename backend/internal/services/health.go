@@ -0,0 +1,243 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// HealthSeverity ranks how urgent a credential issue is
+type HealthSeverity string
+
+const (
+	SeverityCritical HealthSeverity = "critical"
+	SeverityWarning  HealthSeverity = "warning"
+)
+
+// Health issue codes
+const (
+	IssueOpenNetwork        = "open_network"
+	IssueWEP                = "wep"
+	IssueShortPassword      = "short_password"
+	IssueDictionaryPassword = "dictionary_password"
+	IssueStalePassword      = "stale_password"
+	IssueReusedPassword     = "reused_password"
+	IssueUncheckedPassword  = "unchecked_password"
+)
+
+// healthPenalties is the score deducted for each issue
+var healthPenalties = map[string]int{
+	IssueOpenNetwork:        60,
+	IssueWEP:                60,
+	IssueShortPassword:      20,
+	IssueDictionaryPassword: 40,
+	IssueStalePassword:      15,
+	IssueReusedPassword:     25,
+	IssueUncheckedPassword:  10,
+}
+
+// HealthIssue describes a single problem found on a credential
+type HealthIssue struct {
+	Code     string         `json:"code"`
+	Severity HealthSeverity `json:"severity"`
+	Message  string         `json:"message"`
+}
+
+// CredentialHealth is the health score of one credential (0-100, higher is better)
+type CredentialHealth struct {
+	ID           uuid.UUID           `json:"id"`
+	UserID       uuid.UUID           `json:"user_id"`
+	UserEmail    string              `json:"user_email,omitempty"`
+	SSID         string              `json:"ssid"`
+	SecurityType models.SecurityType `json:"security_type"`
+	Score        int                 `json:"score"`
+	Issues       []HealthIssue       `json:"issues"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// HealthSummary aggregates the scores of a report
+type HealthSummary struct {
+	TotalCredentials int            `json:"total_credentials"`
+	AverageScore     int            `json:"average_score"`
+	HealthyCount     int            `json:"healthy_count"`
+	IssueCounts      map[string]int `json:"issue_counts"`
+}
+
+// HealthReport is the security health report for a set of credentials
+type HealthReport struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Summary     HealthSummary      `json:"summary"`
+	Credentials []CredentialHealth `json:"credentials"`
+}
+
+// HealthService scores WiFi credentials for common security problems. It reads the
+// fingerprints and weakness flags stored with each credential and never decrypts passwords.
+type HealthService struct {
+	wifiRepo       *repositories.WifiRepository
+	maxPasswordAge time.Duration
+}
+
+// NewHealthService creates a new health service
+func NewHealthService(wifiRepo *repositories.WifiRepository, maxPasswordAgeDays int) *HealthService {
+	return &HealthService{
+		wifiRepo:       wifiRepo,
+		maxPasswordAge: time.Duration(maxPasswordAgeDays) * 24 * time.Hour,
+	}
+}
+
+// UserReport builds the health report for a user's credentials
+func (s *HealthService) UserReport(userID uuid.UUID) (*HealthReport, error) {
+	credentials, err := s.wifiRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WiFi credentials: %w", err)
+	}
+	return s.buildReport(credentials, &userID)
+}
+
+// SystemReport builds the health report for every credential (admin only)
+func (s *HealthService) SystemReport() (*HealthReport, error) {
+	credentials, err := s.wifiRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all WiFi credentials: %w", err)
+	}
+	return s.buildReport(credentials, nil)
+}
+
+// buildReport scores credentials and aggregates the results
+func (s *HealthService) buildReport(credentials []models.WifiCredential, userID *uuid.UUID) (*HealthReport, error) {
+	reused, err := s.wifiRepo.FindReusedPasswords(userID)
+	if err != nil {
+		return nil, err
+	}
+	reusedSet := make(map[string]bool, len(reused))
+	for _, r := range reused {
+		reusedSet[r.UserID.String()+":"+r.PasswordFingerprint] = true
+	}
+
+	report := &HealthReport{
+		GeneratedAt: time.Now(),
+		Credentials: make([]CredentialHealth, 0, len(credentials)),
+		Summary: HealthSummary{
+			TotalCredentials: len(credentials),
+			IssueCounts:      make(map[string]int),
+		},
+	}
+
+	totalScore := 0
+	for i := range credentials {
+		credential := &credentials[i]
+		isReused := reusedSet[credential.UserID.String()+":"+credential.PasswordFingerprint]
+
+		health := s.scoreCredential(credential, isReused)
+
+		totalScore += health.Score
+		if len(health.Issues) == 0 {
+			report.Summary.HealthyCount++
+		}
+		for _, issue := range health.Issues {
+			report.Summary.IssueCounts[issue.Code]++
+		}
+		report.Credentials = append(report.Credentials, *health)
+	}
+
+	if len(credentials) > 0 {
+		report.Summary.AverageScore = totalScore / len(credentials)
+	}
+
+	return report, nil
+}
+
+// scoreCredential evaluates a single credential
+func (s *HealthService) scoreCredential(credential *models.WifiCredential, isReused bool) *CredentialHealth {
+	var issues []HealthIssue
+
+	switch credential.SecurityType {
+	case models.SecurityNone:
+		issues = append(issues, HealthIssue{
+			Code:     IssueOpenNetwork,
+			Severity: SeverityCritical,
+			Message:  "Open network: traffic is not encrypted",
+		})
+	case models.SecurityWEP:
+		issues = append(issues, HealthIssue{
+			Code:     IssueWEP,
+			Severity: SeverityCritical,
+			Message:  "WEP can be cracked in minutes, switch to WPA2 or newer",
+		})
+	}
+
+	// Flags are set when the password is saved; the backfill leaves them unset when the
+	// stored password can't be decrypted
+	switch {
+	case credential.PasswordShort == nil || credential.PasswordDictionary == nil:
+		issues = append(issues, HealthIssue{
+			Code:     IssueUncheckedPassword,
+			Severity: SeverityWarning,
+			Message:  "Password couldn't be checked, it may be corrupted. Set it again to fix this.",
+		})
+	default:
+		if *credential.PasswordShort {
+			issues = append(issues, HealthIssue{
+				Code:     IssueShortPassword,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("Password is shorter than %d characters", minRecommendedPassphraseLength),
+			})
+		}
+		if *credential.PasswordDictionary {
+			issues = append(issues, HealthIssue{
+				Code:     IssueDictionaryPassword,
+				Severity: SeverityCritical,
+				Message:  "Password is a common password, a dictionary word or the network name",
+			})
+		}
+	}
+
+	// Open networks have no password to change
+	if s.maxPasswordAge > 0 && credential.SecurityType != models.SecurityNone && time.Since(credential.UpdatedAt) > s.maxPasswordAge {
+		issues = append(issues, HealthIssue{
+			Code:     IssueStalePassword,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Password has not been changed for over %d days", int(s.maxPasswordAge.Hours()/24)),
+		})
+	}
+
+	if isReused {
+		issues = append(issues, HealthIssue{
+			Code:     IssueReusedPassword,
+			Severity: SeverityWarning,
+			Message:  "The same password is used for other networks",
+		})
+	}
+
+	score := 100
+	for _, issue := range issues {
+		score -= healthPenalties[issue.Code]
+	}
+	if score < 0 {
+		score = 0
+	}
+
+	userEmail := ""
+	if credential.User != nil {
+		userEmail = credential.User.Email
+	}
+
+	if issues == nil {
+		issues = []HealthIssue{}
+	}
+
+	return &CredentialHealth{
+		ID:           credential.ID,
+		UserID:       credential.UserID,
+		UserEmail:    userEmail,
+		SSID:         credential.SSID,
+		SecurityType: credential.SecurityType,
+		Score:        score,
+		Issues:       issues,
+		UpdatedAt:    credential.UpdatedAt,
+	}
+}
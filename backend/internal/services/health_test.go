@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gin-quickstart/internal/models"
+)
+
+func TestScoreCredentialStalePassword(t *testing.T) {
+	service := NewHealthService(nil, 180)
+	checked := false
+
+	tests := []struct {
+		name         string
+		securityType models.SecurityType
+		age          time.Duration
+		wantStale    bool
+	}{
+		{name: "old WPA2 password", securityType: models.SecurityWPA2, age: 200 * 24 * time.Hour, wantStale: true},
+		{name: "old WEP key", securityType: models.SecurityWEP, age: 200 * 24 * time.Hour, wantStale: true},
+		{name: "recent WPA2 password", securityType: models.SecurityWPA2, age: 10 * 24 * time.Hour},
+		{name: "old open network", securityType: models.SecurityNone, age: 200 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := service.scoreCredential(&models.WifiCredential{
+				SSID:               "Office",
+				SecurityType:       tt.securityType,
+				PasswordShort:      &checked,
+				PasswordDictionary: &checked,
+				UpdatedAt:          time.Now().Add(-tt.age),
+			}, false)
+
+			stale := false
+			for _, issue := range health.Issues {
+				if issue.Code == IssueStalePassword {
+					stale = true
+				}
+			}
+			if stale != tt.wantStale {
+				t.Errorf("stale password issue = %v, want %v", stale, tt.wantStale)
+			}
+		})
+	}
+}
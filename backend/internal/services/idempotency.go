@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotentResponse is a stored response to replay for a retried request
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyService remembers the responses to requests sent with an Idempotency-Key
+// header, so clients can safely retry requests that create credentials
+type IdempotencyService struct {
+	keyRepo     *repositories.IdempotencyKeyRepository
+	wifiService *WifiService // Encrypts stored responses, which may hold generated passwords
+	ttl         time.Duration
+}
+
+// NewIdempotencyService creates a new idempotency service. Keys are forgotten ttlHours after first use.
+func NewIdempotencyService(keyRepo *repositories.IdempotencyKeyRepository, wifiService *WifiService, ttlHours int) *IdempotencyService {
+	return &IdempotencyService{
+		keyRepo:     keyRepo,
+		wifiService: wifiService,
+		ttl:         time.Duration(ttlHours) * time.Hour,
+	}
+}
+
+// Begin reserves key for a request with the given fingerprint. It returns the stored
+// response when the key was used for the same request before, and nil when the
+// request should run and its outcome be passed to Complete or Release.
+func (s *IdempotencyService) Begin(userID uuid.UUID, key, fingerprint string) (*IdempotentResponse, error) {
+	now := time.Now()
+
+	// A second attempt covers a record that expired but wasn't cleaned up yet
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.keyRepo.Reserve(&models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: fingerprint,
+			ExpiresAt:   now.Add(s.ttl),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		record, err := s.keyRepo.FindByUserAndKey(userID, key)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			continue // Released in the meantime
+		}
+		if record.ExpiresAt.Before(now) {
+			if err := s.keyRepo.Delete(record.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case record.RequestHash != fingerprint:
+			return nil, ErrIdempotencyKeyReused
+		case record.StatusCode == 0:
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		body, err := s.wifiService.DecryptPassword(record.EncryptedResponseBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt stored response: %w", err)
+		}
+		return &IdempotentResponse{
+			StatusCode:  record.StatusCode,
+			ContentType: record.ContentType,
+			Body:        []byte(body),
+		}, nil
+	}
+	return nil, ErrIdempotencyKeyInProgress
+}
+
+// Complete stores the response to the request key was reserved for
+func (s *IdempotencyService) Complete(userID uuid.UUID, key string, response IdempotentResponse) error {
+	record, err := s.keyRepo.FindByUserAndKey(userID, key)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return nil // Expired and cleaned up while the request ran
+	}
+
+	encrypted, err := s.wifiService.encryptPassword(string(response.Body))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt response: %w", err)
+	}
+	return s.keyRepo.Complete(record.ID, response.StatusCode, truncate(response.ContentType, 100), encrypted)
+}
+
+// Release forgets key so the request can be retried, for outcomes that may change
+// on retry such as server errors
+func (s *IdempotencyService) Release(userID uuid.UUID, key string) error {
+	record, err := s.keyRepo.FindByUserAndKey(userID, key)
+	if err != nil || record == nil {
+		return err
+	}
+	return s.keyRepo.Delete(record.ID)
+}
+
+// Run deletes expired keys immediately and then every interval. It blocks, so start it in a goroutine.
+func (s *IdempotencyService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.cleanup()
+		<-ticker.C
+	}
+}
+
+// cleanup deletes expired keys and logs how many were removed
+func (s *IdempotencyService) cleanup() {
+	deleted, err := s.keyRepo.DeleteExpired(time.Now())
+	if err != nil {
+		log.Printf("Idempotency key cleanup failed: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Idempotency key cleanup removed %d expired keys", deleted)
+	}
+}
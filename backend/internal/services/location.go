@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLocationNotFound      = errors.New("location not found")
+	ErrInvalidLocationKind   = errors.New("invalid location kind")
+	ErrInvalidLocationParent = errors.New("invalid parent: sites are top level, buildings go in sites or folders, floors go in buildings")
+	ErrLocationCycle         = errors.New("a location can't be moved inside itself")
+	ErrLocationHasChildren   = errors.New("location still contains other locations")
+)
+
+// LocationService handles the location hierarchy
+type LocationService struct {
+	locationRepo   *repositories.LocationRepository
+	membershipRepo *repositories.MembershipRepository
+}
+
+// NewLocationService creates a new location service
+func NewLocationService(locationRepo *repositories.LocationRepository, membershipRepo *repositories.MembershipRepository) *LocationService {
+	return &LocationService{
+		locationRepo:   locationRepo,
+		membershipRepo: membershipRepo,
+	}
+}
+
+// LocationRequest represents a request to create or update a location
+type LocationRequest struct {
+	Name     string              `json:"name" binding:"required,min=1,max=100"`
+	Kind     models.LocationKind `json:"kind" binding:"required,oneof=site building floor folder"`
+	ParentID *uuid.UUID          `json:"parent_id"`
+
+	OrganizationID *uuid.UUID `json:"organization_id"` // Create an organization location; ignored on update
+}
+
+// Create creates a new location for a user, or for an organization the user is at least a member of
+func (s *LocationService) Create(userID uuid.UUID, req *LocationRequest) (*models.Location, error) {
+	scope, err := resolveScope(s.membershipRepo, userID, req.OrganizationID, models.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validatePlacement(scope, uuid.Nil, req.Kind, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	location := &models.Location{
+		UserID:         userID,
+		OrganizationID: req.OrganizationID,
+		ParentID:       req.ParentID,
+		Name:           strings.TrimSpace(req.Name),
+		Kind:           req.Kind,
+	}
+	if err := s.locationRepo.Create(location); err != nil {
+		return nil, fmt.Errorf("failed to create location: %w", err)
+	}
+	return location, nil
+}
+
+// GetAll retrieves the personal locations of a user, or the locations of an organization
+// they belong to, as a flat list; parent_id links the tree
+func (s *LocationService) GetAll(userID uuid.UUID, organizationID *uuid.UUID) ([]models.Location, error) {
+	scope, err := resolveScope(s.membershipRepo, userID, organizationID, models.OrgRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.locationRepo.FindByScope(scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+	return locations, nil
+}
+
+// Update renames, re-kinds or moves a location
+func (s *LocationService) Update(id uuid.UUID, userID uuid.UUID, req *LocationRequest) (*models.Location, error) {
+	location, err := s.getEditable(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := repositories.ScopeOf(location.UserID, location.OrganizationID)
+	if err := s.validatePlacement(scope, location.ID, req.Kind, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	// Changing the kind could leave children under a parent that can't hold them
+	if req.Kind != location.Kind {
+		hasChildren, err := s.locationRepo.HasChildren(location.ID)
+		if err != nil {
+			return nil, err
+		}
+		if hasChildren {
+			return nil, ErrLocationHasChildren
+		}
+	}
+
+	location.Name = strings.TrimSpace(req.Name)
+	location.Kind = req.Kind
+	location.ParentID = req.ParentID
+	if err := s.locationRepo.Update(location); err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+	return location, nil
+}
+
+// Delete deletes an empty location. Credentials in it become unassigned.
+func (s *LocationService) Delete(id uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.getEditable(id, userID); err != nil {
+		return err
+	}
+
+	hasChildren, err := s.locationRepo.HasChildren(id)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return ErrLocationHasChildren
+	}
+
+	if err := s.locationRepo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete location: %w", err)
+	}
+	return nil
+}
+
+// getEditable retrieves a location the user may change: one of their personal locations,
+// or a location of an organization where they are at least a member
+func (s *LocationService) getEditable(id uuid.UUID, userID uuid.UUID) (*models.Location, error) {
+	location, err := s.locationRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	// Locations outside the user's workspaces are reported as missing rather than forbidden
+	if location == nil {
+		return nil, ErrLocationNotFound
+	}
+	if location.OrganizationID == nil {
+		if location.UserID != userID {
+			return nil, ErrLocationNotFound
+		}
+		return location, nil
+	}
+	if _, err := requireOrgRole(s.membershipRepo, *location.OrganizationID, userID, models.OrgRoleMember); err != nil {
+		if errors.Is(err, ErrOrganizationNotFound) {
+			return nil, ErrLocationNotFound
+		}
+		return nil, err
+	}
+	return location, nil
+}
+
+// validatePlacement checks the kind of a location against its parent and, for
+// existing locations (id != uuid.Nil), that it isn't moved inside its own subtree
+func (s *LocationService) validatePlacement(scope repositories.Scope, id uuid.UUID, kind models.LocationKind, parentID *uuid.UUID) error {
+	if !models.IsValidLocationKind(string(kind)) {
+		return ErrInvalidLocationKind
+	}
+
+	if parentID == nil {
+		// Only sites and folders can be top level
+		if kind == models.LocationSite || kind == models.LocationFolder {
+			return nil
+		}
+		return ErrInvalidLocationParent
+	}
+
+	// The parent must be in the same workspace as the location
+	parent, err := s.locationRepo.FindByID(*parentID)
+	if err != nil {
+		return fmt.Errorf("failed to get location: %w", err)
+	}
+	if parent == nil || !scope.Contains(parent.UserID, parent.OrganizationID) {
+		return ErrLocationNotFound
+	}
+	if !parent.Kind.CanContain(kind) {
+		return ErrInvalidLocationParent
+	}
+
+	if id != uuid.Nil {
+		inSubtree, err := s.locationRepo.IsInSubtree(id, parent.ID)
+		if err != nil {
+			return err
+		}
+		if inSubtree {
+			return ErrLocationCycle
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrganizationNotFound    = errors.New("organization not found")
+	ErrOrganizationNotEmpty    = errors.New("organization still has WiFi credentials, including ones in the trash")
+	ErrInsufficientRole        = errors.New("your role in this organization does not allow this action")
+	ErrMembershipNotFound      = errors.New("user is not a member of this organization")
+	ErrLastOwner               = errors.New("an organization must keep at least one owner")
+	ErrAlreadyMember           = errors.New("user is already a member of this organization")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvitationExpired       = errors.New("invitation has expired or was already accepted")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+)
+
+// invitationTTL is how long an invitation can be accepted
+const invitationTTL = 7 * 24 * time.Hour
+
+// OrganizationService manages organizations, their members and invitations
+type OrganizationService struct {
+	orgRepo        *repositories.OrganizationRepository
+	membershipRepo *repositories.MembershipRepository
+	invitationRepo *repositories.InvitationRepository
+	userRepo       *repositories.UserRepository
+	wifiRepo       *repositories.WifiRepository
+	transactor     *repositories.Transactor
+	mailer         Mailer
+	frontendURL    string // Invitation emails link to the accept page of the frontend
+}
+
+// NewOrganizationService creates a new organization service
+func NewOrganizationService(orgRepo *repositories.OrganizationRepository, membershipRepo *repositories.MembershipRepository, invitationRepo *repositories.InvitationRepository, userRepo *repositories.UserRepository, wifiRepo *repositories.WifiRepository, transactor *repositories.Transactor, mailer Mailer, frontendURL string) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:        orgRepo,
+		membershipRepo: membershipRepo,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		wifiRepo:       wifiRepo,
+		transactor:     transactor,
+		mailer:         mailer,
+		frontendURL:    strings.TrimRight(frontendURL, "/"),
+	}
+}
+
+// OrganizationRequest represents a request to create or rename an organization
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+// UpdateMemberRequest represents a request to change a member's role
+type UpdateMemberRequest struct {
+	Role models.OrgRole `json:"role" binding:"required,oneof=owner admin member viewer"`
+}
+
+// InviteRequest represents a request to invite someone to an organization by email
+type InviteRequest struct {
+	Email string         `json:"email" binding:"required,email"`
+	Role  models.OrgRole `json:"role" binding:"required,oneof=owner admin member viewer"`
+}
+
+// AcceptInvitationRequest carries the token of an invitation
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Create creates an organization with the user as its first owner
+func (s *OrganizationService) Create(userID uuid.UUID, req *OrganizationRequest) (*models.Organization, error) {
+	organization := &models.Organization{
+		Name:      strings.TrimSpace(req.Name),
+		CreatedBy: userID,
+	}
+
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		if err := s.orgRepo.WithTx(tx).Create(organization); err != nil {
+			return err
+		}
+		return s.membershipRepo.WithTx(tx).Create(&models.Membership{
+			OrganizationID: organization.ID,
+			UserID:         userID,
+			Role:           models.OrgRoleOwner,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return organization, nil
+}
+
+// ListForUser retrieves the memberships of a user, each with its organization
+func (s *OrganizationService) ListForUser(userID uuid.UUID) ([]models.Membership, error) {
+	memberships, err := s.membershipRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organizations: %w", err)
+	}
+	return memberships, nil
+}
+
+// Get retrieves an organization the user belongs to, along with their role in it
+func (s *OrganizationService) Get(organizationID, userID uuid.UUID) (*models.Organization, models.OrgRole, error) {
+	membership, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleViewer)
+	if err != nil {
+		return nil, "", err
+	}
+	organization, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	return organization, membership.Role, nil
+}
+
+// Update renames an organization. Admins and owners only.
+func (s *OrganizationService) Update(organizationID, userID uuid.UUID, req *OrganizationRequest) (*models.Organization, models.OrgRole, error) {
+	membership, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+	organization, err := s.getOrganization(organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	organization.Name = strings.TrimSpace(req.Name)
+	if err := s.orgRepo.Update(organization); err != nil {
+		return nil, "", fmt.Errorf("failed to update organization: %w", err)
+	}
+	return organization, membership.Role, nil
+}
+
+// Delete deletes an organization along with its tags, locations, members and invitations.
+// Owners only, and only once its credentials have been deleted and purged from the trash.
+func (s *OrganizationService) Delete(organizationID, userID uuid.UUID) error {
+	if _, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	count, err := s.wifiRepo.CountByOrganizationID(organizationID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrOrganizationNotEmpty
+	}
+
+	if err := s.orgRepo.Delete(organizationID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrganizationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListMembers retrieves the members of an organization the user belongs to
+func (s *OrganizationService) ListMembers(organizationID, userID uuid.UUID) ([]models.Membership, error) {
+	if _, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleViewer); err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.membershipRepo.FindByOrganizationID(organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members: %w", err)
+	}
+	return memberships, nil
+}
+
+// UpdateMemberRole changes a member's role. Admins manage admins, members and viewers;
+// only owners can make or demote owners, and the last owner can't be demoted.
+func (s *OrganizationService) UpdateMemberRole(organizationID, memberID, userID uuid.UUID, req *UpdateMemberRequest) (*models.Membership, error) {
+	actor, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	var member *models.Membership
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		membershipRepo := s.membershipRepo.WithTx(tx)
+
+		member, err = membershipRepo.FindByOrganizationAndUser(organizationID, memberID)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return ErrMembershipNotFound
+		}
+		if (member.Role == models.OrgRoleOwner || req.Role == models.OrgRoleOwner) && actor.Role != models.OrgRoleOwner {
+			return ErrInsufficientRole
+		}
+		if member.Role == models.OrgRoleOwner && req.Role != models.OrgRoleOwner {
+			if err := checkNotLastOwner(membershipRepo, organizationID); err != nil {
+				return err
+			}
+		}
+
+		if err := membershipRepo.UpdateRole(organizationID, memberID, req.Role); err != nil {
+			return err
+		}
+		member.Role = req.Role
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember removes a user from an organization. Any member can leave; removing
+// someone else takes an admin, or an owner when the member is an owner.
+// The last owner can't leave.
+func (s *OrganizationService) RemoveMember(organizationID, memberID, userID uuid.UUID) error {
+	minimum := models.OrgRoleAdmin
+	if memberID == userID {
+		minimum = models.OrgRoleViewer
+	}
+	actor, err := requireOrgRole(s.membershipRepo, organizationID, userID, minimum)
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		membershipRepo := s.membershipRepo.WithTx(tx)
+
+		member, err := membershipRepo.FindByOrganizationAndUser(organizationID, memberID)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return ErrMembershipNotFound
+		}
+		if member.Role == models.OrgRoleOwner {
+			if actor.Role != models.OrgRoleOwner {
+				return ErrInsufficientRole
+			}
+			if err := checkNotLastOwner(membershipRepo, organizationID); err != nil {
+				return err
+			}
+		}
+
+		return membershipRepo.Delete(organizationID, memberID)
+	})
+}
+
+// Invite creates an invitation for an email address and emails the invitee a link to
+// accept it. Admins and owners only; only owners can invite owners. The token is only
+// sent to the invitee, so the inviter can't accept the invitation themselves.
+func (s *OrganizationService) Invite(organizationID, userID uuid.UUID, req *InviteRequest) (*models.Invitation, error) {
+	actor, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role == models.OrgRoleOwner && actor.Role != models.OrgRoleOwner {
+		return nil, ErrInsufficientRole
+	}
+
+	organization, err := s.orgRepo.FindByID(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	invitee, err := s.userRepo.FindByEmail(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if invitee != nil {
+		existing, err := s.membershipRepo.FindByOrganizationAndUser(organizationID, invitee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	// Invitation tokens have the same format as share tokens
+	token, tokenHash, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.Invitation{
+		OrganizationID: organizationID,
+		Email:          req.Email,
+		Role:           req.Role,
+		TokenHash:      tokenHash,
+		InvitedBy:      userID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+	if err := s.invitationRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	// An invitation nobody received can't be accepted, so don't keep it
+	if err := s.sendInvitation(organization, invitation, token); err != nil {
+		if deleteErr := s.invitationRepo.Delete(invitation.ID); deleteErr != nil {
+			return nil, fmt.Errorf("failed to send invitation: %w (and failed to remove it: %v)", err, deleteErr)
+		}
+		return nil, fmt.Errorf("failed to send invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// sendInvitation emails the invitee the link to accept an invitation
+func (s *OrganizationService) sendInvitation(organization *models.Organization, invitation *models.Invitation, token string) error {
+	link := s.frontendURL + "/invitations/accept?token=" + url.QueryEscape(token)
+	subject := fmt.Sprintf("You're invited to join %s", organization.Name)
+	body := fmt.Sprintf("You have been invited to join %s as %s.\n\n"+
+		"Sign in with this email address and open the link below to accept the invitation:\n\n%s\n\n"+
+		"The link expires on %s. If you didn't expect this invitation, you can ignore this email.\n",
+		organization.Name, invitation.Role, link, invitation.ExpiresAt.UTC().Format("January 2, 2006 15:04 UTC"))
+
+	return s.mailer.Send(invitation.Email, subject, body)
+}
+
+// ListInvitations retrieves the pending invitations of an organization. Admins and owners only.
+func (s *OrganizationService) ListInvitations(organizationID, userID uuid.UUID) ([]models.Invitation, error) {
+	if _, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.invitationRepo.FindPendingByOrganizationID(organizationID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation deletes an invitation so its token no longer works. Admins and owners only.
+func (s *OrganizationService) RevokeInvitation(organizationID, invitationID, userID uuid.UUID) error {
+	if _, err := requireOrgRole(s.membershipRepo, organizationID, userID, models.OrgRoleAdmin); err != nil {
+		return err
+	}
+
+	invitation, err := s.invitationRepo.FindByID(invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation == nil || invitation.OrganizationID != organizationID {
+		return ErrInvitationNotFound
+	}
+
+	if err := s.invitationRepo.Delete(invitationID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AcceptInvitation adds the user to the organization an invitation is for. The user
+// must be signed in with the email address the invitation was sent to.
+func (s *OrganizationService) AcceptInvitation(userID uuid.UUID, req *AcceptInvitationRequest) (*models.Organization, models.OrgRole, error) {
+	invitation, err := s.invitationRepo.FindByTokenHash(hashShareToken(req.Token))
+	if err != nil {
+		return nil, "", err
+	}
+	if invitation == nil {
+		return nil, "", ErrInvitationNotFound
+	}
+	if !invitation.IsPending(time.Now()) {
+		return nil, "", ErrInvitationExpired
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, "", ErrInvitationEmailMismatch
+	}
+
+	existing, err := s.membershipRepo.FindByOrganizationAndUser(invitation.OrganizationID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		return nil, "", ErrAlreadyMember
+	}
+
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		// Marking the invitation first makes a second accept of the same token fail
+		accepted, err := s.invitationRepo.WithTx(tx).MarkAccepted(invitation.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrInvitationExpired
+		}
+		return s.membershipRepo.WithTx(tx).Create(&models.Membership{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         userID,
+			Role:           invitation.Role,
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	organization, err := s.getOrganization(invitation.OrganizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	return organization, invitation.Role, nil
+}
+
+// getOrganization retrieves an organization by ID
+func (s *OrganizationService) getOrganization(id uuid.UUID) (*models.Organization, error) {
+	organization, err := s.orgRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if organization == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return organization, nil
+}
+
+// checkNotLastOwner fails if the organization has a single owner, who therefore can't
+// be demoted or removed
+func checkNotLastOwner(membershipRepo *repositories.MembershipRepository, organizationID uuid.UUID) error {
+	owners, err := membershipRepo.CountByRole(organizationID, models.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// requireOrgRole checks that the user belongs to the organization with at least the
+// minimum role. Organizations the user doesn't belong to are reported as missing.
+func requireOrgRole(membershipRepo *repositories.MembershipRepository, organizationID, userID uuid.UUID, minimum models.OrgRole) (*models.Membership, error) {
+	membership, err := membershipRepo.FindByOrganizationAndUser(organizationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	if !membership.Role.AtLeast(minimum) {
+		return nil, ErrInsufficientRole
+	}
+	return membership, nil
+}
+
+// resolveScope returns the workspace a request targets: the organization when
+// organizationID is set, checking the user's role there, otherwise their personal one
+func resolveScope(membershipRepo *repositories.MembershipRepository, userID uuid.UUID, organizationID *uuid.UUID, minimum models.OrgRole) (repositories.Scope, error) {
+	if organizationID != nil {
+		if _, err := requireOrgRole(membershipRepo, *organizationID, userID, minimum); err != nil {
+			return repositories.Scope{}, err
+		}
+	}
+	return repositories.ScopeOf(userID, organizationID), nil
+}
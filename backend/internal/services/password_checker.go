@@ -0,0 +1,70 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"strings"
+
+	"gin-quickstart/internal/models"
+)
+
+// commonPasswordsList holds frequently used passwords, one per line
+//
+//go:embed wordlists/common_passwords.txt
+var commonPasswordsList []byte
+
+// minRecommendedPassphraseLength is the length below which passphrases are flagged as short
+const minRecommendedPassphraseLength = 12
+
+// PasswordChecker flags weak passwords. Credentials are checked when their password
+// is saved, so health reports read the stored flags instead of decrypting passwords.
+type PasswordChecker struct {
+	commonPasswords map[string]bool
+	dictionaryWords map[string]bool
+}
+
+// NewPasswordChecker creates a new password checker using the embedded common password
+// list and the generator's word list
+func NewPasswordChecker(passwordGenerator *PasswordGenerator) *PasswordChecker {
+	c := &PasswordChecker{
+		commonPasswords: make(map[string]bool),
+		dictionaryWords: make(map[string]bool, len(passwordGenerator.words)),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(commonPasswordsList))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			c.commonPasswords[line] = true
+		}
+	}
+	for _, word := range passwordGenerator.words {
+		c.dictionaryWords[word] = true
+	}
+
+	return c
+}
+
+// Check reports whether the password of a network is short and whether it is a
+// dictionary password. Open networks have no password and raw hex keys are random
+// by nature, so neither is flagged.
+func (c *PasswordChecker) Check(password, ssid string, security models.SecurityType, keyFormat models.KeyFormat) (short, dictionary bool) {
+	if security == models.SecurityNone || keyFormat == models.KeyFormatHex {
+		return false, false
+	}
+	return len(password) < minRecommendedPassphraseLength, c.isDictionaryPassword(password, ssid)
+}
+
+// isDictionaryPassword reports whether a password is common, a single
+// dictionary word with decorations (e.g. "Sunshine1!"), or the SSID itself
+func (c *PasswordChecker) isDictionaryPassword(password, ssid string) bool {
+	lower := strings.ToLower(password)
+	if c.commonPasswords[lower] || strings.EqualFold(password, ssid) {
+		return true
+	}
+
+	core := strings.TrimFunc(lower, func(r rune) bool {
+		return r < 'a' || r > 'z'
+	})
+	return c.dictionaryWords[core] || c.commonPasswords[core]
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// effLargeWordlist is the EFF large diceware list (https://www.eff.org/dice), one "<roll>\t<word>" per line
+//
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlist []byte
+
+var (
+	ErrNoCharacterClasses = errors.New("at least one character class must be enabled")
+	ErrPasswordTooLong    = fmt.Errorf("passphrases are limited to %d words to fit in 63 characters", maxPassphraseWords)
+	ErrLengthTooShort     = errors.New("length is too short to include every enabled character class")
+)
+
+// PasswordMode defines how passwords are generated
+type PasswordMode string
+
+const (
+	PasswordModeRandom PasswordMode = "random" // Random characters from the enabled classes
+	PasswordModeWords  PasswordMode = "words"  // Diceware-style words joined by a separator
+)
+
+const (
+	lowercaseCharacters = "abcdefghijklmnopqrstuvwxyz"
+	uppercaseCharacters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitCharacters     = "0123456789"
+	symbolCharacters    = "!#$%&*+-=?@^_~"
+
+	// ambiguousCharacters are easily confused when read off a printed card
+	ambiguousCharacters = "0O1lI|"
+
+	defaultPasswordLength = 16
+	defaultWordCount      = 4
+
+	// maxPassphraseWords is the most words that always fit in the 63 character WPA limit:
+	// the longest EFF words have 9 characters, so 6 words, 5 separators and a digit take 60
+	maxPassphraseWords = 6
+)
+
+// PasswordPolicy controls password generation.
+// Ambiguous characters are avoided unless AllowAmbiguous is set. Passphrase words are
+// lowercase and read in context, so only capitalized initials and the number are filtered.
+type PasswordPolicy struct {
+	Mode           PasswordMode `json:"mode" binding:"omitempty,oneof=random words"`
+	Length         int          `json:"length" binding:"omitempty,min=5,max=63"`
+	Lowercase      bool         `json:"lowercase"`
+	Uppercase      bool         `json:"uppercase"`
+	Digits         bool         `json:"digits"`
+	Symbols        bool         `json:"symbols"`
+	Words          int          `json:"words" binding:"omitempty,min=3,max=6"`
+	Separator      string       `json:"separator" binding:"omitempty,max=1,printascii"`
+	Capitalize     bool         `json:"capitalize"`
+	IncludeNumber  bool         `json:"include_number"`
+	AllowAmbiguous bool         `json:"allow_ambiguous"`
+}
+
+// GeneratedPassword contains a generated password and its estimated strength
+type GeneratedPassword struct {
+	Password    string  `json:"password"`
+	EntropyBits float64 `json:"entropy_bits"`
+}
+
+// PasswordGenerator generates random passwords and passphrases
+type PasswordGenerator struct {
+	words []string
+
+	// capitalizableWords are the words whose capitalized initial isn't ambiguous
+	capitalizableWords []string
+}
+
+// NewPasswordGenerator creates a new password generator using the embedded word list
+func NewPasswordGenerator() *PasswordGenerator {
+	g := &PasswordGenerator{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(effLargeWordlist))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		word := fields[1]
+		g.words = append(g.words, word)
+		if !strings.ContainsAny(strings.ToUpper(word[:1]), ambiguousCharacters) {
+			g.capitalizableWords = append(g.capitalizableWords, word)
+		}
+	}
+
+	return g
+}
+
+// Generate creates a password according to the policy
+func (g *PasswordGenerator) Generate(policy PasswordPolicy) (*GeneratedPassword, error) {
+	if policy.Mode == PasswordModeWords {
+		return g.generateWords(policy)
+	}
+	return g.generateRandom(policy)
+}
+
+// generateRandom builds a password of random characters with at least one from each enabled class
+func (g *PasswordGenerator) generateRandom(policy PasswordPolicy) (*GeneratedPassword, error) {
+	length := policy.Length
+	if length == 0 {
+		length = defaultPasswordLength
+	}
+
+	// Default to letters and digits when no class was chosen
+	if !policy.Lowercase && !policy.Uppercase && !policy.Digits && !policy.Symbols {
+		policy.Lowercase, policy.Uppercase, policy.Digits = true, true, true
+	}
+
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{policy.Lowercase, lowercaseCharacters},
+		{policy.Uppercase, uppercaseCharacters},
+		{policy.Digits, digitCharacters},
+		{policy.Symbols, symbolCharacters},
+	} {
+		if !class.enabled {
+			continue
+		}
+		chars := class.chars
+		if !policy.AllowAmbiguous {
+			chars = removeCharacters(chars, ambiguousCharacters)
+		}
+		classes = append(classes, chars)
+	}
+	if len(classes) == 0 {
+		return nil, ErrNoCharacterClasses
+	}
+	if length < len(classes) {
+		return nil, fmt.Errorf("%w: it must be at least %d", ErrLengthTooShort, len(classes))
+	}
+
+	pool := strings.Join(classes, "")
+	password := make([]byte, 0, length)
+
+	// One guaranteed character per class, the rest from the combined pool
+	for _, class := range classes {
+		c, err := randomByte(class)
+		if err != nil {
+			return nil, err
+		}
+		password = append(password, c)
+	}
+	for len(password) < length {
+		c, err := randomByte(pool)
+		if err != nil {
+			return nil, err
+		}
+		password = append(password, c)
+	}
+
+	if err := shuffleBytes(password); err != nil {
+		return nil, err
+	}
+
+	return &GeneratedPassword{
+		Password:    string(password),
+		EntropyBits: roundEntropy(float64(length) * math.Log2(float64(len(pool)))),
+	}, nil
+}
+
+// generateWords builds a diceware-style passphrase from the embedded word list
+func (g *PasswordGenerator) generateWords(policy PasswordPolicy) (*GeneratedPassword, error) {
+	count := policy.Words
+	if count == 0 {
+		count = defaultWordCount
+	}
+	if count > maxPassphraseWords {
+		return nil, ErrPasswordTooLong
+	}
+
+	separator := policy.Separator
+	if separator == "" {
+		separator = "-"
+	}
+
+	words := g.words
+	if policy.Capitalize && !policy.AllowAmbiguous {
+		words = g.capitalizableWords
+	}
+
+	digits := digitCharacters
+	if !policy.AllowAmbiguous {
+		digits = removeCharacters(digits, ambiguousCharacters)
+	}
+
+	// Every combination fits in 63 characters, so no passphrase is rejected and the entropy is exact
+	entropy := float64(count) * math.Log2(float64(len(words)))
+	if policy.IncludeNumber {
+		entropy += math.Log2(float64(len(digits)))
+	}
+
+	parts := make([]string, count)
+	for i := range parts {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate random number: %w", err)
+		}
+		word := words[n.Int64()]
+		if policy.Capitalize {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		parts[i] = word
+	}
+
+	if policy.IncludeNumber {
+		digit, err := randomByte(digits)
+		if err != nil {
+			return nil, err
+		}
+		parts[len(parts)-1] += string(digit)
+	}
+
+	return &GeneratedPassword{
+		Password:    strings.Join(parts, separator),
+		EntropyBits: roundEntropy(entropy),
+	}, nil
+}
+
+// randomByte picks a uniformly random byte from chars
+func randomByte(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return chars[n.Int64()], nil
+}
+
+// shuffleBytes performs a Fisher-Yates shuffle using crypto/rand
+func shuffleBytes(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return fmt.Errorf("failed to generate random number: %w", err)
+		}
+		j := n.Int64()
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+// removeCharacters returns s without any of the characters in remove
+func removeCharacters(s, remove string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(remove, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// roundEntropy rounds an entropy estimate to one decimal place
+func roundEntropy(bits float64) float64 {
+	return math.Round(bits*10) / 10
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"gin-quickstart/internal/repositories"
+)
+
+// PurgeService permanently removes trashed users and WiFi credentials once
+// they have been in the trash longer than the retention period
+type PurgeService struct {
+	userRepo  *repositories.UserRepository
+	wifiRepo  *repositories.WifiRepository
+	retention time.Duration
+}
+
+// NewPurgeService creates a new purge service
+func NewPurgeService(userRepo *repositories.UserRepository, wifiRepo *repositories.WifiRepository, retentionDays int) *PurgeService {
+	return &PurgeService{
+		userRepo:  userRepo,
+		wifiRepo:  wifiRepo,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+// Run purges expired trash immediately and then every interval. It blocks, so start it in a goroutine.
+func (s *PurgeService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.purge()
+		<-ticker.C
+	}
+}
+
+// purge removes expired trash and logs what was removed
+func (s *PurgeService) purge() {
+	cutoff := time.Now().Add(-s.retention)
+
+	credentials, err := s.wifiRepo.PurgeDeleted(cutoff)
+	if err != nil {
+		log.Printf("Trash purge failed: %v", err)
+		return
+	}
+
+	users, err := s.userRepo.PurgeDeleted(cutoff)
+	if err != nil {
+		log.Printf("Trash purge failed: %v", err)
+		return
+	}
+
+	if credentials > 0 || users > 0 {
+		log.Printf("Trash purge removed %d WiFi credentials and %d users deleted before %s", credentials, users, cutoff.Format(time.RFC3339))
+	}
+}
@@ -2,11 +2,12 @@ package services
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"unicode/utf8"
 
 	"gin-quickstart/internal/models"
-
-	qrcode "github.com/skip2/go-qrcode"
+	"gin-quickstart/internal/qrcode"
 )
 
 // QRCodeService handles QR code generation
@@ -19,18 +20,24 @@ func NewQRCodeService() *QRCodeService {
 
 // GenerateWiFiQRCode generates a QR code for WiFi credentials
 // Format: WIFI:T:<security>;S:<ssid>;P:<password>;H:<hidden>;;
-func (s *QRCodeService) GenerateWiFiQRCode(ssid string, password string, security models.SecurityType, hidden bool) (string, error) {
+func (s *QRCodeService) GenerateWiFiQRCode(ssid string, password string, security models.SecurityType, keyFormat models.KeyFormat, hidden bool) (string, error) {
 	// Build WiFi QR code string according to specification
 	// Reference: https://github.com/zxing/zxing/wiki/Barcode-Contents#wi-fi-network-config-android-ios-11
-	wifiString := s.buildWiFiString(ssid, password, security, hidden)
+	wifiString := s.buildWiFiString(ssid, password, security, keyFormat, hidden)
 
 	// Generate QR code as PNG bytes
-	// Using 256x256 pixels with medium error correction
-	pngBytes, err := qrcode.Encode(wifiString, qrcode.Medium, 256)
+	// Using 256x256 pixels with medium error correction; non-ASCII payloads
+	// get a UTF-8 ECI designator so scanners decode them correctly
+	code, err := qrcode.Encode(wifiString, qrcode.Medium)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate QR code: %w", err)
 	}
 
+	pngBytes, err := code.PNG(256)
+	if err != nil {
+		return "", fmt.Errorf("failed to render QR code: %w", err)
+	}
+
 	// Encode to base64 for easy storage and transmission
 	base64String := base64.StdEncoding.EncodeToString(pngBytes)
 
@@ -38,10 +45,15 @@ func (s *QRCodeService) GenerateWiFiQRCode(ssid string, password string, securit
 }
 
 // buildWiFiString constructs the WiFi configuration string for QR code
-func (s *QRCodeService) buildWiFiString(ssid string, password string, security models.SecurityType, hidden bool) string {
+func (s *QRCodeService) buildWiFiString(ssid string, password string, security models.SecurityType, keyFormat models.KeyFormat, hidden bool) string {
 	// Escape special characters in SSID and password
-	escapedSSID := escapeWiFiString(ssid)
-	escapedPassword := escapeWiFiString(password)
+	escapedSSID := formatWiFiValue(ssid)
+	escapedPassword := formatWiFiValue(password)
+
+	// Raw hex keys are written as-is so scanners interpret them as hex
+	if keyFormat == models.KeyFormatHex {
+		escapedPassword = password
+	}
 
 	// Hidden flag: "true" if hidden, empty otherwise
 	hiddenFlag := ""
@@ -61,6 +73,42 @@ func (s *QRCodeService) buildWiFiString(ssid string, password string, security m
 	return fmt.Sprintf("WIFI:T:%s;S:%s;P:%s;H:%s;;", securityStr, escapedSSID, escapedPassword, hiddenFlag)
 }
 
+// formatWiFiValue encodes an SSID or password for the WIFI: payload.
+// Binary values (control characters or invalid UTF-8) are written as hex digits,
+// and text that is itself valid hex is enclosed in double quotes so scanners
+// don't decode it as hex.
+func formatWiFiValue(s string) string {
+	if isBinaryWiFiValue(s) {
+		return hex.EncodeToString([]byte(s))
+	}
+	if isHexString(s) {
+		return `"` + escapeWiFiString(s) + `"`
+	}
+	return escapeWiFiString(s)
+}
+
+// isBinaryWiFiValue reports whether a value can't be represented as printable text
+func isBinaryWiFiValue(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7F {
+			return true
+		}
+	}
+	return false
+}
+
+// isHexString reports whether s is a non-empty even-length string of hex digits
+func isHexString(s string) bool {
+	if s == "" || len(s)%2 != 0 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
 // escapeWiFiString escapes special characters in WiFi QR code strings
 // Special characters that need escaping: \ ; , : "
 func escapeWiFiString(s string) string {
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("plan limit reached")
+	ErrInvalidPlan   = errors.New("invalid plan")
+	ErrUserNotFound  = errors.New("user not found")
+)
+
+// exportWindow is the period the export limit applies to
+const exportWindow = 24 * time.Hour
+
+// PlanLimits holds the quotas of a plan. Zero means unlimited.
+type PlanLimits struct {
+	MaxCredentials   int `json:"max_credentials"`
+	MaxShareLinks    int `json:"max_share_links"`
+	MaxExportsPerDay int `json:"max_exports_per_day"`
+}
+
+// PlanInfo describes a plan and its limits
+type PlanInfo struct {
+	Plan   models.Plan `json:"plan"`
+	Limits PlanLimits  `json:"limits"`
+}
+
+// QuotaCounter is the usage of one quota. A limit of zero means unlimited.
+type QuotaCounter struct {
+	Used  int64 `json:"used"`
+	Limit int   `json:"limit"`
+}
+
+// QuotaUsage shows a user how much of their plan they use
+type QuotaUsage struct {
+	Plan         models.Plan  `json:"plan"`
+	Credentials  QuotaCounter `json:"credentials"`
+	ShareLinks   QuotaCounter `json:"share_links"`   // Links that can still be opened
+	ExportsToday QuotaCounter `json:"exports_today"` // Exports in the last 24 hours
+}
+
+// SetPlanRequest represents a request to change a user's plan
+type SetPlanRequest struct {
+	Plan models.Plan `json:"plan" binding:"required"`
+}
+
+// ExportContext carries who exports data and from where, for re-authentication, the
+// export quota and auditing
+type ExportContext struct {
+	UserID        uuid.UUID
+	TokenIssuedAt time.Time
+	IPAddress     string
+	UserAgent     string
+}
+
+// revealContext returns the context for re-authenticating the exporting user
+func (ec ExportContext) revealContext() RevealContext {
+	return RevealContext{
+		UserID:        ec.UserID,
+		TokenIssuedAt: ec.TokenIssuedAt,
+		IPAddress:     ec.IPAddress,
+		UserAgent:     ec.UserAgent,
+	}
+}
+
+// QuotaService enforces the per-plan limits on credentials, share links and exports
+type QuotaService struct {
+	userRepo  *repositories.UserRepository
+	orgRepo   *repositories.OrganizationRepository
+	wifiRepo  *repositories.WifiRepository
+	shareRepo *repositories.ShareLinkRepository
+	auditRepo *repositories.AuditLogRepository
+	plans     map[models.Plan]PlanLimits
+}
+
+// NewQuotaService creates a new quota service. Plans missing from plans are unlimited.
+func NewQuotaService(userRepo *repositories.UserRepository, orgRepo *repositories.OrganizationRepository, wifiRepo *repositories.WifiRepository, shareRepo *repositories.ShareLinkRepository, auditRepo *repositories.AuditLogRepository, plans map[models.Plan]PlanLimits) *QuotaService {
+	return &QuotaService{
+		userRepo:  userRepo,
+		orgRepo:   orgRepo,
+		wifiRepo:  wifiRepo,
+		shareRepo: shareRepo,
+		auditRepo: auditRepo,
+		plans:     plans,
+	}
+}
+
+// Plans lists every plan with its limits
+func (s *QuotaService) Plans() []PlanInfo {
+	plans := make([]PlanInfo, 0, len(models.Plans))
+	for _, plan := range models.Plans {
+		plans = append(plans, PlanInfo{Plan: plan, Limits: s.plans[plan]})
+	}
+	return plans
+}
+
+// Usage reports a user's plan and how much of each quota they use. Only personal
+// credentials count; organization credentials count against the organization's plan.
+func (s *QuotaService) Usage(userID uuid.UUID) (*QuotaUsage, error) {
+	plan, limits, err := s.limitsFor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.wifiRepo.CountByScope(repositories.ScopeOf(userID, nil))
+	if err != nil {
+		return nil, err
+	}
+	shareLinks, err := s.shareRepo.CountActiveByUserID(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	exports, err := s.countExports(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuotaUsage{
+		Plan:         plan,
+		Credentials:  QuotaCounter{Used: credentials, Limit: limits.MaxCredentials},
+		ShareLinks:   QuotaCounter{Used: shareLinks, Limit: limits.MaxShareLinks},
+		ExportsToday: QuotaCounter{Used: exports, Limit: limits.MaxExportsPerDay},
+	}, nil
+}
+
+// CheckCredentials returns ErrQuotaExceeded if that many more WiFi credentials don't fit
+// in the scope. Personal credentials count against the user's plan and an organization's
+// credentials against the organization's plan; credentials in the trash don't count.
+// It is for checks before any work is done, such as dry runs; the writes themselves must
+// call CheckCredentialsTx.
+func (s *QuotaService) CheckCredentials(scope repositories.Scope, adding int) error {
+	return s.checkCredentials(s.userRepo, s.orgRepo, s.wifiRepo, scope, adding, false)
+}
+
+// CheckCredentialsTx is CheckCredentials inside the transaction that adds the credentials.
+// It locks the user or organization row until the transaction ends, so concurrent
+// requests can't both pass the check and exceed the limit together.
+func (s *QuotaService) CheckCredentialsTx(tx *gorm.DB, scope repositories.Scope, adding int) error {
+	return s.checkCredentials(s.userRepo.WithTx(tx), s.orgRepo.WithTx(tx), s.wifiRepo.WithTx(tx), scope, adding, true)
+}
+
+func (s *QuotaService) checkCredentials(userRepo *repositories.UserRepository, orgRepo *repositories.OrganizationRepository, wifiRepo *repositories.WifiRepository, scope repositories.Scope, adding int, lock bool) error {
+	var plan models.Plan
+	owner := "the"
+	if scope.IsOrganization() {
+		findOrganization := orgRepo.FindByID
+		if lock {
+			findOrganization = orgRepo.FindByIDForUpdate
+		}
+		organization, err := findOrganization(*scope.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if organization == nil {
+			return ErrOrganizationNotFound
+		}
+		plan, owner = organization.Plan, "the organization's"
+	} else {
+		findUser := userRepo.FindByID
+		if lock {
+			findUser = userRepo.FindByIDForUpdate
+		}
+		user, err := findUser(scope.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+		plan = user.Plan
+	}
+
+	limits := s.plans[plan]
+	if limits.MaxCredentials == 0 {
+		return nil
+	}
+
+	count, err := wifiRepo.CountByScope(scope)
+	if err != nil {
+		return err
+	}
+	if count+int64(adding) > int64(limits.MaxCredentials) {
+		return fmt.Errorf("%w: %s %s plan allows %d WiFi credentials", ErrQuotaExceeded, owner, plan, limits.MaxCredentials)
+	}
+	return nil
+}
+
+// CheckShareLinks returns ErrQuotaExceeded if the user can't create another share link.
+// Revoked, expired and used up links don't count.
+func (s *QuotaService) CheckShareLinks(userID uuid.UUID) error {
+	plan, limits, err := s.limitsFor(userID)
+	if err != nil {
+		return err
+	}
+	if limits.MaxShareLinks == 0 {
+		return nil
+	}
+
+	count, err := s.shareRepo.CountActiveByUserID(userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if count >= int64(limits.MaxShareLinks) {
+		return fmt.Errorf("%w: the %s plan allows %d active share links", ErrQuotaExceeded, plan, limits.MaxShareLinks)
+	}
+	return nil
+}
+
+// RecordExport counts an export against the user's daily limit, returning ErrQuotaExceeded
+// once it is used up, and writes an audit log entry for it
+func (s *QuotaService) RecordExport(ec ExportContext, resourceType string, resourceID *uuid.UUID) error {
+	plan, limits, err := s.limitsFor(ec.UserID)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxExportsPerDay > 0 {
+		count, err := s.countExports(ec.UserID)
+		if err != nil {
+			return err
+		}
+		if count >= int64(limits.MaxExportsPerDay) {
+			return fmt.Errorf("%w: the %s plan allows %d exports per day", ErrQuotaExceeded, plan, limits.MaxExportsPerDay)
+		}
+	}
+
+	return s.auditRepo.Create(&models.AuditLog{
+		UserID:       ec.UserID,
+		Action:       models.AuditActionExport,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    ec.IPAddress,
+		UserAgent:    truncate(ec.UserAgent, 500),
+	})
+}
+
+// SetPlan changes a user's plan (admin only). Existing data above the new limits is
+// kept, but the user can't add more until they are back under them.
+func (s *QuotaService) SetPlan(userID uuid.UUID, plan models.Plan) (*models.User, error) {
+	if !models.IsValidPlan(string(plan)) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPlan, plan)
+	}
+
+	if err := s.userRepo.UpdatePlan(userID, plan); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// SetOrganizationPlan changes an organization's plan (admin only). Credentials above the
+// new limit are kept, but members can't add more until the organization is back under it.
+func (s *QuotaService) SetOrganizationPlan(organizationID uuid.UUID, plan models.Plan) (*models.Organization, error) {
+	if !models.IsValidPlan(string(plan)) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPlan, plan)
+	}
+
+	if err := s.orgRepo.UpdatePlan(organizationID, plan); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	organization, err := s.orgRepo.FindByID(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return organization, nil
+}
+
+// limitsFor looks up the plan of a user and its limits
+func (s *QuotaService) limitsFor(userID uuid.UUID) (models.Plan, PlanLimits, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return "", PlanLimits{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return "", PlanLimits{}, ErrUserNotFound
+	}
+	return user.Plan, s.plans[user.Plan], nil
+}
+
+// countExports returns how many exports a user made within the export window
+func (s *QuotaService) countExports(userID uuid.UUID) (int64, error) {
+	return s.auditRepo.CountByUserAndActionSince(userID, models.AuditActionExport, time.Now().Add(-exportWindow))
+}
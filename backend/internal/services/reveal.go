@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrReauthenticationRequired = errors.New("account password or a recently issued token is required")
+
+// RevealPasswordRequest represents a request to reveal a stored WiFi password.
+// Password may be omitted when the caller's token was issued recently.
+type RevealPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// RevealPasswordResponse contains the plaintext password, notes and custom fields of a credential
+type RevealPasswordResponse struct {
+	ID           uuid.UUID            `json:"id"`
+	SSID         string               `json:"ssid"`
+	SecurityType models.SecurityType  `json:"security_type"`
+	KeyFormat    models.KeyFormat     `json:"key_format"`
+	Password     string               `json:"password"`
+	Notes        string               `json:"notes,omitempty"`
+	CustomFields []models.CustomField `json:"custom_fields,omitempty"`
+}
+
+// RevealContext carries who is asking and from where, for re-authentication and auditing
+type RevealContext struct {
+	UserID        uuid.UUID
+	IsAdmin       bool
+	TokenIssuedAt time.Time
+	IPAddress     string
+	UserAgent     string
+}
+
+// RevealService exposes stored WiFi passwords after re-authentication and records every access
+type RevealService struct {
+	wifiService  *WifiService
+	authService  *AuthService
+	auditService *AuditService
+	reauthWindow time.Duration
+}
+
+// NewRevealService creates a new reveal service. Tokens issued less than
+// reauthWindowMinutes ago count as fresh and skip the password prompt.
+func NewRevealService(wifiService *WifiService, authService *AuthService, auditService *AuditService, reauthWindowMinutes int) *RevealService {
+	return &RevealService{
+		wifiService:  wifiService,
+		authService:  authService,
+		auditService: auditService,
+		reauthWindow: time.Duration(reauthWindowMinutes) * time.Minute,
+	}
+}
+
+// Reveal decrypts and returns the password, notes and custom fields of a WiFi credential
+func (s *RevealService) Reveal(id uuid.UUID, req *RevealPasswordRequest, rc RevealContext) (*RevealPasswordResponse, error) {
+	credential, err := s.authorizeReveal(id, req, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := s.wifiService.DecryptPassword(credential.EncryptedPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	notes, fields, err := s.wifiService.DecryptNotes(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never hand out the password without an audit entry
+	if err := s.record(models.AuditActionPasswordReveal, "wifi_credential", &credential.ID, rc); err != nil {
+		return nil, err
+	}
+
+	return &RevealPasswordResponse{
+		ID:           credential.ID,
+		SSID:         credential.SSID,
+		SecurityType: credential.SecurityType,
+		KeyFormat:    credential.KeyFormat,
+		Password:     password,
+		Notes:        notes,
+		CustomFields: fields,
+	}, nil
+}
+
+// RevealForPass returns a WiFi credential with its decrypted password for building a
+// Wallet pass, with the same checks and audit entry as Reveal
+func (s *RevealService) RevealForPass(id uuid.UUID, req *RevealPasswordRequest, rc RevealContext) (*models.WifiCredential, string, error) {
+	credential, err := s.authorizeReveal(id, req, rc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	password, err := s.wifiService.DecryptPassword(credential.EncryptedPassword)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	if err := s.record(models.AuditActionPasswordReveal, "wifi_credential", &credential.ID, rc); err != nil {
+		return nil, "", err
+	}
+	return credential, password, nil
+}
+
+// authorizeReveal returns the credential once the caller holds the reveal permission
+// on it and has re-authenticated. A wrong account password is audited.
+func (s *RevealService) authorizeReveal(id uuid.UUID, req *RevealPasswordRequest, rc RevealContext) (*models.WifiCredential, error) {
+	// Check ownership first so other users' credentials don't leave an audit trail
+	credential, err := s.wifiService.GetWithPermission(id, rc.UserID, rc.IsAdmin, models.GrantReveal)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reauthenticate(req, rc); err != nil {
+		if errors.Is(err, ErrInvalidPassword) {
+			if auditErr := s.record(models.AuditActionPasswordRevealDenied, "wifi_credential", &credential.ID, rc); auditErr != nil {
+				return nil, auditErr
+			}
+		}
+		return nil, err
+	}
+	return credential, nil
+}
+
+// Reauthenticate checks the caller the same way a reveal does, for endpoints that hand
+// out many passwords at once. A wrong account password is audited against resourceType.
+func (s *RevealService) Reauthenticate(req *RevealPasswordRequest, rc RevealContext, resourceType string) error {
+	err := s.reauthenticate(req, rc)
+	if errors.Is(err, ErrInvalidPassword) {
+		if auditErr := s.record(models.AuditActionPasswordRevealDenied, resourceType, nil, rc); auditErr != nil {
+			return auditErr
+		}
+	}
+	return err
+}
+
+// BulkExport re-authenticates the user like Reveal and then exports the credentials of
+// a bulk export request, recording a reveal for every credential handed out
+func (s *RevealService) BulkExport(ec ExportContext, isAdmin bool, req *BulkWifiRequest) (*BulkWifiResult, error) {
+	rc := ec.revealContext()
+	rc.IsAdmin = isAdmin
+	if err := s.Reauthenticate(&RevealPasswordRequest{Password: req.Password}, rc, "bulk_export"); err != nil {
+		return nil, err
+	}
+
+	return s.wifiService.bulk(ec, isAdmin, req, func(id uuid.UUID) error {
+		return s.record(models.AuditActionPasswordReveal, "wifi_credential", &id, rc)
+	})
+}
+
+// reauthenticate accepts either the account password or a token issued within the re-auth window
+func (s *RevealService) reauthenticate(req *RevealPasswordRequest, rc RevealContext) error {
+	if req.Password != "" {
+		return s.authService.VerifyPassword(rc.UserID, req.Password)
+	}
+
+	if rc.TokenIssuedAt.IsZero() || time.Since(rc.TokenIssuedAt) > s.reauthWindow {
+		return ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// record writes a reveal audit log entry
+func (s *RevealService) record(action models.AuditAction, resourceType string, resourceID *uuid.UUID, rc RevealContext) error {
+	return s.auditService.Record(&models.AuditLog{
+		UserID:       rc.UserID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		IPAddress:    rc.IPAddress,
+		UserAgent:    truncate(rc.UserAgent, 500),
+	})
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"gin-quickstart/internal/repositories"
+)
+
+// rotationBatchSize caps how many credentials are rotated per run; the rest wait for the next one
+const rotationBatchSize = 100
+
+// RotationService rotates the passwords of WiFi credentials that have a rotation schedule
+type RotationService struct {
+	wifiRepo    *repositories.WifiRepository
+	wifiService *WifiService
+}
+
+// NewRotationService creates a new rotation service
+func NewRotationService(wifiRepo *repositories.WifiRepository, wifiService *WifiService) *RotationService {
+	return &RotationService{
+		wifiRepo:    wifiRepo,
+		wifiService: wifiService,
+	}
+}
+
+// Run rotates due credentials immediately and then every interval. It blocks, so start it in a goroutine.
+func (s *RotationService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.rotateDue()
+		<-ticker.C
+	}
+}
+
+// rotateDue rotates every credential whose next rotation has passed and logs the outcome
+func (s *RotationService) rotateDue() {
+	now := time.Now()
+
+	credentials, err := s.wifiRepo.FindDueForRotation(now, rotationBatchSize)
+	if err != nil {
+		log.Printf("Password rotation failed: %v", err)
+		return
+	}
+
+	rotated := 0
+	for _, credential := range credentials {
+		if err := s.wifiService.Rotate(credential.ID, now); err != nil {
+			log.Printf("Password rotation failed for WiFi credential %s: %v", credential.ID, err)
+			continue
+		}
+		rotated++
+	}
+
+	if rotated > 0 {
+		log.Printf("Password rotation rotated %d WiFi credentials", rotated)
+	}
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrShareLinkNotFound    = errors.New("share link not found")
+	ErrShareLinkUnavailable = errors.New("share link has expired, been revoked or reached its view limit")
+	ErrSharePinRequired     = errors.New("this share link requires a PIN")
+	ErrInvalidSharePin      = errors.New("invalid PIN")
+	ErrInvalidShareExpiry   = errors.New("expires_at must be in the future")
+	ErrInvalidKioskLink     = errors.New("kiosk links can't have a PIN, a view limit or a visible password")
+)
+
+const (
+	// shareTokenBytes is the amount of randomness in a share token
+	shareTokenBytes = 32
+
+	// maxSharePinAttempts is how many wrong PINs revoke a share link
+	maxSharePinAttempts = 5
+)
+
+// ShareService manages share links and serves shared credentials to anonymous viewers
+type ShareService struct {
+	shareRepo    *repositories.ShareLinkRepository
+	wifiRepo     *repositories.WifiRepository
+	wifiService  *WifiService
+	quotaService *QuotaService
+	events       *CredentialEvents
+	publicURL    string
+}
+
+// NewShareService creates a new share service. publicURL is the base URL share links point to.
+func NewShareService(shareRepo *repositories.ShareLinkRepository, wifiRepo *repositories.WifiRepository, wifiService *WifiService, quotaService *QuotaService, events *CredentialEvents, publicURL string) *ShareService {
+	return &ShareService{
+		shareRepo:    shareRepo,
+		wifiRepo:     wifiRepo,
+		wifiService:  wifiService,
+		quotaService: quotaService,
+		events:       events,
+		publicURL:    publicURL,
+	}
+}
+
+// CreateShareLinkRequest represents a request to share a WiFi credential
+type CreateShareLinkRequest struct {
+	Type         models.ShareLinkType `json:"type" binding:"omitempty,oneof=link kiosk"` // Defaults to link
+	ExpiresAt    *time.Time           `json:"expires_at"`
+	MaxViews     *int                 `json:"max_views" binding:"omitempty,min=1"`
+	PIN          string               `json:"pin" binding:"omitempty,numeric,min=4,max=8"`
+	ShowPassword bool                 `json:"show_password"` // Show the password as text, not just inside the QR code
+}
+
+// CreateShareLinkResponse returns a new share link. The token can't be retrieved again.
+type CreateShareLinkResponse struct {
+	*models.PublicShareLink
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// ShareLinkInfo describes a share link to a viewer before it is opened
+type ShareLinkInfo struct {
+	RequiresPin    bool       `json:"requires_pin"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	ViewsRemaining *int       `json:"views_remaining"`
+}
+
+// ViewShareLinkRequest carries the PIN of a protected share link
+type ViewShareLinkRequest struct {
+	PIN string `json:"pin" form:"pin"`
+}
+
+// SharedCredential is what an anonymous viewer of a share link sees
+type SharedCredential struct {
+	SSID           string              `json:"ssid"`
+	SecurityType   models.SecurityType `json:"security_type"`
+	IsHidden       bool                `json:"is_hidden"`
+	QRCodeData     string              `json:"qr_code_data"`
+	Password       string              `json:"password,omitempty"` // Only when the link allows it
+	ExpiresAt      *time.Time          `json:"expires_at"`
+	ViewsRemaining *int                `json:"views_remaining"`
+}
+
+// Create creates a share link for a credential the user may access
+func (s *ShareService) Create(credentialID uuid.UUID, userID uuid.UUID, isAdmin bool, req *CreateShareLinkRequest) (*CreateShareLinkResponse, error) {
+	if _, err := s.wifiService.GetAsOwner(credentialID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidShareExpiry
+	}
+
+	// Kiosk screens stay on for anyone walking by, so they only ever show the QR code
+	linkType := req.Type
+	if linkType == "" {
+		linkType = models.ShareLinkTypeLink
+	}
+	if linkType == models.ShareLinkTypeKiosk && (req.PIN != "" || req.MaxViews != nil || req.ShowPassword) {
+		return nil, ErrInvalidKioskLink
+	}
+
+	if err := s.quotaService.CheckShareLinks(userID); err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.ShareLink{
+		CredentialID: credentialID,
+		UserID:       userID,
+		Type:         linkType,
+		TokenHash:    tokenHash,
+		ShowPassword: req.ShowPassword,
+		ExpiresAt:    req.ExpiresAt,
+		MaxViews:     req.MaxViews,
+	}
+
+	if req.PIN != "" {
+		pinHash, err := bcrypt.GenerateFromPassword([]byte(req.PIN), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash PIN: %w", err)
+		}
+		link.PinHash = string(pinHash)
+	}
+
+	if err := s.shareRepo.Create(link); err != nil {
+		return nil, err
+	}
+
+	return &CreateShareLinkResponse{
+		PublicShareLink: link.ToPublic(),
+		Token:           token,
+		URL:             s.publicURL + "/" + string(linkType) + "/" + token,
+	}, nil
+}
+
+// ListByCredential retrieves the share links of a credential the user may access
+func (s *ShareService) ListByCredential(credentialID uuid.UUID, userID uuid.UUID, isAdmin bool) ([]models.ShareLink, error) {
+	if _, err := s.wifiService.GetAsOwner(credentialID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	links, err := s.shareRepo.FindByCredentialID(credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share links: %w", err)
+	}
+	return links, nil
+}
+
+// Revoke revokes a share link of a credential the user may access. Kiosk screens
+// showing the link are told right away.
+func (s *ShareService) Revoke(credentialID, linkID uuid.UUID, userID uuid.UUID, isAdmin bool) error {
+	if _, err := s.wifiService.GetAsOwner(credentialID, userID, isAdmin); err != nil {
+		return err
+	}
+
+	link, err := s.shareRepo.FindByID(linkID)
+	if err != nil {
+		return fmt.Errorf("failed to get share link: %w", err)
+	}
+	if link == nil || link.CredentialID != credentialID {
+		return ErrShareLinkNotFound
+	}
+
+	if err := s.shareRepo.Revoke(linkID, time.Now()); err != nil {
+		return err
+	}
+	s.events.Publish(credentialID)
+	return nil
+}
+
+// Lookup describes an active share link without counting a view
+func (s *ShareService) Lookup(token string) (*ShareLinkInfo, error) {
+	link, _, err := s.findActive(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareLinkInfo{
+		RequiresPin:    link.PinHash != "",
+		ExpiresAt:      link.ExpiresAt,
+		ViewsRemaining: link.ViewsRemaining(),
+	}, nil
+}
+
+// View opens a share link, checking its PIN and counting the view
+func (s *ShareService) View(token string, req *ViewShareLinkRequest) (*SharedCredential, error) {
+	now := time.Now()
+
+	link, credential, err := s.findActive(token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.PinHash != "" {
+		if req.PIN == "" {
+			return nil, ErrSharePinRequired
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PinHash), []byte(req.PIN)); err != nil {
+			if err := s.shareRepo.RecordFailedPin(link.ID, maxSharePinAttempts, now); err != nil {
+				return nil, err
+			}
+			return nil, ErrInvalidSharePin
+		}
+	}
+
+	// Another viewer may have used up the last view since the link was loaded
+	counted, err := s.shareRepo.RecordView(link.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !counted {
+		return nil, ErrShareLinkUnavailable
+	}
+	link.ViewCount++
+
+	shared := &SharedCredential{
+		SSID:           credential.SSID,
+		SecurityType:   credential.SecurityType,
+		IsHidden:       credential.IsHidden,
+		QRCodeData:     credential.QRCodeData,
+		ExpiresAt:      link.ExpiresAt,
+		ViewsRemaining: link.ViewsRemaining(),
+	}
+
+	if link.ShowPassword {
+		password, err := s.wifiService.DecryptPassword(credential.EncryptedPassword)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt password: %w", err)
+		}
+		shared.Password = password
+	}
+
+	return shared, nil
+}
+
+// findActive loads a share link by token together with its credential, failing if either
+// is gone or the link can no longer be opened. Kiosk links only open on the kiosk page.
+func (s *ShareService) findActive(token string, now time.Time) (*models.ShareLink, *models.WifiCredential, error) {
+	link, err := s.shareRepo.FindByTokenHash(hashShareToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if link == nil || link.Type == models.ShareLinkTypeKiosk {
+		return nil, nil, ErrShareLinkNotFound
+	}
+	if link.Status(now) != models.ShareLinkActive {
+		return nil, nil, ErrShareLinkUnavailable
+	}
+
+	// Trashed credentials aren't found, so their links stop working until they are restored
+	credential, err := s.wifiRepo.FindByID(link.CredentialID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get WiFi credential: %w", err)
+	}
+	if credential == nil {
+		return nil, nil, ErrShareLinkNotFound
+	}
+	if credential.ValidityStatus(now) != models.ValidityActive {
+		return nil, nil, ErrShareLinkUnavailable
+	}
+
+	return link, credential, nil
+}
+
+// generateShareToken returns a random URL-safe token and the hash stored in its place
+func generateShareToken() (string, string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashShareToken(token), nil
+}
+
+// hashShareToken hashes a share token for storage and lookup. Tokens carry enough
+// randomness that an unsalted hash can't be reversed.
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
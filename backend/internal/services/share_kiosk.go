@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// KioskFrame is what a kiosk screen shows for its credential
+type KioskFrame struct {
+	SSID         string                `json:"ssid"`
+	SecurityType models.SecurityType   `json:"security_type"`
+	IsHidden     bool                  `json:"is_hidden"`
+	QRCodeData   string                `json:"qr_code_data,omitempty"` // Only while the credential is valid
+	Status       models.ValidityStatus `json:"status"`
+	ValidFrom    *time.Time            `json:"valid_from"`
+	ValidUntil   *time.Time            `json:"valid_until"`
+	Version      int                   `json:"version"` // Changes whenever the credential is edited or rotated
+}
+
+// LookupKiosk checks that token belongs to an active kiosk link, without counting a view
+func (s *ShareService) LookupKiosk(token string) (*models.ShareLink, error) {
+	link, err := s.shareRepo.FindByTokenHash(hashShareToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.Type != models.ShareLinkTypeKiosk {
+		return nil, ErrShareLinkNotFound
+	}
+	if link.Status(time.Now()) != models.ShareLinkActive {
+		return nil, ErrShareLinkUnavailable
+	}
+	return link, nil
+}
+
+// OpenKiosk starts showing a kiosk link. It counts a view per connection, so the owner
+// can see when a screen last connected, and subscribes to changes of the credential.
+// The caller must call the returned function once the screen disconnects.
+func (s *ShareService) OpenKiosk(token string) (*models.ShareLink, <-chan struct{}, func(), error) {
+	link, err := s.LookupKiosk(token)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	counted, err := s.shareRepo.RecordView(link.ID, time.Now())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !counted {
+		return nil, nil, nil, ErrShareLinkUnavailable
+	}
+
+	changes, unsubscribe := s.events.Subscribe(link.CredentialID)
+	return link, changes, unsubscribe, nil
+}
+
+// KioskFrame loads the current frame of a kiosk link. Outside the credential's validity
+// window the frame has no QR code. It fails once the link has been revoked or has expired,
+// and while the credential is in the trash.
+func (s *ShareService) KioskFrame(linkID uuid.UUID, now time.Time) (*KioskFrame, error) {
+	link, err := s.shareRepo.FindByID(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if link == nil {
+		return nil, ErrShareLinkNotFound
+	}
+	if link.Status(now) != models.ShareLinkActive {
+		return nil, ErrShareLinkUnavailable
+	}
+
+	credential, err := s.wifiRepo.FindByID(link.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WiFi credential: %w", err)
+	}
+	if credential == nil {
+		return nil, ErrShareLinkUnavailable
+	}
+
+	frame := &KioskFrame{
+		SSID:         credential.SSID,
+		SecurityType: credential.SecurityType,
+		IsHidden:     credential.IsHidden,
+		Status:       credential.ValidityStatus(now),
+		ValidFrom:    credential.ValidFrom,
+		ValidUntil:   credential.ValidUntil,
+		Version:      credential.Version,
+	}
+	if frame.Status == models.ValidityActive {
+		frame.QRCodeData = credential.QRCodeData
+	}
+	return frame, nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTagNotFound  = errors.New("tag not found")
+	ErrTagNameTaken = errors.New("a tag with this name already exists")
+)
+
+// TagService handles tag business logic
+type TagService struct {
+	tagRepo        *repositories.TagRepository
+	wifiRepo       *repositories.WifiRepository
+	membershipRepo *repositories.MembershipRepository
+	transactor     *repositories.Transactor
+}
+
+// NewTagService creates a new tag service
+func NewTagService(tagRepo *repositories.TagRepository, wifiRepo *repositories.WifiRepository, membershipRepo *repositories.MembershipRepository, transactor *repositories.Transactor) *TagService {
+	return &TagService{
+		tagRepo:        tagRepo,
+		wifiRepo:       wifiRepo,
+		membershipRepo: membershipRepo,
+		transactor:     transactor,
+	}
+}
+
+// TagRequest represents a request to create or update a tag
+type TagRequest struct {
+	Name           string     `json:"name" binding:"required,min=1,max=50"`
+	Color          string     `json:"color" binding:"omitempty,hexcolor,len=7"`
+	OrganizationID *uuid.UUID `json:"organization_id"` // Create an organization tag; ignored on update
+}
+
+// BulkTagRequest represents a request to add and remove tags on many credentials at once
+type BulkTagRequest struct {
+	OrganizationID *uuid.UUID  `json:"organization_id"` // Credentials and tags of this organization instead of personal ones
+	CredentialIDs  []uuid.UUID `json:"credential_ids" binding:"required,min=1,max=500"`
+	Add            []uuid.UUID `json:"add"`
+	Remove         []uuid.UUID `json:"remove"`
+}
+
+// BulkTagResponse reports how many credentials a bulk tag change touched
+type BulkTagResponse struct {
+	Updated int `json:"updated"`
+}
+
+// Create creates a new tag for a user, or for an organization the user is at least a member of
+func (s *TagService) Create(userID uuid.UUID, req *TagRequest) (*models.Tag, error) {
+	scope, err := resolveScope(s.membershipRepo, userID, req.OrganizationID, models.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(scope, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{
+		UserID:         userID,
+		OrganizationID: req.OrganizationID,
+		Name:           name,
+		Color:          req.Color,
+	}
+	if err := s.tagRepo.Create(tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return tag, nil
+}
+
+// GetAll retrieves the personal tags of a user, or the tags of an organization they belong to
+func (s *TagService) GetAll(userID uuid.UUID, organizationID *uuid.UUID) ([]models.Tag, error) {
+	scope, err := resolveScope(s.membershipRepo, userID, organizationID, models.OrgRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.FindByScope(scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Update renames or recolors a tag
+func (s *TagService) Update(id uuid.UUID, userID uuid.UUID, req *TagRequest) (*models.Tag, error) {
+	tag, err := s.getEditable(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(repositories.ScopeOf(tag.UserID, tag.OrganizationID), name, tag.ID); err != nil {
+		return nil, err
+	}
+
+	tag.Name = name
+	tag.Color = req.Color
+	if err := s.tagRepo.Update(tag); err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+	return tag, nil
+}
+
+// Delete deletes a tag and removes it from all credentials
+func (s *TagService) Delete(id uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.getEditable(id, userID); err != nil {
+		return err
+	}
+	if err := s.tagRepo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// BulkAssign adds and removes tags on several credentials in one transaction.
+// Every credential and tag must belong to the user, or to the organization in req.
+func (s *TagService) BulkAssign(userID uuid.UUID, req *BulkTagRequest) (*BulkTagResponse, error) {
+	scope, err := resolveScope(s.membershipRepo, userID, req.OrganizationID, models.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialIDs := uniqueIDs(req.CredentialIDs)
+	credentials, err := s.wifiRepo.FindByIDs(credentialIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WiFi credentials: %w", err)
+	}
+	if len(credentials) != len(credentialIDs) {
+		return nil, ErrWifiNotFound
+	}
+	for _, credential := range credentials {
+		if !scope.Contains(credential.UserID, credential.OrganizationID) {
+			return nil, ErrUnauthorizedAccess
+		}
+	}
+
+	add, err := s.scopedTagIDs(scope, req.Add)
+	if err != nil {
+		return nil, err
+	}
+	remove, err := s.scopedTagIDs(scope, req.Remove)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		tagRepo := s.tagRepo.WithTx(tx)
+		if err := tagRepo.Unassign(credentialIDs, remove); err != nil {
+			return err
+		}
+		return tagRepo.Assign(credentialIDs, add)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tags: %w", err)
+	}
+
+	return &BulkTagResponse{Updated: len(credentialIDs)}, nil
+}
+
+// scopedTagIDs deduplicates tag IDs and checks that every tag lies in the scope
+func (s *TagService) scopedTagIDs(scope repositories.Scope, ids []uuid.UUID) ([]uuid.UUID, error) {
+	ids = uniqueIDs(ids)
+	tags, err := s.tagRepo.FindByIDs(scope, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	if len(tags) != len(ids) {
+		return nil, ErrTagNotFound
+	}
+	return ids, nil
+}
+
+// getEditable retrieves a tag the user may change: one of their personal tags, or a tag
+// of an organization where they are at least a member
+func (s *TagService) getEditable(id uuid.UUID, userID uuid.UUID) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	// Tags outside the user's workspaces are reported as missing rather than forbidden
+	if tag == nil {
+		return nil, ErrTagNotFound
+	}
+	if tag.OrganizationID == nil {
+		if tag.UserID != userID {
+			return nil, ErrTagNotFound
+		}
+		return tag, nil
+	}
+	if _, err := requireOrgRole(s.membershipRepo, *tag.OrganizationID, userID, models.OrgRoleMember); err != nil {
+		if errors.Is(err, ErrOrganizationNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+// checkNameAvailable ensures no other tag in the scope has this name
+func (s *TagService) checkNameAvailable(scope repositories.Scope, name string, exceptID uuid.UUID) error {
+	existing, err := s.tagRepo.FindByName(scope, name)
+	if err != nil {
+		return fmt.Errorf("failed to check tag name: %w", err)
+	}
+	if existing != nil && existing.ID != exceptID {
+		return ErrTagNameTaken
+	}
+	return nil
+}
+
+// uniqueIDs returns ids without duplicates, keeping the first occurrence
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"gin-quickstart/internal/models"
+
+	"go.mozilla.org/pkcs7"
+)
+
+var (
+	ErrPassSigningNotConfigured = errors.New("Apple Wallet pass signing is not configured")
+)
+
+// PassConfig holds the Apple Wallet pass identity and signing material locations
+type PassConfig struct {
+	TypeIdentifier   string
+	TeamIdentifier   string
+	OrganizationName string
+	CertPath         string
+	KeyPath          string
+	WWDRCertPath     string
+}
+
+// PassService builds signed Apple Wallet (.pkpass) bundles for WiFi credentials
+type PassService struct {
+	qrCodeService *QRCodeService
+	config        PassConfig
+	signerCert    *x509.Certificate
+	signerKey     crypto.PrivateKey
+	wwdrCert      *x509.Certificate
+}
+
+// NewPassService creates a new pass service.
+// Signing material is loaded once at startup; when no certificate is configured
+// the service is created but GeneratePass returns ErrPassSigningNotConfigured.
+func NewPassService(qrCodeService *QRCodeService, config PassConfig) (*PassService, error) {
+	service := &PassService{
+		qrCodeService: qrCodeService,
+		config:        config,
+	}
+
+	if config.CertPath == "" && config.KeyPath == "" {
+		return service, nil
+	}
+
+	if config.TypeIdentifier == "" || config.TeamIdentifier == "" {
+		return nil, errors.New("pass type identifier and team identifier are required for pass signing")
+	}
+
+	signerCert, err := loadCertificate(config.CertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pass certificate: %w", err)
+	}
+
+	signerKey, err := loadPrivateKey(config.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pass private key: %w", err)
+	}
+
+	wwdrCert, err := loadCertificate(config.WWDRCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load WWDR certificate: %w", err)
+	}
+
+	service.signerCert = signerCert
+	service.signerKey = signerKey
+	service.wwdrCert = wwdrCert
+
+	return service, nil
+}
+
+// IsConfigured reports whether passes can be signed
+func (s *PassService) IsConfigured() bool {
+	return s.signerCert != nil
+}
+
+// passJSON mirrors the subset of the pass.json schema used for WiFi passes
+type passJSON struct {
+	FormatVersion      int           `json:"formatVersion"`
+	PassTypeIdentifier string        `json:"passTypeIdentifier"`
+	SerialNumber       string        `json:"serialNumber"`
+	TeamIdentifier     string        `json:"teamIdentifier"`
+	OrganizationName   string        `json:"organizationName"`
+	Description        string        `json:"description"`
+	LogoText           string        `json:"logoText"`
+	ForegroundColor    string        `json:"foregroundColor"`
+	BackgroundColor    string        `json:"backgroundColor"`
+	LabelColor         string        `json:"labelColor"`
+	Generic            passStructure `json:"generic"`
+	Barcode            passBarcode   `json:"barcode"`
+	Barcodes           []passBarcode `json:"barcodes"`
+}
+
+type passStructure struct {
+	PrimaryFields   []passField `json:"primaryFields"`
+	SecondaryFields []passField `json:"secondaryFields"`
+	AuxiliaryFields []passField `json:"auxiliaryFields,omitempty"`
+	BackFields      []passField `json:"backFields,omitempty"`
+}
+
+type passField struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type passBarcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+// GeneratePass builds a signed .pkpass archive for a WiFi credential.
+// The plaintext password is required because it is shown on the pass and encoded in the barcode.
+func (s *PassService) GeneratePass(credential *models.WifiCredential, password string) ([]byte, error) {
+	if !s.IsConfigured() {
+		return nil, ErrPassSigningNotConfigured
+	}
+
+	passBytes, err := json.MarshalIndent(s.buildPass(credential, password), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode pass.json: %w", err)
+	}
+
+	// Collect all files that go into the bundle (except manifest and signature)
+	files := map[string][]byte{
+		"pass.json": passBytes,
+	}
+	for name, size := range map[string]int{
+		"icon.png":    29,
+		"icon@2x.png": 58,
+		"icon@3x.png": 87,
+		"logo.png":    50,
+		"logo@2x.png": 100,
+	} {
+		imageBytes, err := renderPassImage(size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		files[name] = imageBytes
+	}
+
+	// Manifest lists the SHA-1 hash of every file in the bundle
+	manifest := make(map[string]string, len(files))
+	for name, content := range files {
+		sum := sha1.Sum(content)
+		manifest[name] = hex.EncodeToString(sum[:])
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	files["manifest.json"] = manifestBytes
+
+	signature, err := s.signManifest(manifestBytes)
+	if err != nil {
+		return nil, err
+	}
+	files["signature"] = signature
+
+	// Zip everything into the .pkpass archive
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to pass: %w", name, err)
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, fmt.Errorf("failed to write %s to pass: %w", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize pass archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// buildPass constructs the pass.json content for a credential
+func (s *PassService) buildPass(credential *models.WifiCredential, password string) *passJSON {
+	payload := s.qrCodeService.buildWiFiString(credential.SSID, password, credential.SecurityType, credential.KeyFormat, credential.IsHidden)
+
+	// Wallet converts the message to bytes using messageEncoding, so only fall back
+	// to UTF-8 when the payload cannot be represented in Latin-1
+	encoding := "iso-8859-1"
+	for _, r := range payload {
+		if r > 0xFF {
+			encoding = "utf-8"
+			break
+		}
+	}
+
+	barcode := passBarcode{
+		Format:          "PKBarcodeFormatQR",
+		Message:         payload,
+		MessageEncoding: encoding,
+		AltText:         credential.SSID,
+	}
+
+	secondary := []passField{
+		{Key: "security", Label: "SECURITY", Value: securityLabel(credential.SecurityType)},
+	}
+	if credential.SecurityType != models.SecurityNone {
+		secondary = append([]passField{{Key: "password", Label: "PASSWORD", Value: password}}, secondary...)
+	}
+
+	var auxiliary []passField
+	if credential.IsHidden {
+		auxiliary = append(auxiliary, passField{Key: "hidden", Label: "NETWORK", Value: "Hidden"})
+	}
+
+	return &passJSON{
+		FormatVersion:      1,
+		PassTypeIdentifier: s.config.TypeIdentifier,
+		SerialNumber:       credential.ID.String(),
+		TeamIdentifier:     s.config.TeamIdentifier,
+		OrganizationName:   s.config.OrganizationName,
+		Description:        fmt.Sprintf("WiFi access for %s", credential.SSID),
+		LogoText:           "WiFi",
+		ForegroundColor:    "rgb(255, 255, 255)",
+		BackgroundColor:    "rgb(37, 99, 235)",
+		LabelColor:         "rgb(219, 234, 254)",
+		Generic: passStructure{
+			PrimaryFields: []passField{
+				{Key: "ssid", Label: "NETWORK", Value: credential.SSID},
+			},
+			SecondaryFields: secondary,
+			AuxiliaryFields: auxiliary,
+			BackFields: []passField{
+				{Key: "instructions", Label: "How to connect", Value: "Open the Camera app and scan the QR code, or join the network manually using the details on the front of this pass."},
+			},
+		},
+		Barcode:  barcode,
+		Barcodes: []passBarcode{barcode},
+	}
+}
+
+// signManifest creates the detached PKCS#7 signature of manifest.json
+func (s *PassService) signManifest(manifest []byte) ([]byte, error) {
+	signedData, err := pkcs7.NewSignedData(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize pass signature: %w", err)
+	}
+	signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+
+	if err := signedData.AddSignerChain(s.signerCert, s.signerKey, []*x509.Certificate{s.wwdrCert}, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, fmt.Errorf("failed to sign pass manifest: %w", err)
+	}
+	signedData.Detach()
+
+	signature, err := signedData.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize pass signature: %w", err)
+	}
+	return signature, nil
+}
+
+// securityLabel returns a human readable label for a security type
+func securityLabel(security models.SecurityType) string {
+	if security == models.SecurityNone {
+		return "Open"
+	}
+	return string(security)
+}
+
+// renderPassImage draws the square WiFi glyph used for pass icons and logos
+func renderPassImage(size int) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	background := color.NRGBA{R: 37, G: 99, B: 235, A: 255}
+	foreground := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+	// Signal arcs radiate upwards from a point near the bottom centre
+	cx := float64(size) / 2
+	cy := float64(size) * 0.78
+	unit := float64(size) / 10
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.SetNRGBA(x, y, background)
+
+			dx := float64(x) + 0.5 - cx
+			dy := cy - (float64(y) + 0.5)
+			dist := math.Hypot(dx, dy)
+
+			// Dot at the origin
+			if dist <= unit*0.9 {
+				img.SetNRGBA(x, y, foreground)
+				continue
+			}
+
+			// Three arcs within a 90 degree wedge pointing up
+			if dy <= 0 || math.Abs(dx) > dy {
+				continue
+			}
+			for _, radius := range []float64{2.2, 3.9, 5.6} {
+				if math.Abs(dist-radius*unit) <= unit*0.55 {
+					img.SetNRGBA(x, y, foreground)
+					break
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// loadCertificate reads the first PEM encoded certificate from a file
+func loadCertificate(path string) (*x509.Certificate, error) {
+	if path == "" {
+		return nil, errors.New("certificate path is not set")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate found in %s", path)
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// loadPrivateKey reads a PEM encoded RSA or ECDSA private key (PKCS#1, PKCS#8 or SEC 1)
+func loadPrivateKey(path string) (crypto.PrivateKey, error) {
+	if path == "" {
+		return nil, errors.New("private key path is not set")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key found in %s", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key format: %w", err)
+	}
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, errors.New("private key must be RSA or ECDSA")
+	}
+}
@@ -3,71 +3,269 @@ package services
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"gin-quickstart/internal/models"
 	"gin-quickstart/internal/repositories"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 var (
-	ErrWifiNotFound       = errors.New("WiFi credential not found")
-	ErrUnauthorizedAccess = errors.New("unauthorized access to WiFi credential")
+	ErrWifiNotFound        = errors.New("WiFi credential not found")
+	ErrUnauthorizedAccess  = errors.New("unauthorized access to WiFi credential")
+	ErrSSIDTooLong         = fmt.Errorf("SSID must be at most %d bytes", maxSSIDBytes)
+	ErrInvalidSecurityType = errors.New("invalid security type")
+	ErrVersionMismatch     = errors.New("WiFi credential was changed by someone else, reload it and try again")
 )
 
+// maxSSIDBytes is the 802.11 SSID length limit, measured in bytes rather than characters
+const maxSSIDBytes = 32
+
 // WifiService handles WiFi credential business logic
 type WifiService struct {
-	wifiRepo      *repositories.WifiRepository
-	qrCodeService *QRCodeService
-	encryptionKey []byte
+	wifiRepo          *repositories.WifiRepository
+	versionRepo       *repositories.WifiVersionRepository
+	tagRepo           *repositories.TagRepository
+	locationRepo      *repositories.LocationRepository
+	grantRepo         *repositories.CredentialGrantRepository
+	membershipRepo    *repositories.MembershipRepository
+	transactor        *repositories.Transactor
+	quotaService      *QuotaService
+	qrCodeService     *QRCodeService
+	passwordGenerator *PasswordGenerator
+	passwordChecker   *PasswordChecker
+	events            *CredentialEvents // Told about every saved change, for live views
+	encryptionKey     []byte
 }
 
 // NewWifiService creates a new WiFi service
-func NewWifiService(wifiRepo *repositories.WifiRepository, qrCodeService *QRCodeService, encryptionKey string) *WifiService {
+func NewWifiService(wifiRepo *repositories.WifiRepository, versionRepo *repositories.WifiVersionRepository, tagRepo *repositories.TagRepository, locationRepo *repositories.LocationRepository, grantRepo *repositories.CredentialGrantRepository, membershipRepo *repositories.MembershipRepository, transactor *repositories.Transactor, quotaService *QuotaService, qrCodeService *QRCodeService, passwordGenerator *PasswordGenerator, passwordChecker *PasswordChecker, events *CredentialEvents, encryptionKey string) *WifiService {
 	return &WifiService{
-		wifiRepo:      wifiRepo,
-		qrCodeService: qrCodeService,
-		encryptionKey: []byte(encryptionKey), // Must be 32 bytes for AES-256
+		wifiRepo:          wifiRepo,
+		versionRepo:       versionRepo,
+		tagRepo:           tagRepo,
+		locationRepo:      locationRepo,
+		grantRepo:         grantRepo,
+		membershipRepo:    membershipRepo,
+		transactor:        transactor,
+		quotaService:      quotaService,
+		qrCodeService:     qrCodeService,
+		passwordGenerator: passwordGenerator,
+		passwordChecker:   passwordChecker,
+		events:            events,
+		encryptionKey:     []byte(encryptionKey), // Must be 32 bytes for AES-256
 	}
 }
 
 // CreateWifiRequest represents a request to create WiFi credential
 type CreateWifiRequest struct {
-	SSID         string               `json:"ssid" binding:"required,min=1,max=32"`
-	Password     string               `json:"password" binding:"max=63"`
-	SecurityType models.SecurityType  `json:"security_type" binding:"required"`
-	IsHidden     bool                 `json:"is_hidden"`
+	OrganizationID *uuid.UUID `json:"organization_id"` // Create in this organization instead of the personal workspace
+
+	SSID         string              `json:"ssid" binding:"required,min=1"`
+	Password     string              `json:"password" binding:"max=64"`
+	SecurityType models.SecurityType `json:"security_type" binding:"required"`
+	KeyFormat    models.KeyFormat    `json:"key_format" binding:"omitempty,oneof=passphrase hex"`
+	IsHidden     bool                `json:"is_hidden"`
+	LocationID   *uuid.UUID          `json:"location_id"`
+	TagIDs       []uuid.UUID         `json:"tag_ids"`
+
+	// Guest credentials: an optional validity window and a schedule for replacing the password
+	ValidFrom        *time.Time `json:"valid_from"`
+	ValidUntil       *time.Time `json:"valid_until"`
+	RotationSchedule string     `json:"rotation_schedule" binding:"max=100"` // "daily", "weekly" or a cron expression
+
+	// Encrypted like the password and only returned by the reveal endpoint
+	Notes        string               `json:"notes"`
+	CustomFields []models.CustomField `json:"custom_fields"`
+
+	// GeneratePassword replaces Password with a generated one; Create writes it back to Password
+	GeneratePassword bool            `json:"generate_password"`
+	PasswordPolicy   *PasswordPolicy `json:"password_policy"`
 }
 
 // UpdateWifiRequest represents a request to update WiFi credential
 type UpdateWifiRequest struct {
-	SSID         string               `json:"ssid" binding:"omitempty,min=1,max=32"`
-	Password     string               `json:"password" binding:"omitempty,max=63"`
-	SecurityType models.SecurityType  `json:"security_type" binding:"omitempty"`
-	IsHidden     *bool                `json:"is_hidden"`
+	SSID         string              `json:"ssid" binding:"omitempty,min=1"`
+	Password     string              `json:"password" binding:"omitempty,max=64"`
+	SecurityType models.SecurityType `json:"security_type" binding:"omitempty"`
+	KeyFormat    models.KeyFormat    `json:"key_format" binding:"omitempty,oneof=passphrase hex"`
+	IsHidden     *bool               `json:"is_hidden"`
+	LocationID   *uuid.UUID          `json:"location_id"` // uuid.Nil removes the credential from its location
+	TagIDs       *[]uuid.UUID        `json:"tag_ids"`     // Replaces all tags when present
+
+	ValidFrom        *time.Time `json:"valid_from"`                                    // The zero time removes the bound
+	ValidUntil       *time.Time `json:"valid_until"`                                   // The zero time removes the bound
+	RotationSchedule *string    `json:"rotation_schedule" binding:"omitempty,max=100"` // "" stops rotation
+
+	Notes        *string               `json:"notes"`         // "" removes the notes
+	CustomFields *[]models.CustomField `json:"custom_fields"` // Replaces all custom fields when present
+}
+
+// ReplaceWifiRequest represents a request to replace a WiFi credential. Fields that are
+// left out are cleared, except the password, which is never returned and so is kept
+// unless a new one is sent.
+type ReplaceWifiRequest struct {
+	SSID         string              `json:"ssid" binding:"required,min=1"`
+	Password     string              `json:"password" binding:"max=64"`
+	SecurityType models.SecurityType `json:"security_type" binding:"required"`
+	KeyFormat    models.KeyFormat    `json:"key_format" binding:"omitempty,oneof=passphrase hex"`
+	IsHidden     bool                `json:"is_hidden"`
+	LocationID   *uuid.UUID          `json:"location_id"`
+	TagIDs       []uuid.UUID         `json:"tag_ids"`
+
+	ValidFrom        *time.Time `json:"valid_from"`
+	ValidUntil       *time.Time `json:"valid_until"`
+	RotationSchedule string     `json:"rotation_schedule" binding:"max=100"`
+
+	Notes        string               `json:"notes"`
+	CustomFields []models.CustomField `json:"custom_fields"`
+}
+
+// ToUpdate converts the replacement into an update that sets every field
+func (r *ReplaceWifiRequest) ToUpdate() *UpdateWifiRequest {
+	keyFormat := r.KeyFormat
+	if keyFormat == "" {
+		keyFormat = models.KeyFormatPassphrase
+	}
+	locationID := uuid.Nil
+	if r.LocationID != nil {
+		locationID = *r.LocationID
+	}
+	tagIDs := r.TagIDs
+	if tagIDs == nil {
+		tagIDs = []uuid.UUID{}
+	}
+	customFields := r.CustomFields
+	if customFields == nil {
+		customFields = []models.CustomField{}
+	}
+	validFrom, validUntil := time.Time{}, time.Time{}
+	if r.ValidFrom != nil {
+		validFrom = *r.ValidFrom
+	}
+	if r.ValidUntil != nil {
+		validUntil = *r.ValidUntil
+	}
+
+	return &UpdateWifiRequest{
+		SSID:             r.SSID,
+		Password:         r.Password,
+		SecurityType:     r.SecurityType,
+		KeyFormat:        keyFormat,
+		IsHidden:         &r.IsHidden,
+		LocationID:       &locationID,
+		TagIDs:           &tagIDs,
+		ValidFrom:        &validFrom,
+		ValidUntil:       &validUntil,
+		RotationSchedule: &r.RotationSchedule,
+		Notes:            &r.Notes,
+		CustomFields:     &customFields,
+	}
 }
 
-// Create creates a new WiFi credential with QR code
+// Create creates a new WiFi credential with QR code, in an organization when
+// req.OrganizationID is set (members and above) or else for the user alone
 func (s *WifiService) Create(userID uuid.UUID, req *CreateWifiRequest) (*models.WifiCredential, error) {
+	scope, err := resolveScope(s.membershipRepo, userID, req.OrganizationID, models.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, tagIDs, err := s.newCredential(userID, scope, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.createWithinQuota(scope, credential, &userID, &tagIDs); err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// createWithinQuota stores a new credential and its first version, checking the plan
+// limit of its scope in the same transaction
+func (s *WifiService) createWithinQuota(scope repositories.Scope, credential *models.WifiCredential, changedBy *uuid.UUID, tagIDs *[]uuid.UUID) error {
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		if err := s.quotaService.CheckCredentialsTx(tx, scope, 1); err != nil {
+			return err
+		}
+		return s.saveWithVersionTx(tx, credential, nil, models.VersionChangeCreated, changedBy, nil, tagIDs)
+	})
+	if err != nil {
+		return err
+	}
+	s.events.Publish(credential.ID)
+	return nil
+}
+
+// newCredential validates req and builds the credential it describes, with its password
+// encrypted and QR code generated, along with the validated tag IDs. Nothing is stored.
+func (s *WifiService) newCredential(userID uuid.UUID, scope repositories.Scope, req *CreateWifiRequest) (*models.WifiCredential, []uuid.UUID, error) {
+	// Validate SSID length in bytes (multi-byte UTF-8 characters count more than once)
+	if err := validateSSID(req.SSID); err != nil {
+		return nil, nil, err
+	}
+
 	// Validate security type
 	if !models.IsValidSecurityType(string(req.SecurityType)) {
-		return nil, fmt.Errorf("invalid security type: %s", req.SecurityType)
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidSecurityType, req.SecurityType)
+	}
+
+	if err := validateValidity(req.ValidFrom, req.ValidUntil); err != nil {
+		return nil, nil, err
+	}
+	if err := validateRotation(req.RotationSchedule, req.SecurityType); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.validateLocation(scope, req.LocationID); err != nil {
+		return nil, nil, err
+	}
+	tagIDs, err := s.validateTags(scope, req.TagIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateNotes(req.Notes, req.CustomFields); err != nil {
+		return nil, nil, err
+	}
+
+	// Generate a password if requested
+	if req.GeneratePassword {
+		password, err := s.generatePassword(req.SecurityType, req.PasswordPolicy)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Password = password
+		req.KeyFormat = models.KeyFormatPassphrase
 	}
 
-	// Validate password requirement
-	if req.SecurityType != models.SecurityNone && req.Password == "" {
-		return nil, errors.New("password is required for secured networks")
+	// Validate password against the security type and key format
+	if req.KeyFormat == "" {
+		req.KeyFormat = models.KeyFormatPassphrase
+	}
+	if err := validateWifiKey(req.SecurityType, req.KeyFormat, req.Password); err != nil {
+		return nil, nil, err
 	}
 
 	// Encrypt password
 	encryptedPassword, err := s.encryptPassword(req.Password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt password: %w", err)
+		return nil, nil, fmt.Errorf("failed to encrypt password: %w", err)
+	}
+	encryptedNotes, encryptedFields, err := s.encryptNotes(req.Notes, req.CustomFields)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Generate QR code
@@ -75,31 +273,229 @@ func (s *WifiService) Create(userID uuid.UUID, req *CreateWifiRequest) (*models.
 		req.SSID,
 		req.Password,
 		req.SecurityType,
+		req.KeyFormat,
 		req.IsHidden,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate QR code: %w", err)
 	}
 
 	// Create credential
 	credential := &models.WifiCredential{
 		UserID:            userID,
+		OrganizationID:    req.OrganizationID,
 		SSID:              req.SSID,
 		EncryptedPassword: encryptedPassword,
 		SecurityType:      req.SecurityType,
+		KeyFormat:         req.KeyFormat,
 		IsHidden:          req.IsHidden,
 		QRCodeData:        qrCodeData,
+		EncryptedNotes:    encryptedNotes,
+		EncryptedFields:   encryptedFields,
+		LocationID:        req.LocationID,
+		ValidFrom:         req.ValidFrom,
+		ValidUntil:        req.ValidUntil,
+		RotationSchedule:  req.RotationSchedule,
+	}
+	s.setPasswordChecks(credential, req.Password)
+
+	credential.NextRotationAt, err = nextRotation(credential, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return credential, tagIDs, nil
+}
+
+// Update applies the non-empty fields of req to a WiFi credential, re-encrypting
+// a changed password and regenerating the QR code. It returns ErrVersionMismatch
+// unless the credential is still at version; a version of 0 skips that check.
+func (s *WifiService) Update(id uuid.UUID, userID uuid.UUID, isAdmin bool, version int, req *UpdateWifiRequest) (*models.WifiCredential, error) {
+	credential, err := s.GetWithPermission(id, userID, isAdmin, models.GrantEdit)
+	if err != nil {
+		return nil, err
 	}
+	if version != 0 && credential.Version != version {
+		return nil, ErrVersionMismatch
+	}
+
+	previous := *credential
 
-	if err := s.wifiRepo.Create(credential); err != nil {
-		return nil, fmt.Errorf("failed to create WiFi credential: %w", err)
+	// The QR code needs the plaintext, so start from the stored password
+	currentPassword, err := s.DecryptPassword(credential.EncryptedPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	ssid := credential.SSID
+	if req.SSID != "" {
+		ssid = req.SSID
+	}
+	securityType := credential.SecurityType
+	if req.SecurityType != "" {
+		securityType = req.SecurityType
+	}
+	keyFormat := credential.KeyFormat
+	if req.KeyFormat != "" {
+		keyFormat = req.KeyFormat
+	}
+	if keyFormat == "" {
+		keyFormat = models.KeyFormatPassphrase
+	}
+	isHidden := credential.IsHidden
+	if req.IsHidden != nil {
+		isHidden = *req.IsHidden
+	}
+
+	password := currentPassword
+	if req.Password != "" {
+		password = req.Password
+	} else if securityType == models.SecurityNone {
+		// Switching to an open network drops the old password
+		password = ""
+	}
+
+	if err := validateSSID(ssid); err != nil {
+		return nil, err
+	}
+	if !models.IsValidSecurityType(string(securityType)) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSecurityType, securityType)
+	}
+	if err := validateWifiKey(securityType, keyFormat, password); err != nil {
+		return nil, err
+	}
+
+	validFrom := mergeTime(credential.ValidFrom, req.ValidFrom)
+	validUntil := mergeTime(credential.ValidUntil, req.ValidUntil)
+	rotationSchedule := credential.RotationSchedule
+	if req.RotationSchedule != nil {
+		rotationSchedule = *req.RotationSchedule
+	}
+	if err := validateValidity(validFrom, validUntil); err != nil {
+		return nil, err
+	}
+	if err := validateRotation(rotationSchedule, securityType); err != nil {
+		return nil, err
+	}
+
+	// Tags and locations must come from the credential's own workspace, even when an admin edits it
+	scope := repositories.ScopeOf(credential.UserID, credential.OrganizationID)
+	if req.LocationID != nil {
+		credential.LocationID = req.LocationID
+		if *req.LocationID == uuid.Nil {
+			credential.LocationID = nil
+		}
+		if err := s.validateLocation(scope, credential.LocationID); err != nil {
+			return nil, err
+		}
+	}
+	var tagIDs *[]uuid.UUID
+	if req.TagIDs != nil {
+		ids, err := s.validateTags(scope, *req.TagIDs)
+		if err != nil {
+			return nil, err
+		}
+		tagIDs = &ids
+	}
+
+	// Notes and custom fields are replaced separately, keeping whichever wasn't sent
+	if req.Notes != nil || req.CustomFields != nil {
+		notes, fields, err := s.DecryptNotes(credential)
+		if err != nil {
+			return nil, err
+		}
+		if req.Notes != nil {
+			notes = *req.Notes
+		}
+		if req.CustomFields != nil {
+			fields = *req.CustomFields
+		}
+		if err := validateNotes(notes, fields); err != nil {
+			return nil, err
+		}
+		credential.EncryptedNotes, credential.EncryptedFields, err = s.encryptNotes(notes, fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Only re-encrypt a changed password
+	if password != currentPassword {
+		encryptedPassword, err := s.encryptPassword(password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt password: %w", err)
+		}
+		credential.EncryptedPassword = encryptedPassword
+	}
+
+	qrCodeData, err := s.qrCodeService.GenerateWiFiQRCode(ssid, password, securityType, keyFormat, isHidden)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	credential.SSID = ssid
+	credential.SecurityType = securityType
+	credential.KeyFormat = keyFormat
+	credential.IsHidden = isHidden
+	credential.QRCodeData = qrCodeData
+	s.setPasswordChecks(credential, password) // The SSID takes part in the dictionary check
+
+	// Reschedule only when the schedule or window changed, so an overdue rotation isn't skipped
+	if req.ValidFrom != nil || req.ValidUntil != nil || req.RotationSchedule != nil {
+		credential.ValidFrom = validFrom
+		credential.ValidUntil = validUntil
+		credential.RotationSchedule = rotationSchedule
+		credential.NextRotationAt, err = nextRotation(credential, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.saveWithVersion(credential, &previous, models.VersionChangeUpdated, &userID, nil, tagIDs); err != nil {
+		return nil, err
 	}
 
 	return credential, nil
 }
 
-// GetByID retrieves a WiFi credential by ID
+// IsValidationError reports whether err was caused by invalid credential input
+// rather than a storage or encryption failure
+func IsValidationError(err error) bool {
+	for _, target := range []error{
+		ErrSSIDTooLong,
+		ErrInvalidSecurityType,
+		ErrInvalidKeyFormat,
+		ErrPasswordRequired,
+		ErrPasswordNotAllowed,
+		ErrInvalidPassphrase,
+		ErrInvalidPSK,
+		ErrInvalidWEPKey,
+		ErrTagNotFound,
+		ErrLocationNotFound,
+		ErrInvalidValidityWindow,
+		ErrInvalidRotationSchedule,
+		ErrRotationRequiresPassword,
+		ErrNotesTooLong,
+		ErrInvalidCustomFields,
+		ErrNoCharacterClasses,
+		ErrPasswordTooLong,
+		ErrLengthTooShort,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByID retrieves a WiFi credential the user may view
 func (s *WifiService) GetByID(id uuid.UUID, userID uuid.UUID, isAdmin bool) (*models.WifiCredential, error) {
+	return s.GetWithPermission(id, userID, isAdmin, models.GrantView)
+}
+
+// GetWithPermission retrieves a WiFi credential if the user owns it, is an admin, or
+// holds an organization role or grant that includes the required permission
+func (s *WifiService) GetWithPermission(id uuid.UUID, userID uuid.UUID, isAdmin bool, required models.GrantPermission) (*models.WifiCredential, error) {
 	credential, err := s.wifiRepo.FindByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get WiFi credential: %w", err)
@@ -108,15 +504,130 @@ func (s *WifiService) GetByID(id uuid.UUID, userID uuid.UUID, isAdmin bool) (*mo
 		return nil, ErrWifiNotFound
 	}
 
-	// Check authorization (user can only access their own credentials unless admin)
-	if !isAdmin && credential.UserID != userID {
-		return nil, ErrUnauthorizedAccess
+	if err := s.authorize(credential, userID, isAdmin, &required); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// GetWithAccess retrieves a WiFi credential the user may view, with the highest
+// permission they hold on it
+func (s *WifiService) GetWithAccess(id uuid.UUID, userID uuid.UUID, isAdmin bool) (*models.WifiCredential, models.GrantPermission, error) {
+	credential, err := s.wifiRepo.FindByID(id)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get WiFi credential: %w", err)
+	}
+	if credential == nil {
+		return nil, "", ErrWifiNotFound
+	}
+
+	permission, err := s.access(credential, userID, isAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+	if !permission.Includes(models.GrantView) {
+		return nil, "", ErrUnauthorizedAccess
 	}
+	return credential, permission, nil
+}
 
+// GetAsOwner retrieves a WiFi credential for actions reserved to its owner (and admins),
+// such as deleting it or sharing it further
+func (s *WifiService) GetAsOwner(id uuid.UUID, userID uuid.UUID, isAdmin bool) (*models.WifiCredential, error) {
+	credential, err := s.wifiRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WiFi credential: %w", err)
+	}
+	if credential == nil {
+		return nil, ErrWifiNotFound
+	}
+
+	if err := s.authorize(credential, userID, isAdmin, nil); err != nil {
+		return nil, err
+	}
 	return credential, nil
 }
 
-// GetAllByUser retrieves all WiFi credentials for a user
+// authorize checks the user's access to a credential. A nil required permission asks
+// for ownership: the creator of a personal credential, or an admin or owner of the
+// organization an organization credential belongs to. Otherwise the permission can
+// also come from the user's organization role or a grant.
+func (s *WifiService) authorize(credential *models.WifiCredential, userID uuid.UUID, isAdmin bool, required *models.GrantPermission) error {
+	if isAdmin {
+		return nil
+	}
+
+	if credential.OrganizationID == nil {
+		if credential.UserID == userID {
+			return nil
+		}
+	} else {
+		// Creating an organization credential doesn't make it the creator's
+		membership, err := s.membershipRepo.FindByOrganizationAndUser(*credential.OrganizationID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get membership: %w", err)
+		}
+		if membership != nil {
+			if membership.Role.AtLeast(models.OrgRoleAdmin) {
+				return nil
+			}
+			if required != nil && membership.Role.CredentialPermission().Includes(*required) {
+				return nil
+			}
+		}
+	}
+
+	// Grants never allow owner-only actions
+	if required == nil {
+		return ErrUnauthorizedAccess
+	}
+	grant, err := s.grantRepo.FindByCredentialAndGrantee(credential.ID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get credential grant: %w", err)
+	}
+	if grant == nil || !grant.Permission.Includes(*required) {
+		return ErrUnauthorizedAccess
+	}
+	return nil
+}
+
+// access returns the highest permission the user holds on a credential through
+// ownership, an organization role or a grant, or "" without any. Owners and admins
+// hold GrantEdit.
+func (s *WifiService) access(credential *models.WifiCredential, userID uuid.UUID, isAdmin bool) (models.GrantPermission, error) {
+	if isAdmin {
+		return models.GrantEdit, nil
+	}
+
+	var permission models.GrantPermission
+	if credential.OrganizationID == nil {
+		if credential.UserID == userID {
+			return models.GrantEdit, nil
+		}
+	} else {
+		membership, err := s.membershipRepo.FindByOrganizationAndUser(*credential.OrganizationID, userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get membership: %w", err)
+		}
+		if membership != nil {
+			permission = membership.Role.CredentialPermission()
+		}
+	}
+	if permission.Includes(models.GrantEdit) {
+		return permission, nil
+	}
+
+	grant, err := s.grantRepo.FindByCredentialAndGrantee(credential.ID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get credential grant: %w", err)
+	}
+	if grant != nil && !permission.Includes(grant.Permission) {
+		permission = grant.Permission
+	}
+	return permission, nil
+}
+
+// GetAllByUser retrieves the personal WiFi credentials of a user
 func (s *WifiService) GetAllByUser(userID uuid.UUID) ([]models.WifiCredential, error) {
 	credentials, err := s.wifiRepo.FindByUserID(userID)
 	if err != nil {
@@ -134,26 +645,151 @@ func (s *WifiService) GetAll() ([]models.WifiCredential, error) {
 	return credentials, nil
 }
 
-// Delete deletes a WiFi credential
-func (s *WifiService) Delete(id uuid.UUID, userID uuid.UUID, isAdmin bool) error {
-	// Get credential to check ownership
-	credential, err := s.wifiRepo.FindByID(id)
+// Delete deletes a WiFi credential. Only the owner or an admin may delete it. Like
+// Update, it returns ErrVersionMismatch unless the credential is still at version.
+func (s *WifiService) Delete(id uuid.UUID, userID uuid.UUID, isAdmin bool, version int) error {
+	credential, err := s.GetAsOwner(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		version = credential.Version
+	}
+
+	if err := s.wifiRepo.Delete(id, version); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVersionMismatch
+		}
+		return fmt.Errorf("failed to delete WiFi credential: %w", err)
+	}
+
+	s.events.Publish(id)
+	return nil
+}
+
+// ListTrash retrieves the WiFi credentials in a user's trash, or in an organization's
+// trash when organizationID is set (admins and owners only)
+func (s *WifiService) ListTrash(userID uuid.UUID, organizationID *uuid.UUID) ([]models.WifiCredential, error) {
+	scope, err := resolveScope(s.membershipRepo, userID, organizationID, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.wifiRepo.FindDeletedByScope(scope)
 	if err != nil {
-		return fmt.Errorf("failed to get WiFi credential: %w", err)
+		return nil, fmt.Errorf("failed to get deleted WiFi credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+// Restore takes a WiFi credential out of the trash
+func (s *WifiService) Restore(id uuid.UUID, userID uuid.UUID, isAdmin bool) (*models.WifiCredential, error) {
+	credential, err := s.wifiRepo.FindDeletedByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted WiFi credential: %w", err)
 	}
 	if credential == nil {
-		return ErrWifiNotFound
+		return nil, ErrWifiNotFound
 	}
 
-	// Check authorization
-	if !isAdmin && credential.UserID != userID {
-		return ErrUnauthorizedAccess
+	if err := s.authorize(credential, userID, isAdmin, nil); err != nil {
+		return nil, err
 	}
 
-	if err := s.wifiRepo.Delete(id); err != nil {
-		return fmt.Errorf("failed to delete WiFi credential: %w", err)
+	// Credentials in the trash don't count, so bringing one back needs room in its workspace's plan
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		scope := repositories.ScopeOf(credential.UserID, credential.OrganizationID)
+		if err := s.quotaService.CheckCredentialsTx(tx, scope, 1); err != nil {
+			return err
+		}
+		if err := s.wifiRepo.WithTx(tx).Restore(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrWifiNotFound
+			}
+			return fmt.Errorf("failed to restore WiFi credential: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.events.Publish(id)
 
+	credential.DeletedAt = gorm.DeletedAt{}
+	return credential, nil
+}
+
+// GeneratePassword generates a password without storing anything
+func (s *WifiService) GeneratePassword(policy PasswordPolicy) (*GeneratedPassword, error) {
+	return s.passwordGenerator.Generate(policy)
+}
+
+// generatePassword generates a password suitable for the security type
+func (s *WifiService) generatePassword(security models.SecurityType, policy *PasswordPolicy) (string, error) {
+	if security == models.SecurityNone {
+		return "", ErrPasswordNotAllowed
+	}
+
+	if policy == nil {
+		policy = &PasswordPolicy{}
+		// WEP only accepts 5, 13 or 16 character ASCII keys
+		if security == models.SecurityWEP {
+			policy.Mode = PasswordModeRandom
+			policy.Length = 13
+		}
+	}
+
+	generated, err := s.passwordGenerator.Generate(*policy)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return generated.Password, nil
+}
+
+// validateLocation checks that a location, if set, lies in the credential's scope
+func (s *WifiService) validateLocation(scope repositories.Scope, locationID *uuid.UUID) error {
+	if locationID == nil {
+		return nil
+	}
+	location, err := s.locationRepo.FindByID(*locationID)
+	if err != nil {
+		return fmt.Errorf("failed to get location: %w", err)
+	}
+	if location == nil || !scope.Contains(location.UserID, location.OrganizationID) {
+		return ErrLocationNotFound
+	}
+	return nil
+}
+
+// validateTags deduplicates tag IDs and checks that every tag lies in the credential's scope
+func (s *WifiService) validateTags(scope repositories.Scope, tagIDs []uuid.UUID) ([]uuid.UUID, error) {
+	tagIDs = uniqueIDs(tagIDs)
+	tags, err := s.tagRepo.FindByIDs(scope, tagIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	if len(tags) != len(tagIDs) {
+		return nil, ErrTagNotFound
+	}
+	return tagIDs, nil
+}
+
+// mergeTime applies an optional update to a time field; the zero time clears it
+func mergeTime(current, update *time.Time) *time.Time {
+	if update == nil {
+		return current
+	}
+	if update.IsZero() {
+		return nil
+	}
+	return update
+}
+
+// validateSSID checks an SSID against the 802.11 byte length limit
+func validateSSID(ssid string) error {
+	if len(ssid) > maxSSIDBytes {
+		return ErrSSIDTooLong
+	}
 	return nil
 }
 
@@ -186,6 +822,22 @@ func (s *WifiService) encryptPassword(password string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
+// fingerprintPassword computes a keyed hash of a password so identical passwords
+// can be found without decrypting them. The HMAC key is derived from the
+// encryption key so fingerprints can't be brute-forced without it.
+func (s *WifiService) fingerprintPassword(password string) string {
+	if password == "" {
+		return ""
+	}
+
+	keyMac := hmac.New(sha256.New, s.encryptionKey)
+	keyMac.Write([]byte("wifi-password-fingerprint"))
+
+	mac := hmac.New(sha256.New, keyMac.Sum(nil))
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // DecryptPassword decrypts an encrypted password
 func (s *WifiService) DecryptPassword(encryptedPassword string) (string, error) {
 	if encryptedPassword == "" {
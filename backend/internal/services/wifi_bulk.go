@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidBulkAction = errors.New("action must be delete, tag, move, rotate or export")
+	ErrBulkTooLarge      = fmt.Errorf("at most %d credentials can be changed at once", maxBulkItems)
+	ErrBulkNoTags        = errors.New("add_tag_ids or remove_tag_ids is required")
+	ErrBulkNoVersion     = errors.New("versions must hold the version of every credential, as sent in If-Match")
+)
+
+// maxBulkItems bounds the credentials of one bulk request
+const maxBulkItems = 100
+
+// BulkAction is an operation applied to every credential of a bulk request
+type BulkAction string
+
+const (
+	BulkActionDelete BulkAction = "delete" // Move to the trash, owners only
+	BulkActionTag    BulkAction = "tag"    // Add and remove tags
+	BulkActionMove   BulkAction = "move"   // Change the location
+	BulkActionRotate BulkAction = "rotate" // Replace the password with a generated passphrase
+	BulkActionExport BulkAction = "export" // Return the credentials with their passwords
+)
+
+// BulkItemStatus is the outcome of a bulk action for one credential
+type BulkItemStatus string
+
+const (
+	BulkItemSucceeded BulkItemStatus = "succeeded"
+	BulkItemFailed    BulkItemStatus = "failed"
+	BulkItemSkipped   BulkItemStatus = "skipped" // Rolled back because another credential failed
+)
+
+// BulkWifiRequest represents a request to apply one action to many credentials
+type BulkWifiRequest struct {
+	Action        BulkAction  `json:"action" binding:"required"`
+	CredentialIDs []uuid.UUID `json:"credential_ids" binding:"required,min=1"`
+
+	// delete, move and rotate: the version each credential was last read at, as sent
+	// in If-Match by the single-credential endpoints; 0 skips the check like If-Match: *
+	Versions map[uuid.UUID]int `json:"versions"`
+
+	// tag: tags from each credential's own workspace
+	AddTagIDs    []uuid.UUID `json:"add_tag_ids"`
+	RemoveTagIDs []uuid.UUID `json:"remove_tag_ids"`
+
+	// move: a location from each credential's own workspace; null removes the location
+	LocationID *uuid.UUID `json:"location_id"`
+
+	// export: the account password, as for revealing a password; may be omitted when
+	// the caller's token was issued recently
+	Password string `json:"password"`
+}
+
+// BulkItemResult is the outcome for one credential of a bulk request
+type BulkItemResult struct {
+	ID         uuid.UUID      `json:"id"`
+	Status     BulkItemStatus `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Credential *ImportWifiRow `json:"credential,omitempty"` // export only
+}
+
+// BulkWifiResult reports a bulk request per credential, in request order
+type BulkWifiResult struct {
+	Action    BulkAction       `json:"action"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// Bulk applies req.Action to every credential in req. Each credential is checked
+// like the single-credential endpoint would: deleting needs ownership, tagging,
+// moving and rotating the edit permission and exporting the reveal permission.
+// Deleting, moving and rotating also need the version of each credential from
+// req.Versions, as If-Match does for a single credential.
+// Credentials failing the checks are reported and the others changed in one
+// transaction, so a failure while writing rolls them all back. Exports hand out
+// passwords and go through RevealService.BulkExport, which re-authenticates the user.
+func (s *WifiService) Bulk(ec ExportContext, isAdmin bool, req *BulkWifiRequest) (*BulkWifiResult, error) {
+	if req.Action == BulkActionExport {
+		return nil, ErrReauthenticationRequired
+	}
+	return s.bulk(ec, isAdmin, req, nil)
+}
+
+// bulk implements Bulk and BulkExport. An export counts once against the daily export
+// limit, ec identifying the user and where from, and revealed is called for every
+// exported credential before the result is returned.
+func (s *WifiService) bulk(ec ExportContext, isAdmin bool, req *BulkWifiRequest, revealed func(id uuid.UUID) error) (*BulkWifiResult, error) {
+	ids := uniqueIDs(req.CredentialIDs)
+	if len(ids) > maxBulkItems {
+		return nil, ErrBulkTooLarge
+	}
+
+	var required *models.GrantPermission
+	switch req.Action {
+	case BulkActionDelete:
+	case BulkActionTag:
+		if len(req.AddTagIDs) == 0 && len(req.RemoveTagIDs) == 0 {
+			return nil, ErrBulkNoTags
+		}
+		required = grantPermission(models.GrantEdit)
+	case BulkActionMove, BulkActionRotate:
+		required = grantPermission(models.GrantEdit)
+	case BulkActionExport:
+		required = grantPermission(models.GrantReveal)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBulkAction, req.Action)
+	}
+	if req.Action.changesCredential() {
+		for _, id := range ids {
+			if _, ok := req.Versions[id]; !ok {
+				return nil, fmt.Errorf("%w: missing for %s", ErrBulkNoVersion, id)
+			}
+		}
+	}
+
+	credentials, err := s.wifiRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WiFi credentials: %w", err)
+	}
+	byID := make(map[uuid.UUID]*models.WifiCredential, len(credentials))
+	for i := range credentials {
+		byID[credentials[i].ID] = &credentials[i]
+	}
+
+	result := &BulkWifiResult{
+		Action: req.Action,
+		Items:  make([]BulkItemResult, len(ids)),
+	}
+
+	// Check every credential and prepare its change; writes wait for the transaction
+	now := time.Now()
+	writes := make(map[int]func(tx *gorm.DB) error, len(ids))
+	for i, id := range ids {
+		result.Items[i].ID = id
+
+		write, row, err := s.prepareBulkItem(ec.UserID, isAdmin, req, byID[id], required, now)
+		if err != nil {
+			result.Items[i].Status = BulkItemFailed
+			result.Items[i].Error = err.Error()
+			result.Failed++
+			continue
+		}
+		result.Items[i].Status = BulkItemSucceeded
+		result.Items[i].Credential = row
+		result.Succeeded++
+		if write != nil {
+			writes[i] = write
+		}
+	}
+
+	if req.Action == BulkActionExport {
+		if result.Succeeded > 0 {
+			if err := s.quotaService.RecordExport(ec, "bulk_export", nil); err != nil {
+				return nil, err
+			}
+		}
+		for _, item := range result.Items {
+			if item.Status == BulkItemSucceeded {
+				if err := revealed(item.ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return result, nil
+	}
+
+	failedAt := -1
+	err = s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		for i := range result.Items {
+			if write, ok := writes[i]; ok {
+				if err := write(tx); err != nil {
+					failedAt = i
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if failedAt < 0 {
+			return nil, fmt.Errorf("failed to apply bulk %s: %w", req.Action, err)
+		}
+		for i := range writes {
+			result.Items[i].Status = BulkItemSkipped
+		}
+		result.Items[failedAt].Status = BulkItemFailed
+		result.Items[failedAt].Error = err.Error()
+		result.Failed++
+		result.Succeeded = 0
+		return result, nil
+	}
+
+	changed := make([]uuid.UUID, 0, len(writes))
+	for i := range writes {
+		changed = append(changed, result.Items[i].ID)
+	}
+	s.events.Publish(changed...)
+	return result, nil
+}
+
+// prepareBulkItem checks that the user may apply the action to credential and returns
+// the write to run in the bulk transaction or, for exports, the exported row
+func (s *WifiService) prepareBulkItem(userID uuid.UUID, isAdmin bool, req *BulkWifiRequest, credential *models.WifiCredential, required *models.GrantPermission, now time.Time) (func(tx *gorm.DB) error, *ImportWifiRow, error) {
+	if credential == nil {
+		return nil, nil, ErrWifiNotFound
+	}
+	if err := s.authorize(credential, userID, isAdmin, required); err != nil {
+		return nil, nil, err
+	}
+	if req.Action.changesCredential() {
+		if version := req.Versions[credential.ID]; version != 0 && credential.Version != version {
+			return nil, nil, ErrVersionMismatch
+		}
+	}
+
+	// Tags and locations must come from the credential's own workspace, as in Update
+	scope := repositories.ScopeOf(credential.UserID, credential.OrganizationID)
+	previous := *credential
+
+	switch req.Action {
+	case BulkActionDelete:
+		return func(tx *gorm.DB) error {
+			if err := s.wifiRepo.WithTx(tx).Delete(credential.ID, credential.Version); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrVersionMismatch
+				}
+				return err
+			}
+			return nil
+		}, nil, nil
+
+	case BulkActionTag:
+		add, err := s.validateTags(scope, req.AddTagIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+		remove, err := s.validateTags(scope, req.RemoveTagIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+		return func(tx *gorm.DB) error {
+			tagRepo := s.tagRepo.WithTx(tx)
+			if err := tagRepo.Unassign([]uuid.UUID{credential.ID}, remove); err != nil {
+				return err
+			}
+			return tagRepo.Assign([]uuid.UUID{credential.ID}, add)
+		}, nil, nil
+
+	case BulkActionMove:
+		locationID := req.LocationID
+		if locationID != nil && *locationID == uuid.Nil {
+			locationID = nil
+		}
+		if err := s.validateLocation(scope, locationID); err != nil {
+			return nil, nil, err
+		}
+		credential.LocationID = locationID
+		return func(tx *gorm.DB) error {
+			return s.saveWithVersionTx(tx, credential, &previous, models.VersionChangeUpdated, &userID, nil, nil)
+		}, nil, nil
+
+	case BulkActionRotate:
+		if err := s.rotatePassword(credential, now); err != nil {
+			return nil, nil, err
+		}
+		return func(tx *gorm.DB) error {
+			return s.saveWithVersionTx(tx, credential, &previous, models.VersionChangeRotated, &userID, nil, nil)
+		}, nil, nil
+
+	case BulkActionExport:
+		row, err := s.exportRow(credential)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, row, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrInvalidBulkAction, req.Action)
+}
+
+// changesCredential reports whether the action saves the credentials themselves, which
+// needs their versions like the single-credential endpoints. Tags are kept apart from
+// the credential and change no version.
+func (a BulkAction) changesCredential() bool {
+	return a == BulkActionDelete || a == BulkActionMove || a == BulkActionRotate
+}
+
+// grantPermission returns a pointer to permission, for authorize
+func grantPermission(permission models.GrantPermission) *models.GrantPermission {
+	return &permission
+}
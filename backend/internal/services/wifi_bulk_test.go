@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestBulkRequiresVersions(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name     string
+		action   BulkAction
+		versions map[uuid.UUID]int
+		wantErr  error
+	}{
+		{name: "delete without versions", action: BulkActionDelete, wantErr: ErrBulkNoVersion},
+		{name: "move with another credential's version", action: BulkActionMove, versions: map[uuid.UUID]int{uuid.New(): 1}, wantErr: ErrBulkNoVersion},
+		{name: "rotate without versions", action: BulkActionRotate, wantErr: ErrBulkNoVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newImportTestService(t)
+
+			_, err := service.Bulk(ExportContext{UserID: uuid.New()}, false, &BulkWifiRequest{
+				Action:        tt.action,
+				CredentialIDs: []uuid.UUID{id},
+				Versions:      tt.versions,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBulkDeleteFailsStaleVersions(t *testing.T) {
+	service, mock := newImportTestService(t)
+	userID := uuid.New()
+	current := uuid.New()
+	stale := uuid.New()
+
+	mock.ExpectQuery(`FROM "wifi_qr_codes"`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "user_id", "ssid", "security_type", "version"}).
+			AddRow(current, userID, "Office", models.SecurityWPA2, 4).
+			AddRow(stale, userID, "Guest", models.SecurityWPA2, 4))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "wifi_qr_codes"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := service.Bulk(ExportContext{UserID: userID}, false, &BulkWifiRequest{
+		Action:        BulkActionDelete,
+		CredentialIDs: []uuid.UUID{current, stale},
+		Versions:      map[uuid.UUID]int{current: 4, stale: 3},
+	})
+	if err != nil {
+		t.Fatalf("Bulk: %v", err)
+	}
+
+	want := map[uuid.UUID]BulkItemStatus{current: BulkItemSucceeded, stale: BulkItemFailed}
+	for _, item := range result.Items {
+		if item.Status != want[item.ID] {
+			t.Errorf("item %s: status %s, want %s (error %q)", item.ID, item.Status, want[item.ID], item.Error)
+		}
+		if item.ID == stale && item.Error != ErrVersionMismatch.Error() {
+			t.Errorf("stale item error = %q, want %q", item.Error, ErrVersionMismatch)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
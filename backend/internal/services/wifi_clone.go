@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// CloneWifiRequest represents a request to copy a WiFi credential
+type CloneWifiRequest struct {
+	OrganizationID *uuid.UUID `json:"organization_id"` // Copy into this organization instead of the personal workspace
+	LocationID     *uuid.UUID `json:"location_id"`     // Defaults to the source location when copying within its workspace
+	SSIDSuffix     string     `json:"ssid_suffix" binding:"max=32"`
+
+	// GeneratePassword gives the copy a new password instead of the source's
+	GeneratePassword bool            `json:"generate_password"`
+	PasswordPolicy   *PasswordPolicy `json:"password_policy"`
+}
+
+// Clone copies a WiFi credential the user may reveal, with its notes, custom fields and
+// guest settings, into their personal workspace or req.OrganizationID. The copy is
+// encrypted and gets its QR code anew. Tags and the location are kept only when
+// copying within the source's workspace. It returns the copy and, when one was
+// generated, its password.
+func (s *WifiService) Clone(id uuid.UUID, userID uuid.UUID, isAdmin bool, req *CloneWifiRequest) (*models.WifiCredential, string, error) {
+	source, err := s.GetWithPermission(id, userID, isAdmin, models.GrantReveal)
+	if err != nil {
+		return nil, "", err
+	}
+
+	password, err := s.DecryptPassword(source.EncryptedPassword)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	notes, fields, err := s.DecryptNotes(source)
+	if err != nil {
+		return nil, "", err
+	}
+
+	create := &CreateWifiRequest{
+		OrganizationID:   req.OrganizationID,
+		SSID:             source.SSID + req.SSIDSuffix,
+		Password:         password,
+		SecurityType:     source.SecurityType,
+		KeyFormat:        source.KeyFormat,
+		IsHidden:         source.IsHidden,
+		LocationID:       req.LocationID,
+		ValidFrom:        source.ValidFrom,
+		ValidUntil:       source.ValidUntil,
+		RotationSchedule: source.RotationSchedule,
+		Notes:            notes,
+		CustomFields:     fields,
+		GeneratePassword: req.GeneratePassword,
+		PasswordPolicy:   req.PasswordPolicy,
+	}
+
+	// Tags and locations belong to a workspace, so they only carry over within it
+	if repositories.ScopeOf(userID, req.OrganizationID).Contains(source.UserID, source.OrganizationID) {
+		if create.LocationID == nil {
+			create.LocationID = source.LocationID
+		}
+		for _, tag := range source.Tags {
+			create.TagIDs = append(create.TagIDs, tag.ID)
+		}
+	}
+
+	credential, err := s.Create(userID, create)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if req.GeneratePassword {
+		return credential, create.Password, nil
+	}
+	return credential, "", nil
+}
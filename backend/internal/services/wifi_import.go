@@ -0,0 +1,404 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidImportFile = errors.New("invalid import file")
+	ErrImportEmpty       = errors.New("import file contains no WiFi credentials")
+	ErrImportTooLarge    = fmt.Errorf("import file must have at most %d rows", maxImportRows)
+)
+
+// maxImportRows bounds one import so it fits comfortably in a single transaction
+const maxImportRows = 1000
+
+// ImportFormat is the file format of an import or export
+type ImportFormat string
+
+const (
+	ImportFormatCSV       ImportFormat = "csv"
+	ImportFormatJSON      ImportFormat = "json"
+	ImportFormatBitwarden ImportFormat = "bitwarden" // Unencrypted Bitwarden JSON export
+	ImportFormatKeePass   ImportFormat = "keepass"   // KeePass 2.x XML
+	ImportFormat1Password ImportFormat = "1password" // 1Password CSV
+)
+
+// ImportMode decides what happens to the valid rows when some rows fail
+type ImportMode string
+
+const (
+	ImportModeAtomic  ImportMode = "atomic"  // Import every row or none
+	ImportModePartial ImportMode = "partial" // Import the valid rows and report the others
+)
+
+// ImportRowStatus is the outcome of one row of an import
+type ImportRowStatus string
+
+const (
+	ImportRowValid   ImportRowStatus = "valid"   // Dry run: the row would be imported
+	ImportRowCreated ImportRowStatus = "created" // The row was imported
+	ImportRowFailed  ImportRowStatus = "failed"  // The row is invalid or couldn't be saved
+	ImportRowSkipped ImportRowStatus = "skipped" // Atomic mode: the row is valid but another row failed
+)
+
+// ImportWifiRow is one credential of an import file
+type ImportWifiRow struct {
+	Row          int                 `json:"-"` // Line in a CSV file, position in a JSON array
+	SSID         string              `json:"ssid"`
+	Password     string              `json:"password"`
+	SecurityType models.SecurityType `json:"security_type"`
+	KeyFormat    models.KeyFormat    `json:"key_format"`
+	IsHidden     bool                `json:"is_hidden"`
+
+	// Guest credential settings, only read from JSON files and backups
+	ValidFrom        *time.Time `json:"valid_from,omitempty"`
+	ValidUntil       *time.Time `json:"valid_until,omitempty"`
+	RotationSchedule string     `json:"rotation_schedule,omitempty"`
+
+	Notes        string               `json:"notes,omitempty"`
+	CustomFields []models.CustomField `json:"custom_fields,omitempty"` // Only read from JSON files, backups and password manager exports
+}
+
+// ImportWifiOptions controls how an import runs
+type ImportWifiOptions struct {
+	Mode           ImportMode `form:"mode" binding:"omitempty,oneof=atomic partial"` // Defaults to atomic
+	DryRun         bool       `form:"dry_run"`                                       // Validate without saving anything
+	OrganizationID *uuid.UUID `form:"-"`                                             // Import into this organization
+}
+
+// ImportRowResult reports what happened to one row
+type ImportRowResult struct {
+	Row    int             `json:"row"`
+	SSID   string          `json:"ssid"`
+	Status ImportRowStatus `json:"status"`
+	ID     *uuid.UUID      `json:"id,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// ImportWifiResult summarises an import
+type ImportWifiResult struct {
+	Mode    ImportMode        `json:"mode"`
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// ParseWifiImport reads the rows of an import file.
+// CSV files need a header row naming the columns ssid, password, security_type and
+// optionally key_format and hidden (or is_hidden); other columns are ignored.
+// JSON files hold an array of objects with the same fields. Password manager
+// exports are read by the parsers in wifi_interop.go.
+func ParseWifiImport(format ImportFormat, r io.Reader) ([]ImportWifiRow, error) {
+	var rows []ImportWifiRow
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		rows, err = parseWifiImportCSV(r)
+	case ImportFormatJSON:
+		rows, err = parseWifiImportJSON(r)
+	case ImportFormatBitwarden:
+		rows, err = parseBitwardenImport(r)
+	case ImportFormatKeePass:
+		rows, err = parseKeePassImport(r)
+	case ImportFormat1Password:
+		rows, err = parse1PasswordImport(r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidImportFile, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(rows) > maxImportRows {
+		return nil, ErrImportTooLarge
+	}
+	return rows, nil
+}
+
+// parseWifiImportCSV reads a CSV import file with a header row
+func parseWifiImportCSV(r io.Reader) ([]ImportWifiRow, error) {
+	var rows []ImportWifiRow
+	err := readImportCSV(r, []string{"ssid", "security_type"}, func(line int, field func(string) string) error {
+		if len(rows) == maxImportRows {
+			return ErrImportTooLarge
+		}
+
+		hiddenValue := field("hidden")
+		if hiddenValue == "" {
+			hiddenValue = field("is_hidden")
+		}
+		hidden, err := parseImportBool(hiddenValue)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidImportFile, line, err)
+		}
+
+		rows = append(rows, ImportWifiRow{
+			Row:          line,
+			SSID:         field("ssid"),
+			Password:     field("password"),
+			SecurityType: models.SecurityType(strings.TrimSpace(field("security_type"))),
+			KeyFormat:    models.KeyFormat(strings.TrimSpace(field("key_format"))),
+			IsHidden:     hidden,
+			Notes:        field("notes"),
+		})
+		return nil
+	})
+	return rows, err
+}
+
+// readImportCSV reads a CSV file with a header row, calling fn with the line number of
+// each non-blank record and a lookup of its fields by lowercased column name
+func readImportCSV(r io.Reader, required []string, fn func(line int, field func(column string) string) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Spreadsheets often drop trailing empty cells
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return ErrImportEmpty
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet exports may start with a byte order mark
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, column := range required {
+		if _, ok := columns[column]; !ok {
+			return fmt.Errorf("%w: missing %s column", ErrInvalidImportFile, column)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+
+		// Skip blank lines left at the end of a spreadsheet
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+		if err := fn(line, field); err != nil {
+			return err
+		}
+	}
+}
+
+// parseWifiImportJSON reads a JSON array of import rows
+func parseWifiImportJSON(r io.Reader) ([]ImportWifiRow, error) {
+	var rows []ImportWifiRow
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	for i := range rows {
+		rows[i].Row = i + 1
+	}
+	return rows, nil
+}
+
+// parseImportBool accepts the ways spreadsheets write yes and no; empty means no
+func parseImportBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "no", "n":
+		return false, nil
+	case "yes", "y":
+		return true, nil
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, fmt.Errorf("invalid hidden value %q", value)
+	}
+	return parsed, nil
+}
+
+// Import creates a WiFi credential for every row, validating each with the same rules as Create.
+// In atomic mode nothing is saved unless every row is valid, and all rows are saved in one
+// transaction. In partial mode the valid rows are saved and the others reported.
+// The user's plan must have room for every valid row.
+func (s *WifiService) Import(userID uuid.UUID, rows []ImportWifiRow, opts ImportWifiOptions) (*ImportWifiResult, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportModeAtomic
+	}
+
+	scope, err := resolveScope(s.membershipRepo, userID, opts.OrganizationID, models.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportWifiResult{
+		Mode:   opts.Mode,
+		DryRun: opts.DryRun,
+		Total:  len(rows),
+		Rows:   make([]ImportRowResult, len(rows)),
+	}
+
+	credentials := make([]*models.WifiCredential, len(rows))
+	valid := 0
+	for i, row := range rows {
+		result.Rows[i] = ImportRowResult{Row: row.Row, SSID: row.SSID}
+
+		credential, _, err := s.newCredential(userID, scope, &CreateWifiRequest{
+			OrganizationID:   opts.OrganizationID,
+			SSID:             row.SSID,
+			Password:         row.Password,
+			SecurityType:     row.SecurityType,
+			KeyFormat:        row.KeyFormat,
+			IsHidden:         row.IsHidden,
+			ValidFrom:        row.ValidFrom,
+			ValidUntil:       row.ValidUntil,
+			RotationSchedule: row.RotationSchedule,
+			Notes:            row.Notes,
+			CustomFields:     row.CustomFields,
+		})
+		if err != nil {
+			result.Rows[i].Status = ImportRowFailed
+			result.Rows[i].Error = err.Error()
+			result.Failed++
+			continue
+		}
+
+		credentials[i] = credential
+		result.Rows[i].Status = ImportRowValid
+		valid++
+	}
+
+	if opts.Mode == ImportModeAtomic && result.Failed > 0 && !opts.DryRun {
+		markImportRows(result, ImportRowValid, ImportRowSkipped)
+		return result, nil
+	}
+
+	// Fail early when the rows can't fit; the writes check again in their transactions
+	if err := s.quotaService.CheckCredentials(scope, valid); err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if opts.Mode == ImportModeAtomic {
+		err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+			if err := s.quotaService.CheckCredentialsTx(tx, scope, len(credentials)); err != nil {
+				return err
+			}
+			for _, credential := range credentials {
+				if err := s.saveWithVersionTx(tx, credential, nil, models.VersionChangeCreated, &userID, nil, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for i, credential := range credentials {
+			result.Rows[i].Status = ImportRowCreated
+			result.Rows[i].ID = &credential.ID
+		}
+		result.Created = len(credentials)
+		return result, nil
+	}
+
+	for i, credential := range credentials {
+		if credential == nil {
+			continue
+		}
+		if err := s.createWithinQuota(scope, credential, &userID, nil); err != nil {
+			result.Rows[i].Status = ImportRowFailed
+			result.Rows[i].Error = err.Error()
+			result.Failed++
+			continue
+		}
+		result.Rows[i].Status = ImportRowCreated
+		result.Rows[i].ID = &credential.ID
+		result.Created++
+	}
+	return result, nil
+}
+
+// exportRows returns the personal WiFi credentials of a user as import rows with
+// their passwords, notes and custom fields decrypted, for exports that can be imported again
+func (s *WifiService) exportRows(userID uuid.UUID) ([]ImportWifiRow, error) {
+	credentials, err := s.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ImportWifiRow, 0, len(credentials))
+	for i := range credentials {
+		row, err := s.exportRow(&credentials[i])
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, *row)
+	}
+	return rows, nil
+}
+
+// exportRow returns a credential as an import row with its secrets decrypted
+func (s *WifiService) exportRow(credential *models.WifiCredential) (*ImportWifiRow, error) {
+	password, err := s.DecryptPassword(credential.EncryptedPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	notes, fields, err := s.DecryptNotes(credential)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportWifiRow{
+		SSID:             credential.SSID,
+		Password:         password,
+		SecurityType:     credential.SecurityType,
+		KeyFormat:        credential.KeyFormat,
+		IsHidden:         credential.IsHidden,
+		ValidFrom:        credential.ValidFrom,
+		ValidUntil:       credential.ValidUntil,
+		RotationSchedule: credential.RotationSchedule,
+		Notes:            notes,
+		CustomFields:     fields,
+	}, nil
+}
+
+// markImportRows changes the status of every row with status from to to
+func markImportRows(result *ImportWifiResult, from, to ImportRowStatus) {
+	for i := range result.Rows {
+		if result.Rows[i].Status == from {
+			result.Rows[i].Status = to
+		}
+	}
+}
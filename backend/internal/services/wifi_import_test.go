@@ -0,0 +1,321 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestReadImportCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string // "line:ssid:security_type:hidden" for each record passed on
+		wantErr error
+	}{
+		{
+			name:  "plain header",
+			input: "ssid,password,security_type\nHome,secret123,WPA2\nGuest,,nopass\n",
+			want:  []string{"2:Home:WPA2:", "3:Guest:nopass:"},
+		},
+		{
+			name:  "byte order mark, case and spaces in header",
+			input: "\ufeffSSID, Password ,Security_Type\nHome,secret123,WPA2\n",
+			want:  []string{"2:Home:WPA2:"},
+		},
+		{
+			name:  "columns in any order and unknown columns ignored",
+			input: "comment,security_type,ssid\nfront desk,WPA,Lobby\n",
+			want:  []string{"2:Lobby:WPA:"},
+		},
+		{
+			name:  "blank lines and rows of empty cells skipped",
+			input: "ssid,security_type\n\nHome,WPA2\n,,\n \nOffice,WPA2\n,\n\n",
+			want:  []string{"3:Home:WPA2:", "6:Office:WPA2:"},
+		},
+		{
+			name:  "trailing empty cells dropped by spreadsheets",
+			input: "ssid,security_type,hidden\nHome,WPA2\nAttic,WPA2,yes\n",
+			want:  []string{"2:Home:WPA2:", "3:Attic:WPA2:yes"},
+		},
+		{
+			name:  "quoted fields with commas and line breaks",
+			input: "ssid,security_type\n\"Cafe, upstairs\",WPA2\n\"Two\nlines\",WPA\n",
+			want:  []string{"2:Cafe, upstairs:WPA2:", "3:Two\nlines:WPA:"},
+		},
+		{
+			name:  "header only",
+			input: "ssid,security_type\n",
+			want:  nil,
+		},
+		{
+			name:    "empty file",
+			input:   "",
+			wantErr: ErrImportEmpty,
+		},
+		{
+			name:    "missing required column",
+			input:   "ssid,password\nHome,secret123\n",
+			wantErr: ErrInvalidImportFile,
+		},
+		{
+			name:    "unbalanced quote",
+			input:   "ssid,security_type\n\"Home,WPA2\n",
+			wantErr: ErrInvalidImportFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := readImportCSV(strings.NewReader(tt.input), []string{"ssid", "security_type"}, func(line int, field func(string) string) error {
+				got = append(got, fmt.Sprintf("%d:%s:%s:%s", line, field("ssid"), field("security_type"), field("hidden")))
+				return nil
+			})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("records = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportBool(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "", want: false},
+		{value: "   ", want: false},
+		{value: "no", want: false},
+		{value: "N", want: false},
+		{value: "false", want: false},
+		{value: "FALSE", want: false},
+		{value: "0", want: false},
+		{value: "yes", want: true},
+		{value: " Yes ", want: true},
+		{value: "y", want: true},
+		{value: "true", want: true},
+		{value: "TRUE", want: true},
+		{value: "1", want: true},
+		{value: "maybe", wantErr: true},
+		{value: "2", wantErr: true},
+		{value: "nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.value), func(t *testing.T) {
+			got, err := parseImportBool(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseImportBool(%q) = %v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseImportBool(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportAtomicSkipsValidRowsWhenOneFails(t *testing.T) {
+	service, mock := newImportTestService(t)
+
+	rows := []ImportWifiRow{
+		{Row: 2, SSID: "Home", Password: "correct horse battery", SecurityType: models.SecurityWPA2},
+		{Row: 3, SSID: "Broken", Password: "short", SecurityType: models.SecurityWPA2},
+		{Row: 4, SSID: "Guest", SecurityType: models.SecurityNone},
+		{Row: 5, SSID: "Lab", Password: "correct horse battery", SecurityType: "WPA9"},
+	}
+
+	// No query may run: a failed row must stop the import before the quota check
+	result, err := service.Import(uuid.New(), rows, ImportWifiOptions{Mode: ImportModeAtomic})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	want := []ImportRowStatus{ImportRowSkipped, ImportRowFailed, ImportRowSkipped, ImportRowFailed}
+	assertImportStatuses(t, result, want)
+	if result.Created != 0 || result.Failed != 2 {
+		t.Errorf("created %d, failed %d, want 0 and 2", result.Created, result.Failed)
+	}
+	for _, row := range result.Rows {
+		if row.ID != nil {
+			t.Errorf("row %d has ID %s, want none", row.Row, row.ID)
+		}
+		if (row.Status == ImportRowFailed) != (row.Error != "") {
+			t.Errorf("row %d status %s has error %q", row.Row, row.Status, row.Error)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportDryRunSavesNothing(t *testing.T) {
+	tests := []struct {
+		name string
+		mode ImportMode
+		rows []ImportWifiRow
+		want []ImportRowStatus
+	}{
+		{
+			name: "atomic, all valid",
+			mode: ImportModeAtomic,
+			rows: []ImportWifiRow{
+				{Row: 2, SSID: "Home", Password: "correct horse battery", SecurityType: models.SecurityWPA2},
+				{Row: 3, SSID: "Guest", SecurityType: models.SecurityNone},
+			},
+			want: []ImportRowStatus{ImportRowValid, ImportRowValid},
+		},
+		{
+			name: "atomic, one failed",
+			mode: ImportModeAtomic,
+			rows: []ImportWifiRow{
+				{Row: 2, SSID: "Home", Password: "correct horse battery", SecurityType: models.SecurityWPA2},
+				{Row: 3, SSID: "Broken", Password: "short", SecurityType: models.SecurityWPA2},
+			},
+			want: []ImportRowStatus{ImportRowValid, ImportRowFailed},
+		},
+		{
+			name: "partial, one failed",
+			mode: ImportModePartial,
+			rows: []ImportWifiRow{
+				{Row: 2, SSID: "Broken", Password: "short", SecurityType: models.SecurityWPA2},
+				{Row: 3, SSID: "Home", Password: "correct horse battery", SecurityType: models.SecurityWPA2},
+				{Row: 4, SSID: "Office", Password: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", SecurityType: models.SecurityWPA2, KeyFormat: models.KeyFormatHex},
+			},
+			want: []ImportRowStatus{ImportRowFailed, ImportRowValid, ImportRowValid},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newImportTestService(t)
+			userID := uuid.New()
+
+			// The quota check reads the user and counts their credentials, and nothing else runs
+			mock.ExpectQuery(regexp.QuoteMeta(`FROM "users"`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email", "plan"}).AddRow(userID, "user@example.com", models.PlanFree))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "wifi_qr_codes"`)).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+			result, err := service.Import(userID, tt.rows, ImportWifiOptions{Mode: tt.mode, DryRun: true})
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			assertImportStatuses(t, result, tt.want)
+			if !result.DryRun || result.Created != 0 {
+				t.Errorf("dry run %v created %d, want a dry run creating nothing", result.DryRun, result.Created)
+			}
+			for _, row := range result.Rows {
+				if row.ID != nil {
+					t.Errorf("row %d has ID %s, want none", row.Row, row.ID)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestImportDryRunChecksQuota(t *testing.T) {
+	service, mock := newImportTestService(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM "users"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "plan"}).AddRow(userID, "user@example.com", models.PlanFree))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "wifi_qr_codes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(importTestFreeCredentials - 1))
+
+	rows := []ImportWifiRow{
+		{Row: 2, SSID: "Home", Password: "correct horse battery", SecurityType: models.SecurityWPA2},
+		{Row: 3, SSID: "Guest", SecurityType: models.SecurityNone},
+	}
+	_, err := service.Import(userID, rows, ImportWifiOptions{DryRun: true})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("error = %v, want ErrQuotaExceeded", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// importTestFreeCredentials is the credential limit of the free plan in import tests
+const importTestFreeCredentials = 25
+
+// newImportTestService returns a WiFi service on a mocked database. Queries that
+// weren't expected fail.
+func newImportTestService(t *testing.T) (*WifiService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	wifiRepo := repositories.NewWifiRepository(db)
+	quotaService := NewQuotaService(repositories.NewUserRepository(db), repositories.NewOrganizationRepository(db), wifiRepo,
+		repositories.NewShareLinkRepository(db), repositories.NewAuditLogRepository(db), map[models.Plan]PlanLimits{
+			models.PlanFree: {MaxCredentials: importTestFreeCredentials},
+		})
+	passwordGenerator := NewPasswordGenerator()
+
+	service := NewWifiService(wifiRepo, repositories.NewWifiVersionRepository(db), repositories.NewTagRepository(db),
+		repositories.NewLocationRepository(db), repositories.NewCredentialGrantRepository(db), repositories.NewMembershipRepository(db),
+		repositories.NewTransactor(db), quotaService, NewQRCodeService(), passwordGenerator, NewPasswordChecker(passwordGenerator),
+		NewCredentialEvents(), "0123456789abcdef0123456789abcdef")
+	return service, mock
+}
+
+// assertImportStatuses checks the status of every row of an import result
+func assertImportStatuses(t *testing.T, result *ImportWifiResult, want []ImportRowStatus) {
+	t.Helper()
+
+	if len(result.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(result.Rows), len(want))
+	}
+	for i, row := range result.Rows {
+		if row.Status != want[i] {
+			t.Errorf("row %d (%s): status %s, want %s (error %q)", row.Row, row.SSID, row.Status, want[i], row.Error)
+		}
+	}
+}
@@ -0,0 +1,566 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnsupportedExportFormat = errors.New("export format must be bitwarden, keepass or 1password")
+
+// Password managers have no common WiFi item type, so credentials travel as ordinary
+// entries carrying these custom fields. Importers look for any of the names
+// listed for a field, case-insensitively, and skip entries without an SSID.
+// Any other non-empty field of an entry is imported as a custom field.
+const (
+	interopFieldSSID      = "SSID"
+	interopFieldSecurity  = "Security"
+	interopFieldKeyFormat = "Key Format"
+	interopFieldHidden    = "Hidden"
+)
+
+// maxInteropEntries bounds the entries read from a password manager export, which
+// may hold many more entries than the WiFi credentials among them
+const maxInteropEntries = 4 * maxImportRows
+
+var (
+	interopSSIDNames      = []string{"ssid", "network name", "network"}
+	interopSecurityNames  = []string{"security", "security type", "wireless security"}
+	interopKeyFormatNames = []string{"key format"}
+	interopHiddenNames    = []string{"hidden", "hidden network"}
+
+	// Standard KeePass fields, which are never read as custom fields either
+	interopStandardNames = []string{"title", "username", "password", "url", "notes"}
+)
+
+// ExportFile is a credential export ready for download
+type ExportFile struct {
+	Data        []byte
+	ContentType string
+	Filename    string
+}
+
+// ExportWifiRequest represents a request to download credentials for another password
+// manager. Password may be omitted when the caller's token was issued recently.
+type ExportWifiRequest struct {
+	Format   ImportFormat `json:"format" binding:"required"` // bitwarden, keepass or 1password
+	Password string       `json:"password"`                  // Account password, as for revealing a password
+}
+
+// ExportService exports credentials in the formats of other password managers
+type ExportService struct {
+	wifiService   *WifiService
+	quotaService  *QuotaService
+	revealService *RevealService
+}
+
+// NewExportService creates a new export service
+func NewExportService(wifiService *WifiService, quotaService *QuotaService, revealService *RevealService) *ExportService {
+	return &ExportService{
+		wifiService:   wifiService,
+		quotaService:  quotaService,
+		revealService: revealService,
+	}
+}
+
+// Export writes all personal WiFi credentials of a user, with their passwords, in the
+// requested password manager format. The file isn't encrypted, so the user must
+// re-authenticate as for revealing a password. It counts against the daily export limit.
+func (s *ExportService) Export(ec ExportContext, req *ExportWifiRequest) (*ExportFile, error) {
+	format := req.Format
+	if err := s.revealService.Reauthenticate(&RevealPasswordRequest{Password: req.Password}, ec.revealContext(), string(format)+"_export"); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.wifiService.exportRows(ec.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ExportFile{}
+	date := time.Now().UTC().Format("2006-01-02")
+	switch format {
+	case ImportFormatBitwarden:
+		file.Data, err = exportBitwarden(rows)
+		file.ContentType = "application/json"
+		file.Filename = "wifiqr-bitwarden-" + date + ".json"
+	case ImportFormatKeePass:
+		file.Data, err = exportKeePass(rows)
+		file.ContentType = "application/xml"
+		file.Filename = "wifiqr-keepass-" + date + ".xml"
+	case ImportFormat1Password:
+		file.Data, err = export1Password(rows)
+		file.ContentType = "text/csv"
+		file.Filename = "wifiqr-1password-" + date + ".csv"
+	default:
+		return nil, ErrUnsupportedExportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only count the export once the file is ready
+	if err := s.quotaService.RecordExport(ec, string(format)+"_export", nil); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// interopEntry turns the fields of a password manager entry into an import row.
+// fields maps lowercased field names to values. It reports false for entries
+// without an SSID, which aren't WiFi credentials.
+func interopEntry(row int, fields map[string]string, password, notes string, custom []models.CustomField) (ImportWifiRow, bool, error) {
+	ssid := firstField(fields, interopSSIDNames)
+	if ssid == "" {
+		return ImportWifiRow{}, false, nil
+	}
+
+	hidden, err := parseImportBool(firstField(fields, interopHiddenNames))
+	if err != nil {
+		return ImportWifiRow{}, false, fmt.Errorf("%w: entry %d: %v", ErrInvalidImportFile, row, err)
+	}
+
+	return ImportWifiRow{
+		Row:          row,
+		SSID:         ssid,
+		Password:     password,
+		SecurityType: interopSecurityType(firstField(fields, interopSecurityNames), password),
+		KeyFormat:    models.KeyFormat(strings.ToLower(firstField(fields, interopKeyFormatNames))),
+		IsHidden:     hidden,
+		Notes:        notes,
+		CustomFields: custom,
+	}, true, nil
+}
+
+// isInteropField reports whether name is one of the fields interop files use for the
+// credential itself, so it can't round-trip as a custom field
+func isInteropField(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, names := range [][]string{interopSSIDNames, interopSecurityNames, interopKeyFormatNames, interopHiddenNames, interopStandardNames} {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// interopNotes splits the custom fields of a row into those an export can write as
+// fields and the rest, which are appended to the notes as "key: value" lines
+func interopNotes(row ImportWifiRow, allowFields bool) (string, []models.CustomField) {
+	var lines []string
+	if row.Notes != "" {
+		lines = append(lines, row.Notes)
+	}
+
+	var fields []models.CustomField
+	for _, field := range row.CustomFields {
+		if allowFields && !isInteropField(field.Key) {
+			fields = append(fields, field)
+			continue
+		}
+		lines = append(lines, field.Key+": "+field.Value)
+	}
+	return strings.Join(lines, "\n"), fields
+}
+
+// firstField returns the first non-empty value among the given field names
+func firstField(fields map[string]string, names []string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(fields[name]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// interopSecurityType maps the security labels other tools use ("WPA2 Personal",
+// "WPA/WPA2", "WPA3", "None", ...) to a security type. Without a label, networks with
+// a password are assumed to use WPA2. Unknown labels are kept so validation reports them.
+func interopSecurityType(label, password string) models.SecurityType {
+	upper := strings.ToUpper(label)
+	switch {
+	case label == "":
+		if password == "" {
+			return models.SecurityNone
+		}
+		return models.SecurityWPA2
+	case models.IsValidSecurityType(label):
+		return models.SecurityType(label)
+	case strings.Contains(upper, "WPA2"), strings.Contains(upper, "WPA3"):
+		return models.SecurityWPA2
+	case strings.Contains(upper, "WPA"):
+		return models.SecurityWPA
+	case strings.Contains(upper, "WEP"):
+		return models.SecurityWEP
+	case upper == "NONE", upper == "OPEN", upper == "NOPASS":
+		return models.SecurityNone
+	}
+	return models.SecurityType(label)
+}
+
+// Bitwarden
+
+// bitwardenExport is the unencrypted JSON export of a Bitwarden vault
+type bitwardenExport struct {
+	Encrypted bool              `json:"encrypted"`
+	Folders   []bitwardenFolder `json:"folders"`
+	Items     []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	ID       string           `json:"id"`
+	FolderID *string          `json:"folderId"`
+	Type     int              `json:"type"`
+	Name     string           `json:"name"`
+	Notes    *string          `json:"notes"`
+	Favorite bool             `json:"favorite"`
+	Fields   []bitwardenField `json:"fields"`
+	Login    *bitwardenLogin  `json:"login,omitempty"`
+}
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+type bitwardenLogin struct {
+	Username *string       `json:"username"`
+	Password string        `json:"password"`
+	URIs     []interface{} `json:"uris"`
+}
+
+// Bitwarden item and custom field types
+const (
+	bitwardenTypeLogin    = 1
+	bitwardenFieldText    = 0
+	bitwardenFieldBoolean = 2
+)
+
+// exportBitwarden writes rows as login items with WiFi custom fields in a "WiFi" folder
+func exportBitwarden(rows []ImportWifiRow) ([]byte, error) {
+	folderID := uuid.NewString()
+	export := bitwardenExport{
+		Folders: []bitwardenFolder{{ID: folderID, Name: "WiFi"}},
+		Items:   make([]bitwardenItem, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		fields := []bitwardenField{
+			{Name: interopFieldSSID, Value: row.SSID, Type: bitwardenFieldText},
+			{Name: interopFieldSecurity, Value: string(row.SecurityType), Type: bitwardenFieldText},
+			{Name: interopFieldHidden, Value: strconv.FormatBool(row.IsHidden), Type: bitwardenFieldBoolean},
+		}
+		if row.KeyFormat == models.KeyFormatHex {
+			fields = append(fields, bitwardenField{Name: interopFieldKeyFormat, Value: string(row.KeyFormat), Type: bitwardenFieldText})
+		}
+		notes, custom := interopNotes(row, true)
+		for _, field := range custom {
+			fields = append(fields, bitwardenField{Name: field.Key, Value: field.Value, Type: bitwardenFieldText})
+		}
+
+		item := bitwardenItem{
+			ID:       uuid.NewString(),
+			FolderID: &folderID,
+			Type:     bitwardenTypeLogin,
+			Name:     row.SSID,
+			Fields:   fields,
+			Login:    &bitwardenLogin{Password: row.Password, URIs: []interface{}{}},
+		}
+		if notes != "" {
+			item.Notes = &notes
+		}
+		export.Items = append(export.Items, item)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Bitwarden export: %w", err)
+	}
+	return data, nil
+}
+
+// parseBitwardenImport reads the login items with an SSID field from a Bitwarden JSON export
+func parseBitwardenImport(r io.Reader) ([]ImportWifiRow, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	if export.Encrypted {
+		return nil, fmt.Errorf("%w: encrypted Bitwarden exports aren't supported, export as unencrypted JSON", ErrInvalidImportFile)
+	}
+	if len(export.Items) > maxInteropEntries {
+		return nil, ErrImportTooLarge
+	}
+
+	var rows []ImportWifiRow
+	for i, item := range export.Items {
+		if item.Type != bitwardenTypeLogin || item.Login == nil {
+			continue
+		}
+
+		fields := make(map[string]string, len(item.Fields))
+		var custom []models.CustomField
+		for _, field := range item.Fields {
+			fields[strings.ToLower(strings.TrimSpace(field.Name))] = field.Value
+			if !isInteropField(field.Name) && field.Value != "" {
+				custom = append(custom, models.CustomField{Key: field.Name, Value: field.Value})
+			}
+		}
+		notes := ""
+		if item.Notes != nil {
+			notes = *item.Notes
+		}
+
+		row, ok, err := interopEntry(i+1, fields, item.Login.Password, notes, custom)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// KeePass
+
+// keePassFile is the KeePass 2.x XML format, which KeePass and KeePassXC import
+type keePassFile struct {
+	XMLName xml.Name    `xml:"KeePassFile"`
+	Meta    keePassMeta `xml:"Meta"`
+	Root    keePassRoot `xml:"Root"`
+}
+
+type keePassMeta struct {
+	Generator string `xml:"Generator"`
+}
+
+type keePassRoot struct {
+	Group keePassGroup `xml:"Group"`
+}
+
+type keePassGroup struct {
+	UUID    string         `xml:"UUID,omitempty"`
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+	Groups  []keePassGroup `xml:"Group"`
+}
+
+type keePassEntry struct {
+	UUID    string          `xml:"UUID,omitempty"`
+	Strings []keePassString `xml:"String"`
+}
+
+type keePassString struct {
+	Key   string       `xml:"Key"`
+	Value keePassValue `xml:"Value"`
+}
+
+type keePassValue struct {
+	ProtectInMemory string `xml:"ProtectInMemory,attr,omitempty"`
+	Protected       string `xml:"Protected,attr,omitempty"` // Encrypted with the inner stream of a KDBX file
+	Text            string `xml:",chardata"`
+}
+
+// exportKeePass writes rows as entries with WiFi string fields in a "WiFi" group
+func exportKeePass(rows []ImportWifiRow) ([]byte, error) {
+	group := keePassGroup{
+		UUID:    keePassUUID(),
+		Name:    "WiFi",
+		Entries: make([]keePassEntry, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		notes, custom := interopNotes(row, true)
+		strs := []keePassString{
+			{Key: "Title", Value: keePassValue{Text: row.SSID}},
+			{Key: "UserName", Value: keePassValue{}},
+			{Key: "Password", Value: keePassValue{ProtectInMemory: "True", Text: row.Password}},
+			{Key: "URL", Value: keePassValue{}},
+			{Key: "Notes", Value: keePassValue{Text: notes}},
+			{Key: interopFieldSSID, Value: keePassValue{Text: row.SSID}},
+			{Key: interopFieldSecurity, Value: keePassValue{Text: string(row.SecurityType)}},
+			{Key: interopFieldHidden, Value: keePassValue{Text: strconv.FormatBool(row.IsHidden)}},
+		}
+		if row.KeyFormat == models.KeyFormatHex {
+			strs = append(strs, keePassString{Key: interopFieldKeyFormat, Value: keePassValue{Text: string(row.KeyFormat)}})
+		}
+		for _, field := range custom {
+			strs = append(strs, keePassString{Key: field.Key, Value: keePassValue{Text: field.Value}})
+		}
+		group.Entries = append(group.Entries, keePassEntry{UUID: keePassUUID(), Strings: strs})
+	}
+
+	file := keePassFile{
+		Meta: keePassMeta{Generator: "WiFi QR"},
+		Root: keePassRoot{Group: group},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="utf-8" standalone="yes"?>` + "\n")
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(file); err != nil {
+		return nil, fmt.Errorf("failed to encode KeePass export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// keePassUUID returns a random UUID encoded the way KeePass XML stores them
+func keePassUUID() string {
+	id := uuid.New()
+	return base64.StdEncoding.EncodeToString(id[:])
+}
+
+// parseKeePassImport reads the entries with an SSID field from a KeePass 2.x XML file,
+// in any group
+func parseKeePassImport(r io.Reader) ([]ImportWifiRow, error) {
+	var file keePassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	var rows []ImportWifiRow
+	entry := 0
+	var walk func(group keePassGroup) error
+	walk = func(group keePassGroup) error {
+		for _, e := range group.Entries {
+			entry++
+			if entry > maxInteropEntries {
+				return ErrImportTooLarge
+			}
+
+			fields := make(map[string]string, len(e.Strings))
+			var custom []models.CustomField
+			for _, str := range e.Strings {
+				if strings.EqualFold(str.Value.Protected, "True") {
+					return fmt.Errorf("%w: protected values aren't supported, export as KeePass XML (2.x)", ErrInvalidImportFile)
+				}
+				fields[strings.ToLower(strings.TrimSpace(str.Key))] = str.Value.Text
+				if !isInteropField(str.Key) && str.Value.Text != "" {
+					custom = append(custom, models.CustomField{Key: str.Key, Value: str.Value.Text})
+				}
+			}
+
+			row, ok, err := interopEntry(entry, fields, fields["password"], fields["notes"], custom)
+			if err != nil {
+				return err
+			}
+			if ok {
+				rows = append(rows, row)
+			}
+		}
+		for _, child := range group.Groups {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(file.Root.Group); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// 1Password
+
+// onePasswordColumns are the CSV columns of an export, named after the fields of
+// 1Password's Wireless Router item so its CSV importer can map them
+var onePasswordColumns = []string{"Title", "Network Name", "Wireless Security", "Wireless Network Password", "Hidden", "Key Format", "Notes"}
+
+// export1Password writes rows as a 1Password CSV file. Custom fields have no column,
+// so they are appended to the notes. Cells that a spreadsheet would run as a formula
+// are escaped.
+func export1Password(rows []ImportWifiRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(onePasswordColumns); err != nil {
+		return nil, fmt.Errorf("failed to encode 1Password export: %w", err)
+	}
+	for _, row := range rows {
+		keyFormat := ""
+		if row.KeyFormat == models.KeyFormatHex {
+			keyFormat = string(row.KeyFormat)
+		}
+		notes, _ := interopNotes(row, false)
+		record := []string{row.SSID, row.SSID, string(row.SecurityType), row.Password, strconv.FormatBool(row.IsHidden), keyFormat, notes}
+		for i, cell := range record {
+			record[i] = escapeCSVFormula(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to encode 1Password export: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode 1Password export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeCSVFormula prefixes a cell starting with a formula character with an
+// apostrophe, so spreadsheet applications opening the export show it as text
+// instead of evaluating it
+func escapeCSVFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// parse1PasswordImport reads the rows with a network name from a 1Password CSV file.
+// The password comes from "Wireless Network Password", or "Password" in login exports.
+func parse1PasswordImport(r io.Reader) ([]ImportWifiRow, error) {
+	var rows []ImportWifiRow
+	err := readImportCSV(r, nil, func(line int, field func(string) string) error {
+		if len(rows) == maxImportRows {
+			return ErrImportTooLarge
+		}
+
+		fields := make(map[string]string)
+		for _, names := range [][]string{interopSSIDNames, interopSecurityNames, interopKeyFormatNames, interopHiddenNames} {
+			for _, name := range names {
+				fields[name] = field(name)
+			}
+		}
+
+		password := field("wireless network password")
+		if password == "" {
+			password = field("password")
+		}
+
+		row, ok, err := interopEntry(line, fields, password, field("notes"), nil)
+		if err != nil {
+			return err
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+		return nil
+	})
+	return rows, err
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"gin-quickstart/internal/models"
+)
+
+func TestEscapeCSVFormula(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{cell: "", want: ""},
+		{cell: "Home", want: "Home"},
+		{cell: "=HYPERLINK(\"http://example.com\")", want: "'=HYPERLINK(\"http://example.com\")"},
+		{cell: "+1234", want: "'+1234"},
+		{cell: "-2+3", want: "'-2+3"},
+		{cell: "@SUM(A1)", want: "'@SUM(A1)"},
+		{cell: "\t=1", want: "'\t=1"},
+		{cell: "\r=1", want: "'\r=1"},
+		{cell: "a=b", want: "a=b"},
+		{cell: "'quoted", want: "'quoted"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeCSVFormula(tt.cell); got != tt.want {
+			t.Errorf("escapeCSVFormula(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestExport1PasswordEscapesFormulas(t *testing.T) {
+	data, err := export1Password([]ImportWifiRow{{
+		SSID:         "=cmd|'/C calc'!A0",
+		Password:     "-secret",
+		SecurityType: models.SecurityWPA2,
+		Notes:        "@admin",
+	}})
+	if err != nil {
+		t.Fatalf("export1Password: %v", err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want header and one row", len(records))
+	}
+	want := []string{"'=cmd|'/C calc'!A0", "'=cmd|'/C calc'!A0", "WPA2", "'-secret", "false", "", "'@admin"}
+	for i, cell := range records[1] {
+		if cell != want[i] {
+			t.Errorf("column %s = %q, want %q", records[0][i], cell, want[i])
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+)
+
+var (
+	ErrPasswordRequired   = errors.New("password is required for secured networks")
+	ErrPasswordNotAllowed = errors.New("open networks must not have a password")
+	ErrInvalidPassphrase  = errors.New("WPA passphrase must be 8-63 printable ASCII characters")
+	ErrInvalidPSK         = errors.New("raw WPA PSK must be exactly 64 hexadecimal characters")
+	ErrInvalidWEPKey      = errors.New("WEP key must be 5, 13 or 16 ASCII characters, or 10, 26 or 32 hexadecimal characters")
+	ErrInvalidKeyFormat   = errors.New("invalid key format")
+)
+
+const (
+	// pskIterations and pskLength are fixed by IEEE 802.11i for WPA-PSK
+	pskIterations = 4096
+	pskLength     = 32
+)
+
+// DerivePSKRequest represents a request to derive a WPA PSK
+type DerivePSKRequest struct {
+	SSID       string `json:"ssid" binding:"required,min=1"`
+	Passphrase string `json:"passphrase" binding:"required"`
+}
+
+// DerivePSKResponse contains the derived PSK in the 64-hex-digit form used by AP configs
+type DerivePSKResponse struct {
+	SSID string `json:"ssid"`
+	PSK  string `json:"psk"`
+}
+
+// DerivePSK derives the 256-bit WPA pre-shared key from a passphrase and SSID
+// using PBKDF2-HMAC-SHA1 with 4096 iterations, as AP configs (e.g. wpa_psk=) expect
+func DerivePSK(req *DerivePSKRequest) (*DerivePSKResponse, error) {
+	if err := validateSSID(req.SSID); err != nil {
+		return nil, err
+	}
+	if !isValidPassphrase(req.Passphrase) {
+		return nil, ErrInvalidPassphrase
+	}
+
+	key, err := pbkdf2.Key(sha1.New, req.Passphrase, []byte(req.SSID), pskIterations, pskLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive PSK: %w", err)
+	}
+
+	return &DerivePSKResponse{
+		SSID: req.SSID,
+		PSK:  hex.EncodeToString(key),
+	}, nil
+}
+
+// validateWifiKey checks that a password is valid for the security type and key format
+func validateWifiKey(security models.SecurityType, format models.KeyFormat, password string) error {
+	if !models.IsValidKeyFormat(string(format)) {
+		return ErrInvalidKeyFormat
+	}
+
+	switch security {
+	case models.SecurityNone:
+		if password != "" {
+			return ErrPasswordNotAllowed
+		}
+		return nil
+
+	case models.SecurityWPA, models.SecurityWPA2:
+		if password == "" {
+			return ErrPasswordRequired
+		}
+		if format == models.KeyFormatHex {
+			if len(password) != 64 || !isHexDigits(password) {
+				return ErrInvalidPSK
+			}
+			return nil
+		}
+		if !isValidPassphrase(password) {
+			return ErrInvalidPassphrase
+		}
+		return nil
+
+	case models.SecurityWEP:
+		if password == "" {
+			return ErrPasswordRequired
+		}
+		if format == models.KeyFormatHex {
+			switch len(password) {
+			case 10, 26, 32:
+				if isHexDigits(password) {
+					return nil
+				}
+			}
+			return ErrInvalidWEPKey
+		}
+		switch len(password) {
+		case 5, 13, 16:
+			if isPrintableASCII(password) {
+				return nil
+			}
+		}
+		return ErrInvalidWEPKey
+
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidSecurityType, security)
+	}
+}
+
+// isValidPassphrase checks the WPA passphrase rules: 8-63 printable ASCII characters
+func isValidPassphrase(passphrase string) bool {
+	return len(passphrase) >= 8 && len(passphrase) <= 63 && isPrintableASCII(passphrase)
+}
+
+// isPrintableASCII reports whether s only contains characters 0x20-0x7E
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+// isHexDigits reports whether s only contains hexadecimal digits
+func isHexDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
+			return false
+		}
+	}
+	return true
+}
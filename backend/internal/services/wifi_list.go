@@ -0,0 +1,256 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid or expired cursor")
+	ErrInvalidFilter = errors.New("tag, location_id and organization_id must be valid UUIDs")
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+
+	// cursorTimeLayout is RFC 3339 with the offset, at the microsecond precision of
+	// PostgreSQL timestamps
+	cursorTimeLayout = "2006-01-02T15:04:05.999999Z07:00"
+)
+
+// ListWifiRequest holds the query parameters for listing WiFi credentials
+type ListWifiRequest struct {
+	Limit        int                 `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor       string              `form:"cursor"`
+	Sort         string              `form:"sort" binding:"omitempty,oneof=created_at updated_at ssid"`
+	Order        string              `form:"order" binding:"omitempty,oneof=asc desc"`
+	SecurityType models.SecurityType `form:"security_type" binding:"omitempty,oneof=WPA WPA2 WEP nopass"`
+	IsHidden     *bool               `form:"is_hidden"`
+	Query        string              `form:"q" binding:"max=64"`
+	Tags         []string            `form:"tag" binding:"max=10"` // Tag IDs; credentials must carry all of them
+	LocationID   string              `form:"location_id"`          // Includes nested locations
+	Organization string              `form:"organization_id"`      // List this organization's credentials instead of personal ones
+}
+
+// Pagination describes where a page sits in the full result set
+type Pagination struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// ListWifiResult is one page of WiFi credentials
+type ListWifiResult struct {
+	Credentials []models.WifiCredential
+	Pagination  Pagination
+
+	// Permission is the caller's access to every credential of a List page
+	Permission models.GrantPermission
+
+	// Permissions holds the caller's grant on each credential; only set by ListSharedWith
+	Permissions map[uuid.UUID]models.GrantPermission
+}
+
+// listCursor is the opaque cursor handed to clients. It records the sort it was
+// issued for so it can't be replayed against a different ordering.
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// List returns a page of the user's personal WiFi credentials, or of an organization's
+// credentials when req.Organization is set (any member). Pass a nil userID to list
+// every credential (admin only); req.Organization then narrows it to one organization.
+func (s *WifiService) List(userID *uuid.UUID, req *ListWifiRequest) (*ListWifiResult, error) {
+	var organizationID *uuid.UUID
+	if req.Organization != "" {
+		id, err := uuid.Parse(req.Organization)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		organizationID = &id
+	}
+
+	// Owners and admins have full access, organization members that of their role
+	permission := models.GrantEdit
+	filter := repositories.WifiListFilter{PreloadUser: userID == nil}
+	if userID != nil {
+		if organizationID != nil {
+			membership, err := requireOrgRole(s.membershipRepo, *organizationID, *userID, models.OrgRoleViewer)
+			if err != nil {
+				return nil, err
+			}
+			permission = membership.Role.CredentialPermission()
+		}
+		scope := repositories.ScopeOf(*userID, organizationID)
+		filter.Scope = &scope
+	} else if organizationID != nil {
+		filter.Scope = &repositories.Scope{OrganizationID: organizationID}
+	}
+
+	result, err := s.list(filter, req)
+	if err != nil {
+		return nil, err
+	}
+	result.Permission = permission
+	return result, nil
+}
+
+// ListSharedWith returns a page of the WiFi credentials other users have granted userID access to,
+// with the permission held on each
+func (s *WifiService) ListSharedWith(userID uuid.UUID, req *ListWifiRequest) (*ListWifiResult, error) {
+	result, err := s.list(repositories.WifiListFilter{SharedWith: &userID, PreloadUser: true}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(result.Credentials))
+	for i := range result.Credentials {
+		ids[i] = result.Credentials[i].ID
+	}
+	grants, err := s.grantRepo.FindByGranteeAndCredentialIDs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Permissions = make(map[uuid.UUID]models.GrantPermission, len(grants))
+	for _, grant := range grants {
+		result.Permissions[grant.CredentialID] = grant.Permission
+	}
+	return result, nil
+}
+
+// list applies the request's paging, sorting and filters on top of the given scope
+func (s *WifiService) list(filter repositories.WifiListFilter, req *ListWifiRequest) (*ListWifiResult, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	sort := req.Sort
+	if sort == "" {
+		sort = "created_at"
+	}
+	order := req.Order
+	if order == "" {
+		order = "desc"
+		if sort == "ssid" {
+			order = "asc"
+		}
+	}
+
+	filter.SecurityType = req.SecurityType
+	filter.IsHidden = req.IsHidden
+	filter.Search = strings.TrimSpace(req.Query)
+	filter.SortBy = sort
+	filter.Descending = order == "desc"
+	filter.Limit = limit
+
+	for _, tag := range req.Tags {
+		tagID, err := uuid.Parse(tag)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		filter.TagIDs = append(filter.TagIDs, tagID)
+	}
+	if req.LocationID != "" {
+		locationID, err := uuid.Parse(req.LocationID)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		filter.LocationID = &locationID
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeListCursor(req.Cursor)
+		if err != nil || cursor.Sort != sort || cursor.Order != order {
+			return nil, ErrInvalidCursor
+		}
+		filter.After = &repositories.ListCursor{Value: cursor.Value, ID: cursor.ID}
+		if sort != "ssid" {
+			after, err := time.Parse(cursorTimeLayout, cursor.Value)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			filter.After.Time = after
+		}
+	}
+
+	credentials, total, err := s.wifiRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WiFi credentials: %w", err)
+	}
+
+	result := &ListWifiResult{
+		Credentials: credentials,
+		Pagination: Pagination{
+			Total: total,
+			Limit: limit,
+		},
+	}
+
+	// The repository fetches one extra row to tell whether another page follows
+	if len(credentials) > limit {
+		result.Credentials = credentials[:limit]
+		last := result.Credentials[limit-1]
+		result.Pagination.HasMore = true
+		result.Pagination.NextCursor = encodeListCursor(listCursor{
+			Sort:  sort,
+			Order: order,
+			Value: cursorValue(&last, sort),
+			ID:    last.ID,
+		})
+	}
+
+	return result, nil
+}
+
+// cursorValue returns a credential's value for the sort column as stored in a cursor
+func cursorValue(credential *models.WifiCredential, sort string) string {
+	switch sort {
+	case "updated_at":
+		return credential.UpdatedAt.Format(cursorTimeLayout)
+	case "ssid":
+		return credential.SSID
+	default:
+		return credential.CreatedAt.Format(cursorTimeLayout)
+	}
+}
+
+// encodeListCursor serializes a cursor to an opaque URL-safe string
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor parses a cursor produced by encodeListCursor
+func decodeListCursor(encoded string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gin-quickstart/internal/models"
+)
+
+var (
+	ErrNotesTooLong        = fmt.Errorf("notes must be at most %d bytes", maxNotesBytes)
+	ErrInvalidCustomFields = errors.New("invalid custom fields")
+)
+
+const (
+	maxNotesBytes            = 10000
+	maxCustomFields          = 50
+	maxCustomFieldKeyBytes   = 100
+	maxCustomFieldValueBytes = 1000
+)
+
+// validateNotes checks the notes and custom fields of a credential. Keys are
+// trimmed, must be unique (ignoring case) and can't be empty.
+func validateNotes(notes string, fields []models.CustomField) error {
+	if len(notes) > maxNotesBytes {
+		return ErrNotesTooLong
+	}
+	if len(fields) > maxCustomFields {
+		return fmt.Errorf("%w: at most %d fields are allowed", ErrInvalidCustomFields, maxCustomFields)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for i := range fields {
+		fields[i].Key = strings.TrimSpace(fields[i].Key)
+		key := fields[i].Key
+		switch {
+		case key == "":
+			return fmt.Errorf("%w: field %d has no key", ErrInvalidCustomFields, i+1)
+		case len(key) > maxCustomFieldKeyBytes:
+			return fmt.Errorf("%w: key %q must be at most %d bytes", ErrInvalidCustomFields, key, maxCustomFieldKeyBytes)
+		case len(fields[i].Value) > maxCustomFieldValueBytes:
+			return fmt.Errorf("%w: value of %q must be at most %d bytes", ErrInvalidCustomFields, key, maxCustomFieldValueBytes)
+		case seen[strings.ToLower(key)]:
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidCustomFields, key)
+		}
+		seen[strings.ToLower(key)] = true
+	}
+	return nil
+}
+
+// encryptNotes encrypts notes and custom fields the same way as passwords.
+// Empty notes and an empty field list are stored as empty strings.
+func (s *WifiService) encryptNotes(notes string, fields []models.CustomField) (string, string, error) {
+	encryptedNotes, err := s.encryptPassword(notes)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt notes: %w", err)
+	}
+
+	if len(fields) == 0 {
+		return encryptedNotes, "", nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode custom fields: %w", err)
+	}
+	encryptedFields, err := s.encryptPassword(string(data))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt custom fields: %w", err)
+	}
+	return encryptedNotes, encryptedFields, nil
+}
+
+// DecryptNotes decrypts the notes and custom fields of a credential
+func (s *WifiService) DecryptNotes(credential *models.WifiCredential) (string, []models.CustomField, error) {
+	notes, err := s.DecryptPassword(credential.EncryptedNotes)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt notes: %w", err)
+	}
+
+	data, err := s.DecryptPassword(credential.EncryptedFields)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt custom fields: %w", err)
+	}
+	var fields []models.CustomField
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &fields); err != nil {
+			return "", nil, fmt.Errorf("failed to decode custom fields: %w", err)
+		}
+	}
+	return notes, fields, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"log"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// passwordCheckBatchSize is how many credentials the backfill decrypts per query
+const passwordCheckBatchSize = 100
+
+// setPasswordChecks stores the fingerprint and weakness flags of password on credential.
+// It must run after the SSID, security type and key format are set.
+func (s *WifiService) setPasswordChecks(credential *models.WifiCredential, password string) {
+	short, dictionary := s.passwordChecker.Check(password, credential.SSID, credential.SecurityType, credential.KeyFormat)
+	credential.PasswordFingerprint = s.fingerprintPassword(password)
+	credential.PasswordShort = &short
+	credential.PasswordDictionary = &dictionary
+}
+
+// BackfillPasswordChecks fingerprints and checks the passwords of credentials saved
+// before the checks existed, including those in the trash. Passwords that can't be
+// decrypted are logged and left unchecked, so health reports list them. It only
+// decrypts unchecked credentials, so it is cheap to run on every start.
+func (s *WifiService) BackfillPasswordChecks() {
+	checked, failed := 0, 0
+
+	var afterID uuid.UUID
+	for {
+		credentials, err := s.wifiRepo.FindUncheckedPasswords(afterID, passwordCheckBatchSize)
+		if err != nil {
+			log.Printf("Password check backfill failed: %v", err)
+			return
+		}
+		if len(credentials) == 0 {
+			break
+		}
+
+		for i := range credentials {
+			credential := &credentials[i]
+			afterID = credential.ID
+
+			password, err := s.DecryptPassword(credential.EncryptedPassword)
+			if err != nil {
+				log.Printf("Password check backfill can't decrypt credential %s: %v", credential.ID, err)
+				failed++
+				continue
+			}
+
+			s.setPasswordChecks(credential, password)
+			if err := s.wifiRepo.UpdatePasswordChecks(credential); err != nil {
+				log.Printf("Password check backfill failed: %v", err)
+				return
+			}
+			checked++
+		}
+	}
+
+	if checked > 0 || failed > 0 {
+		log.Printf("Password check backfill checked %d credentials, %d couldn't be decrypted", checked, failed)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrInvalidRotationSchedule  = errors.New("invalid rotation schedule")
+	ErrInvalidValidityWindow    = errors.New("valid_until must be after valid_from")
+	ErrRotationRequiresPassword = errors.New("open networks have no password to rotate")
+)
+
+// minRotationInterval is the shortest gap allowed between two scheduled rotations
+const minRotationInterval = time.Hour
+
+// rotationParser accepts standard five-field cron expressions, descriptors such as
+// @daily and an optional CRON_TZ= prefix to run in a specific time zone
+var rotationParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// rotationAliases maps the named schedules to cron descriptors
+var rotationAliases = map[string]string{
+	"daily":  "@daily",  // Every day at midnight
+	"weekly": "@weekly", // Every Sunday at midnight
+}
+
+// parseRotationSchedule parses "daily", "weekly" or a cron expression
+func parseRotationSchedule(schedule string) (cron.Schedule, error) {
+	spec := schedule
+	if alias, ok := rotationAliases[schedule]; ok {
+		spec = alias
+	}
+
+	parsed, err := rotationParser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRotationSchedule, err)
+	}
+
+	// Guest devices have to reconnect after every rotation, so refuse schedules that fire constantly
+	first := parsed.Next(time.Now())
+	if parsed.Next(first).Sub(first) < minRotationInterval {
+		return nil, fmt.Errorf("%w: must not run more than once an hour", ErrInvalidRotationSchedule)
+	}
+	return parsed, nil
+}
+
+// validateValidity checks that a validity window, if bounded on both ends, is not empty
+func validateValidity(validFrom, validUntil *time.Time) error {
+	if validFrom != nil && validUntil != nil && !validUntil.After(*validFrom) {
+		return ErrInvalidValidityWindow
+	}
+	return nil
+}
+
+// validateRotation checks that a credential's rotation schedule can be applied to it
+func validateRotation(schedule string, securityType models.SecurityType) error {
+	if schedule == "" {
+		return nil
+	}
+	if securityType == models.SecurityNone {
+		return ErrRotationRequiresPassword
+	}
+	_, err := parseRotationSchedule(schedule)
+	return err
+}
+
+// nextRotation returns when a credential should next be rotated after the given time:
+// never before valid_from, and not at all once the next slot falls after valid_until
+func nextRotation(credential *models.WifiCredential, after time.Time) (*time.Time, error) {
+	if credential.RotationSchedule == "" {
+		return nil, nil
+	}
+
+	schedule, err := parseRotationSchedule(credential.RotationSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.ValidFrom != nil && credential.ValidFrom.After(after) {
+		after = *credential.ValidFrom
+	}
+	next := schedule.Next(after)
+	if next.IsZero() || (credential.ValidUntil != nil && next.After(*credential.ValidUntil)) {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// Rotate replaces the password of a credential that is due for rotation with a generated
+// one, regenerates its QR code and schedules the next rotation. The old password stays in
+// the version history. Credentials that are no longer due (edited or rotated since they
+// were picked up) are left alone.
+func (s *WifiService) Rotate(id uuid.UUID, now time.Time) error {
+	credential, err := s.wifiRepo.FindByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get WiFi credential: %w", err)
+	}
+	if credential == nil || credential.NextRotationAt == nil || credential.NextRotationAt.After(now) {
+		return nil
+	}
+	previous := *credential
+
+	if err := s.rotatePassword(credential, now); err != nil {
+		return err
+	}
+
+	return s.saveWithVersion(credential, &previous, models.VersionChangeRotated, nil, nil, nil)
+}
+
+// rotatePassword gives credential a generated passphrase and a new QR code, and
+// reschedules its next rotation from now. Nothing is stored.
+func (s *WifiService) rotatePassword(credential *models.WifiCredential, now time.Time) error {
+	password, err := s.generatePassword(credential.SecurityType, nil)
+	if err != nil {
+		return err
+	}
+
+	encryptedPassword, err := s.encryptPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
+	qrCodeData, err := s.qrCodeService.GenerateWiFiQRCode(credential.SSID, password, credential.SecurityType, models.KeyFormatPassphrase, credential.IsHidden)
+	if err != nil {
+		return fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	// Schedule from now rather than from the missed slot, so downtime causes one rotation, not a burst
+	next, err := nextRotation(credential, now)
+	if err != nil {
+		return err
+	}
+
+	credential.EncryptedPassword = encryptedPassword
+	credential.KeyFormat = models.KeyFormatPassphrase
+	s.setPasswordChecks(credential, password)
+	credential.QRCodeData = qrCodeData
+	credential.LastRotatedAt = &now
+	credential.NextRotationAt = next
+	return nil
+}
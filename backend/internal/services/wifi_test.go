@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gin-quickstart/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestGetWithAccessHidesQRCodeFromViewers(t *testing.T) {
+	ownerID := uuid.New()
+	callerID := uuid.New()
+	organizationID := uuid.New()
+
+	tests := []struct {
+		name           string
+		organization   bool
+		role           models.OrgRole // Caller's role in the credential's organization, if any
+		grant          models.GrantPermission
+		wantPermission models.GrantPermission
+		wantErr        error
+	}{
+		{name: "view grantee", grant: models.GrantView, wantPermission: models.GrantView},
+		{name: "reveal grantee", grant: models.GrantReveal, wantPermission: models.GrantReveal},
+		{name: "edit grantee", grant: models.GrantEdit, wantPermission: models.GrantEdit},
+		{name: "no grant", wantErr: ErrUnauthorizedAccess},
+		{name: "organization viewer", organization: true, role: models.OrgRoleViewer, wantPermission: models.GrantView},
+		{name: "organization viewer with reveal grant", organization: true, role: models.OrgRoleViewer, grant: models.GrantReveal, wantPermission: models.GrantReveal},
+		{name: "organization member", organization: true, role: models.OrgRoleMember, wantPermission: models.GrantEdit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newImportTestService(t)
+			credentialID := uuid.New()
+
+			credentialRows := sqlmock.NewRows([]string{"id", "user_id", "organization_id", "ssid", "security_type", "qr_code_data"})
+			if tt.organization {
+				credentialRows.AddRow(credentialID, ownerID, organizationID, "Office", models.SecurityWPA2, "cXI=")
+			} else {
+				credentialRows.AddRow(credentialID, ownerID, nil, "Office", models.SecurityWPA2, "cXI=")
+			}
+			mock.ExpectQuery(`FROM "wifi_qr_codes"`).WillReturnRows(credentialRows)
+			mock.ExpectQuery(`FROM "wifi_credential_tags"`).WillReturnRows(sqlmock.NewRows([]string{"credential_id", "tag_id"}))
+
+			if tt.organization {
+				memberships := sqlmock.NewRows([]string{"id", "organization_id", "user_id", "role"}).
+					AddRow(uuid.New(), organizationID, callerID, tt.role)
+				mock.ExpectQuery(`FROM "organization_members"`).WillReturnRows(memberships)
+			}
+			if !tt.role.AtLeast(models.OrgRoleMember) {
+				grants := sqlmock.NewRows([]string{"id", "credential_id", "grantee_id", "permission", "granted_by"})
+				if tt.grant != "" {
+					grants.AddRow(uuid.New(), credentialID, callerID, tt.grant, ownerID)
+				}
+				mock.ExpectQuery(`FROM "credential_grants"`).WillReturnRows(grants)
+			}
+
+			credential, permission, err := service.GetWithAccess(credentialID, callerID, false)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetWithAccess: %v", err)
+			}
+			if permission != tt.wantPermission {
+				t.Errorf("permission = %q, want %q", permission, tt.wantPermission)
+			}
+
+			public := credential.ToPublicFor(permission)
+			if wantQR := permission.Includes(models.GrantReveal); (public.QRCodeData != "") != wantQR {
+				t.Errorf("qr_code_data = %q, want it included: %v", public.QRCodeData, wantQR)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGeneratePasswordPolicyErrorsAreValidationErrors(t *testing.T) {
+	service, _ := newImportTestService(t)
+
+	tests := []struct {
+		name    string
+		policy  *PasswordPolicy
+		wantErr error
+	}{
+		{
+			name:    "shorter than the character classes",
+			policy:  &PasswordPolicy{Mode: PasswordModeRandom, Length: 3, Lowercase: true, Uppercase: true, Digits: true, Symbols: true},
+			wantErr: ErrLengthTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.generatePassword(models.SecurityWPA2, tt.policy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !IsValidationError(err) {
+				t.Errorf("IsValidationError(%v) = false", err)
+			}
+		})
+	}
+
+	for _, err := range []error{ErrNoCharacterClasses, ErrPasswordTooLong, ErrLengthTooShort} {
+		if !IsValidationError(fmt.Errorf("failed to generate password: %w", err)) {
+			t.Errorf("IsValidationError(%v) = false", err)
+		}
+	}
+}
+
+func TestGenerateWordsAlwaysFitsWPALimit(t *testing.T) {
+	generator := NewPasswordGenerator()
+
+	longest := 0
+	for _, word := range generator.words {
+		longest = max(longest, len(word))
+	}
+	// Longest words, one character separators and the trailing digit
+	if worst := maxPassphraseWords*longest + maxPassphraseWords - 1 + 1; worst > 63 {
+		t.Fatalf("%d words can take %d characters, more than 63", maxPassphraseWords, worst)
+	}
+
+	policy := PasswordPolicy{Mode: PasswordModeWords, Words: maxPassphraseWords, Capitalize: true, IncludeNumber: true}
+	for i := 0; i < 100; i++ {
+		generated, err := generator.Generate(policy)
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		if len(generated.Password) > 63 {
+			t.Fatalf("password %q has %d characters", generated.Password, len(generated.Password))
+		}
+	}
+
+	policy.Words = maxPassphraseWords + 1
+	if _, err := generator.Generate(policy); !errors.Is(err, ErrPasswordTooLong) {
+		t.Errorf("error = %v, want %v", err, ErrPasswordTooLong)
+	}
+}
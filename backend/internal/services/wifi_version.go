@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gin-quickstart/internal/models"
+	"gin-quickstart/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrVersionNotFound = errors.New("WiFi credential version not found")
+
+// ListVersions retrieves the change history of a WiFi credential, newest first
+func (s *WifiService) ListVersions(id uuid.UUID, userID uuid.UUID, isAdmin bool) ([]*models.PublicWifiCredentialVersion, error) {
+	if _, err := s.GetByID(id, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.versionRepo.FindByCredentialID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WiFi credential versions: %w", err)
+	}
+
+	// Versions come oldest first so each can be compared with its predecessor
+	public := make([]*models.PublicWifiCredentialVersion, len(versions))
+	for i := range versions {
+		var previous *models.WifiCredentialVersion
+		if i > 0 {
+			previous = &versions[i-1]
+		}
+		public[len(versions)-1-i] = versions[i].ToPublic(previous)
+	}
+	return public, nil
+}
+
+// RestoreVersion rolls a WiFi credential back to an earlier version and regenerates its QR code.
+// The rollback itself is recorded as a new version, so it can be undone too. Like
+// Update, it returns ErrVersionMismatch unless the credential is still at ifMatch;
+// an ifMatch of 0 skips that check.
+func (s *WifiService) RestoreVersion(id uuid.UUID, version int, userID uuid.UUID, isAdmin bool, ifMatch int) (*models.WifiCredential, error) {
+	credential, err := s.GetWithPermission(id, userID, isAdmin, models.GrantEdit)
+	if err != nil {
+		return nil, err
+	}
+	if ifMatch != 0 && credential.Version != ifMatch {
+		return nil, ErrVersionMismatch
+	}
+	previous := *credential
+
+	target, err := s.versionRepo.FindByVersion(id, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WiFi credential version: %w", err)
+	}
+	if target == nil {
+		return nil, ErrVersionNotFound
+	}
+
+	password, err := s.DecryptPassword(target.EncryptedPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	keyFormat := target.KeyFormat
+	if keyFormat == "" {
+		keyFormat = models.KeyFormatPassphrase
+	}
+
+	qrCodeData, err := s.qrCodeService.GenerateWiFiQRCode(target.SSID, password, target.SecurityType, keyFormat, target.IsHidden)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	credential.SSID = target.SSID
+	credential.EncryptedPassword = target.EncryptedPassword
+	credential.SecurityType = target.SecurityType
+	credential.KeyFormat = keyFormat
+	credential.IsHidden = target.IsHidden
+	credential.QRCodeData = qrCodeData
+	s.setPasswordChecks(credential, password)
+
+	if err := s.saveWithVersion(credential, &previous, models.VersionChangeRestored, &userID, &version, nil); err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// saveWithVersion creates (previous == nil) or updates a credential and appends a
+// version for its new state in the same transaction. Credentials saved before
+// history was kept get their previous state recorded first, so the change can be undone.
+// Changes that only touch unversioned fields (tags, location) add no version.
+// A non-nil tagIDs replaces the credential's tags, which are reloaded afterwards.
+func (s *WifiService) saveWithVersion(credential, previous *models.WifiCredential, change models.VersionChange, changedBy *uuid.UUID, restoredFrom *int, tagIDs *[]uuid.UUID) error {
+	err := s.transactor.WithinTransaction(func(tx *gorm.DB) error {
+		return s.saveWithVersionTx(tx, credential, previous, change, changedBy, restoredFrom, tagIDs)
+	})
+	if err != nil {
+		return err
+	}
+	s.events.Publish(credential.ID)
+	return nil
+}
+
+// saveWithVersionTx is saveWithVersion inside a transaction the caller owns. Callers
+// publish changes to existing credentials once the transaction has committed.
+func (s *WifiService) saveWithVersionTx(tx *gorm.DB, credential, previous *models.WifiCredential, change models.VersionChange, changedBy *uuid.UUID, restoredFrom *int, tagIDs *[]uuid.UUID) error {
+	wifiRepo := s.wifiRepo.WithTx(tx)
+	versionRepo := s.versionRepo.WithTx(tx)
+	tagRepo := s.tagRepo.WithTx(tx)
+
+	// Writing the credential first locks its row, so concurrent changes
+	// get consecutive version numbers
+	if previous == nil {
+		if err := wifiRepo.Create(credential); err != nil {
+			return fmt.Errorf("failed to create WiFi credential: %w", err)
+		}
+	} else {
+		if err := wifiRepo.Update(credential); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVersionMismatch
+			}
+			return fmt.Errorf("failed to update WiFi credential: %w", err)
+		}
+	}
+
+	if tagIDs != nil {
+		if err := tagRepo.ReplaceForCredential(credential.ID, *tagIDs); err != nil {
+			return err
+		}
+		tags, err := tagRepo.FindByIDs(repositories.ScopeOf(credential.UserID, credential.OrganizationID), *tagIDs)
+		if err != nil {
+			return err
+		}
+		credential.Tags = tags
+	}
+
+	if previous != nil && !versionedFieldsChanged(previous, credential) {
+		return nil
+	}
+
+	latest, err := versionRepo.LatestVersion(credential.ID)
+	if err != nil {
+		return err
+	}
+
+	if latest == 0 && previous != nil {
+		baseline := newCredentialVersion(previous, 1, models.VersionChangeCreated, nil)
+		baseline.CreatedAt = previous.UpdatedAt
+		if err := versionRepo.Create(baseline); err != nil {
+			return err
+		}
+		latest = 1
+	}
+
+	version := newCredentialVersion(credential, latest+1, change, changedBy)
+	version.RestoredFrom = restoredFrom
+	return versionRepo.Create(version)
+}
+
+// versionedFieldsChanged reports whether a change touched any field kept in the version history
+func versionedFieldsChanged(before, after *models.WifiCredential) bool {
+	return before.SSID != after.SSID ||
+		before.EncryptedPassword != after.EncryptedPassword ||
+		before.SecurityType != after.SecurityType ||
+		before.KeyFormat != after.KeyFormat ||
+		before.IsHidden != after.IsHidden
+}
+
+// newCredentialVersion snapshots a credential as the given version
+func newCredentialVersion(credential *models.WifiCredential, version int, change models.VersionChange, changedBy *uuid.UUID) *models.WifiCredentialVersion {
+	return &models.WifiCredentialVersion{
+		CredentialID:        credential.ID,
+		Version:             version,
+		SSID:                credential.SSID,
+		EncryptedPassword:   credential.EncryptedPassword,
+		PasswordFingerprint: credential.PasswordFingerprint,
+		SecurityType:        credential.SecurityType,
+		KeyFormat:           credential.KeyFormat,
+		IsHidden:            credential.IsHidden,
+		ChangeType:          change,
+		ChangedBy:           changedBy,
+	}
+}
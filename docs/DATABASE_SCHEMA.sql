@@ -5,6 +5,9 @@
 -- Enable UUID extension
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
+-- Enable trigram extension (fuzzy SSID search)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 -- ============================================================================
 -- TABLES
 -- ============================================================================
@@ -16,28 +19,280 @@ CREATE TABLE users (
     email VARCHAR(255) NOT NULL UNIQUE,
     password_hash VARCHAR(255) NOT NULL,
     role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    plan VARCHAR(20) NOT NULL DEFAULT 'free' CHECK (plan IN ('free', 'pro', 'business')),
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
     deleted_at TIMESTAMP NULL
 );
 
+-- Table: organizations
+-- Shared workspaces (e.g. one per client company) whose members manage credentials together
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    plan VARCHAR(20) NOT NULL DEFAULT 'free' CHECK (plan IN ('free', 'pro', 'business')),
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Table: organization_members
+-- Users belonging to an organization and their role in it
+CREATE TABLE organization_members (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')), -- Each role includes the ones after it
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_organization_member UNIQUE (organization_id, user_id),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_organization_members_organization_id FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_organization_members_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- Table: organization_invitations
+-- Email invitations to join an organization; only a SHA-256 hash of each token is stored
+CREATE TABLE organization_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by UUID NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_organization_invitations_organization_id FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_organization_invitations_invited_by FOREIGN KEY (invited_by)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- Table: tags
+-- Labels for organising WiFi credentials, personal (organization_id NULL) or shared by an organization
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL, -- Creator
+    organization_id UUID NULL,
+    name VARCHAR(50) NOT NULL, -- Unique per user or organization, see uq_tags_* indexes
+    color VARCHAR(7) NULL, -- Hex color, e.g. #1e88e5
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_tags_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_tags_organization_id FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE
+);
+
+-- Table: locations
+-- Site -> Building -> Floor hierarchy (plus free-form folders) for grouping credentials
+CREATE TABLE locations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL, -- Creator
+    organization_id UUID NULL, -- NULL for personal locations
+    parent_id UUID NULL, -- NULL for top-level locations
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('site', 'building', 'floor', 'folder')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_locations_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_locations_organization_id FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_locations_parent_id FOREIGN KEY (parent_id)
+        REFERENCES locations(id)
+        ON DELETE RESTRICT -- Locations must be emptied before they are deleted
+);
+
 -- Table: wifi_qr_codes
 -- Stores WiFi credentials and generated QR code data
 CREATE TABLE wifi_qr_codes (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    user_id UUID NOT NULL,
+    user_id UUID NOT NULL, -- Creator; the owner of personal credentials
+    organization_id UUID NULL, -- Owning organization, NULL for personal credentials
     ssid VARCHAR(32) NOT NULL,
     encrypted_password BYTEA NULL, -- NULL for open networks (nopass)
+    password_fingerprint VARCHAR(64) NULL, -- HMAC-SHA256 of the password, used for reuse detection
+    password_short BOOLEAN NULL, -- Weakness flags computed when the password is saved; NULL until checked
+    password_dictionary BOOLEAN NULL,
     security_type VARCHAR(10) NOT NULL CHECK (security_type IN ('WPA', 'WPA2', 'WEP', 'nopass')),
+    key_format VARCHAR(20) NOT NULL DEFAULT 'passphrase' CHECK (key_format IN ('passphrase', 'hex')), -- 'hex' for raw PSK / WEP hex keys
     is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
     qr_code_data TEXT NOT NULL, -- WiFi QR code string format
     qr_code_image_url VARCHAR(500) NULL, -- Optional: URL to stored image
+    encrypted_notes TEXT NULL, -- Free-form notes, AES-256-GCM like the password
+    encrypted_custom_fields TEXT NULL, -- JSON array of {key, value} pairs, AES-256-GCM like the password
+    location_id UUID NULL,
+    valid_from TIMESTAMP NULL, -- Guest credentials: start of the validity window
+    valid_until TIMESTAMP NULL, -- Guest credentials: end of the validity window
+    rotation_schedule VARCHAR(100) NULL, -- 'daily', 'weekly' or a cron expression
+    next_rotation_at TIMESTAMP NULL, -- NULL when no rotation is pending
+    last_rotated_at TIMESTAMP NULL,
+    version INTEGER NOT NULL DEFAULT 1, -- Incremented on every change; sent as ETag for If-Match checks
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
     deleted_at TIMESTAMP NULL,
 
-    -- Foreign key constraint
+    -- Foreign key constraints
     CONSTRAINT fk_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_location_id FOREIGN KEY (location_id)
+        REFERENCES locations(id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_organization_id FOREIGN KEY (organization_id)
+        REFERENCES organizations(id)
+        ON DELETE RESTRICT -- Organizations must be emptied (trash included) before they are deleted
+);
+
+-- Table: wifi_credential_tags
+-- Many-to-many link between WiFi credentials and tags
+CREATE TABLE wifi_credential_tags (
+    credential_id UUID NOT NULL,
+    tag_id UUID NOT NULL,
+
+    PRIMARY KEY (credential_id, tag_id),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_credential_tags_credential_id FOREIGN KEY (credential_id)
+        REFERENCES wifi_qr_codes(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_credential_tags_tag_id FOREIGN KEY (tag_id)
+        REFERENCES tags(id)
+        ON DELETE CASCADE
+);
+
+-- Table: wifi_credential_versions
+-- Immutable snapshot of a credential after every change, used for history and rollback
+CREATE TABLE wifi_credential_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    credential_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    ssid VARCHAR(32) NOT NULL,
+    encrypted_password BYTEA NULL, -- Same encryption as wifi_qr_codes
+    password_fingerprint VARCHAR(64) NULL,
+    security_type VARCHAR(10) NOT NULL CHECK (security_type IN ('WPA', 'WPA2', 'WEP', 'nopass')),
+    key_format VARCHAR(20) NOT NULL DEFAULT 'passphrase' CHECK (key_format IN ('passphrase', 'hex')),
+    is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    change_type VARCHAR(20) NOT NULL CHECK (change_type IN ('created', 'updated', 'restored', 'rotated')),
+    restored_from INTEGER NULL, -- Version rolled back to, for 'restored'
+    changed_by UUID NULL, -- NULL for changes made by the system
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_credential_version UNIQUE (credential_id, version),
+
+    -- Foreign key constraint
+    CONSTRAINT fk_credential_id FOREIGN KEY (credential_id)
+        REFERENCES wifi_qr_codes(id)
+        ON DELETE CASCADE
+);
+
+-- Table: credential_grants
+-- Access to a credential given by its owner to another registered user
+CREATE TABLE credential_grants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    credential_id UUID NOT NULL,
+    grantee_id UUID NOT NULL,
+    permission VARCHAR(20) NOT NULL CHECK (permission IN ('view', 'reveal', 'edit')), -- Each level includes the previous ones
+    granted_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_credential_grantee UNIQUE (credential_id, grantee_id),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_credential_grants_credential_id FOREIGN KEY (credential_id)
+        REFERENCES wifi_qr_codes(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_credential_grants_grantee_id FOREIGN KEY (grantee_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_credential_grants_granted_by FOREIGN KEY (granted_by)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- Table: share_links
+-- Revocable public links to a credential; only a SHA-256 hash of each token is stored
+CREATE TABLE share_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    credential_id UUID NOT NULL,
+    user_id UUID NOT NULL, -- Who created the link
+    type VARCHAR(20) NOT NULL DEFAULT 'link' CHECK (type IN ('link', 'kiosk')), -- kiosk: live QR stream for lobby screens
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    pin_hash VARCHAR(255) NULL, -- bcrypt hash of the optional PIN
+    show_password BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP NULL,
+    max_views INTEGER NULL CHECK (max_views > 0),
+    view_count INTEGER NOT NULL DEFAULT 0,
+    failed_pin_attempts INTEGER NOT NULL DEFAULT 0,
+    last_viewed_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_share_links_credential_id FOREIGN KEY (credential_id)
+        REFERENCES wifi_qr_codes(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_share_links_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- Table: audit_logs
+-- Append-only trail of access to sensitive data (e.g. password reveals)
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id UUID NULL,
+    ip_address VARCHAR(45) NULL,
+    user_agent VARCHAR(500) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    -- Foreign key constraint
+    CONSTRAINT fk_audit_logs_user_id FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- Table: idempotency_keys
+-- Responses to requests sent with an Idempotency-Key header, replayed for retries until they expire
+CREATE TABLE idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL, -- SHA-256 of the method, URL and body
+    status_code INTEGER NOT NULL DEFAULT 0, -- 0 while the request is still running
+    content_type VARCHAR(100) NULL,
+    encrypted_response_body TEXT NULL, -- AES-256-GCM like WiFi passwords
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_idempotency_keys_user_key UNIQUE (user_id, key),
+
+    -- Foreign key constraint
+    CONSTRAINT fk_idempotency_keys_user_id FOREIGN KEY (user_id)
         REFERENCES users(id)
         ON DELETE CASCADE
 );
@@ -56,6 +311,38 @@ CREATE INDEX idx_wifi_qr_codes_user_id ON wifi_qr_codes(user_id) WHERE deleted_a
 CREATE INDEX idx_wifi_qr_codes_created_at ON wifi_qr_codes(created_at DESC);
 CREATE INDEX idx_wifi_qr_codes_user_created ON wifi_qr_codes(user_id, created_at DESC) WHERE deleted_at IS NULL;
 CREATE INDEX idx_wifi_qr_codes_deleted_at ON wifi_qr_codes(deleted_at);
+CREATE INDEX idx_wifi_qr_codes_password_fingerprint ON wifi_qr_codes(user_id, password_fingerprint) WHERE deleted_at IS NULL;
+CREATE INDEX idx_wifi_qr_codes_next_rotation_at ON wifi_qr_codes(next_rotation_at) WHERE next_rotation_at IS NOT NULL AND deleted_at IS NULL;
+
+-- Tags and locations indexes
+CREATE INDEX idx_wifi_qr_codes_location_id ON wifi_qr_codes(location_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_wifi_credential_tags_tag_id ON wifi_credential_tags(tag_id);
+CREATE INDEX idx_locations_user_id ON locations(user_id);
+CREATE UNIQUE INDEX uq_tags_user_name ON tags(user_id, name) WHERE organization_id IS NULL;
+CREATE UNIQUE INDEX uq_tags_organization_name ON tags(organization_id, name) WHERE organization_id IS NOT NULL;
+CREATE INDEX idx_locations_parent_id ON locations(parent_id);
+
+-- Organizations indexes
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+CREATE INDEX idx_organization_invitations_organization_id ON organization_invitations(organization_id) WHERE accepted_at IS NULL;
+CREATE INDEX idx_wifi_qr_codes_organization_id ON wifi_qr_codes(organization_id, created_at DESC) WHERE organization_id IS NOT NULL;
+CREATE INDEX idx_tags_organization_id ON tags(organization_id) WHERE organization_id IS NOT NULL;
+CREATE INDEX idx_locations_organization_id ON locations(organization_id) WHERE organization_id IS NOT NULL;
+
+-- Credential grants table indexes
+CREATE INDEX idx_credential_grants_grantee_id ON credential_grants(grantee_id);
+
+-- Share links table indexes
+CREATE INDEX idx_share_links_credential_id ON share_links(credential_id, created_at DESC);
+CREATE INDEX idx_share_links_user_id ON share_links(user_id) WHERE revoked_at IS NULL;
+
+-- Audit logs table indexes
+CREATE INDEX idx_audit_logs_user_id ON audit_logs(user_id, created_at DESC);
+CREATE INDEX idx_audit_logs_resource_id ON audit_logs(resource_id, created_at DESC);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at DESC);
+
+-- Idempotency keys table indexes
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
 
 -- Full-text search index on SSID (for admin search functionality)
 CREATE INDEX idx_wifi_qr_codes_ssid_trgm ON wifi_qr_codes USING gin(ssid gin_trgm_ops);
@@ -80,9 +367,48 @@ CREATE TRIGGER update_users_updated_at
     EXECUTE FUNCTION update_updated_at_column();
 
 -- Trigger for wifi_qr_codes table
+-- The health report reads the password age from updated_at, so storing the password
+-- checks alone (as the startup backfill does) must not count as a change
 CREATE TRIGGER update_wifi_qr_codes_updated_at
     BEFORE UPDATE ON wifi_qr_codes
     FOR EACH ROW
+    WHEN ((to_jsonb(OLD) - ARRAY['password_fingerprint', 'password_short', 'password_dictionary', 'updated_at'])
+        IS DISTINCT FROM (to_jsonb(NEW) - ARRAY['password_fingerprint', 'password_short', 'password_dictionary', 'updated_at']))
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Migration for databases created with the earlier trigger, to run before deploying
+-- the password check backfill:
+--   DROP TRIGGER update_wifi_qr_codes_updated_at ON wifi_qr_codes;
+--   then create it again as above
+
+-- Trigger for credential_grants table
+CREATE TRIGGER update_credential_grants_updated_at
+    BEFORE UPDATE ON credential_grants
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Trigger for organizations table
+CREATE TRIGGER update_organizations_updated_at
+    BEFORE UPDATE ON organizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Trigger for organization_members table
+CREATE TRIGGER update_organization_members_updated_at
+    BEFORE UPDATE ON organization_members
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Trigger for tags table
+CREATE TRIGGER update_tags_updated_at
+    BEFORE UPDATE ON tags
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Trigger for locations table
+CREATE TRIGGER update_locations_updated_at
+    BEFORE UPDATE ON locations
+    FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
 
 -- ============================================================================
@@ -220,7 +546,7 @@ GRANT USAGE ON SCHEMA public TO wifiqr_app;
 -- ============================================================================
 
 -- Query to permanently delete soft-deleted records older than 90 days
--- Run this periodically as a scheduled job
+-- The API already purges the trash hourly (TRASH_RETENTION_DAYS); this is for manual cleanup
 CREATE OR REPLACE FUNCTION cleanup_old_deleted_records()
 RETURNS void AS $$
 BEGIN
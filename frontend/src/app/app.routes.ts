@@ -7,6 +7,7 @@ import { DashboardComponent } from './features/dashboard/dashboard.component';
 import { QrGeneratorComponent } from './features/qr-generator/qr-generator.component';
 import { MyCodesComponent } from './features/my-codes/my-codes.component';
 import { CredentialsComponent } from './features/admin/credentials/credentials.component';
+import { AcceptInvitationComponent } from './features/invitations/accept-invitation/accept-invitation.component';
 
 export const routes: Routes = [
   { path: '', redirectTo: '/dashboard', pathMatch: 'full' },
@@ -15,6 +16,7 @@ export const routes: Routes = [
   { path: 'dashboard', component: DashboardComponent, canActivate: [authGuard] },
   { path: 'qr-generator', component: QrGeneratorComponent, canActivate: [authGuard] },
   { path: 'my-codes', component: MyCodesComponent, canActivate: [authGuard] },
+  { path: 'invitations/accept', component: AcceptInvitationComponent, canActivate: [authGuard] },
   { path: 'admin/credentials', component: CredentialsComponent, canActivate: [authGuard, adminGuard] },
   { path: '**', redirectTo: '/dashboard' }
 ];
@@ -8,6 +8,7 @@ export interface WiFiCredential {
   securityType: SecurityType;
   hidden: boolean;
   qrCodeData: string;
+  version: number; // Sent as If-Match when changing the credential
   createdAt: string;
   updatedAt: string;
 }
@@ -31,6 +32,19 @@ export interface BackendWiFiCredential {
   security_type: SecurityType;
   is_hidden: boolean;
   qr_code_data: string;
+  version: number;
   created_at: string;
   updated_at: string;
 }
+
+export interface BackendPagination {
+  total: number;
+  limit: number;
+  next_cursor?: string;
+  has_more: boolean;
+}
+
+export interface BackendListResponse<T> {
+  data: T[];
+  pagination: BackendPagination;
+}
@@ -0,0 +1,27 @@
+import { Injectable, inject } from '@angular/core';
+import { HttpClient } from '@angular/common/http';
+import { Observable, catchError, throwError } from 'rxjs';
+
+export interface AcceptedOrganization {
+  id: string;
+  name: string;
+  role: string;
+}
+
+@Injectable({
+  providedIn: 'root'
+})
+export class OrganizationService {
+  private readonly http = inject(HttpClient);
+  private readonly apiUrl = 'http://localhost:8080/api';
+
+  acceptInvitation(token: string): Observable<AcceptedOrganization> {
+    return this.http.post<AcceptedOrganization>(`${this.apiUrl}/invitations/accept`, { token }).pipe(
+      catchError(error => {
+        console.error('Failed to accept invitation', error);
+        const errorMessage = error.error?.message || error.error?.error || error.message || 'Failed to accept invitation';
+        return throwError(() => new Error(errorMessage));
+      })
+    );
+  }
+}
@@ -1,8 +1,8 @@
 import { Injectable, inject } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
-import { Observable, catchError, throwError } from 'rxjs';
-import { WiFiCredential, CreateWiFiRequest, WiFiCredentialWithUser, BackendWiFiCredential } from '../models/wifi.model';
-import { map } from 'rxjs/operators';
+import { EMPTY, Observable, catchError, throwError } from 'rxjs';
+import { WiFiCredential, CreateWiFiRequest, WiFiCredentialWithUser, BackendWiFiCredential, BackendListResponse } from '../models/wifi.model';
+import { expand, map, reduce } from 'rxjs/operators';
 
 @Injectable({
   providedIn: 'root'
@@ -26,8 +26,8 @@ export class WiFiService {
   }
 
   getMyCredentials(): Observable<WiFiCredential[]> {
-    return this.http.get<BackendWiFiCredential[]>(`${this.apiUrl}/wifi`).pipe(
-      map(creds => creds.map(c => this.mapFromBackendCredential(c))),
+    return this.getAllPages<BackendWiFiCredential>(`${this.apiUrl}/wifi`).pipe(
+      map(data => data.map(c => this.mapFromBackendCredential(c))),
       catchError(this.handleError('Failed to load your WiFi credentials'))
     );
   }
@@ -39,15 +39,17 @@ export class WiFiService {
     );
   }
 
-  deleteCredential(id: string): Observable<void> {
-    return this.http.delete<void>(`${this.apiUrl}/wifi/${id}`).pipe(
+  deleteCredential(credential: WiFiCredential): Observable<void> {
+    // The backend refuses the delete with 412 if someone changed the credential since it was loaded
+    const headers = { 'If-Match': `"${credential.version}"` };
+    return this.http.delete<void>(`${this.apiUrl}/wifi/${credential.id}`, { headers }).pipe(
       catchError(this.handleError('Failed to delete WiFi credential'))
     );
   }
 
   getAllCredentials(): Observable<WiFiCredentialWithUser[]> {
-    return this.http.get<any[]>(`${this.apiUrl}/admin/credentials`).pipe(
-      map(creds => creds.map(c => this.mapFromBackendCredential(c) as WiFiCredentialWithUser)),
+    return this.getAllPages<any>(`${this.apiUrl}/admin/credentials`).pipe(
+      map(data => data.map(c => this.mapFromBackendCredential(c) as WiFiCredentialWithUser)),
       catchError(this.handleError('Failed to load all WiFi credentials'))
     );
   }
@@ -61,6 +63,22 @@ export class WiFiService {
     return `data:image/png;base64,${qrCodeData}`;
   }
 
+  // Lists are paged; follow next_cursor until the last page
+  private getAllPages<T>(url: string): Observable<T[]> {
+    const getPage = (cursor?: string) => {
+      const params: Record<string, string | number> = { limit: 100 };
+      if (cursor) {
+        params['cursor'] = cursor;
+      }
+      return this.http.get<BackendListResponse<T>>(url, { params });
+    };
+
+    return getPage().pipe(
+      expand(res => res.pagination.has_more && res.pagination.next_cursor ? getPage(res.pagination.next_cursor) : EMPTY),
+      reduce((all, res) => all.concat(res.data), [] as T[])
+    );
+  }
+
   private mapFromBackendCredential(backend: BackendWiFiCredential): WiFiCredential {
     return {
       id: backend.id,
@@ -69,6 +87,7 @@ export class WiFiService {
       securityType: backend.security_type,
       hidden: backend.is_hidden,
       qrCodeData: backend.qr_code_data,
+      version: backend.version,
       createdAt: backend.created_at,
       updatedAt: backend.updated_at
     };
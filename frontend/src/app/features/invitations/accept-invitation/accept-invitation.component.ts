@@ -0,0 +1,39 @@
+import { Component, OnInit, inject, signal } from '@angular/core';
+import { ActivatedRoute, RouterLink } from '@angular/router';
+import { AcceptedOrganization, OrganizationService } from '../../../core/services/organization.service';
+
+@Component({
+  selector: 'app-accept-invitation',
+  standalone: true,
+  imports: [RouterLink],
+  templateUrl: './accept-invitation.component.html',
+  styleUrls: ['./accept-invitation.component.scss']
+})
+export class AcceptInvitationComponent implements OnInit {
+  private readonly route = inject(ActivatedRoute);
+  private readonly organizationService = inject(OrganizationService);
+
+  readonly isLoading = signal(true);
+  readonly organization = signal<AcceptedOrganization | null>(null);
+  readonly errorMessage = signal<string | null>(null);
+
+  ngOnInit(): void {
+    const token = this.route.snapshot.queryParamMap.get('token');
+    if (!token) {
+      this.isLoading.set(false);
+      this.errorMessage.set('This invitation link is incomplete.');
+      return;
+    }
+
+    this.organizationService.acceptInvitation(token).subscribe({
+      next: (organization) => {
+        this.isLoading.set(false);
+        this.organization.set(organization);
+      },
+      error: (error) => {
+        this.isLoading.set(false);
+        this.errorMessage.set(error.message);
+      }
+    });
+  }
+}
@@ -46,14 +46,15 @@ export class MyCodesComponent implements OnInit {
     this.selectedCredential.set(null);
   }
 
-  deleteCredential(id: string, event: Event): void {
+  deleteCredential(credential: WiFiCredential, event: Event): void {
     event.stopPropagation();
 
     if (!confirm('Are you sure you want to delete this WiFi credential?')) {
       return;
     }
 
-    this.wifiService.deleteCredential(id).subscribe({
+    const id = credential.id;
+    this.wifiService.deleteCredential(credential).subscribe({
       next: () => {
         this.credentials.update(credentials =>
           credentials.filter(c => c.id !== id)